@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -12,12 +13,26 @@ import (
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
 	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/guardian"
+	"github.com/Will-Luck/Docker-Guardian/internal/httpapi"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
 	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
 	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+	"github.com/Will-Luck/Docker-Guardian/internal/privdrop"
+	"github.com/Will-Luck/Docker-Guardian/internal/shutdown"
 )
 
 func main() {
+	// Print the configuration schema for tooling and exit
+	if len(os.Args) > 1 && os.Args[1] == "config-schema" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(config.Schema()); err != nil {
+			fmt.Fprintf(os.Stderr, "writing schema: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Accept "autoheal" arg for backward compat with shell version's CMD ["autoheal"]
 	if len(os.Args) > 1 && os.Args[1] != "autoheal" {
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
@@ -29,17 +44,23 @@ func main() {
 		fmt.Fprintf(os.Stderr, "configuration error: %v\n", err)
 		os.Exit(1)
 	}
-	log := logging.New(cfg.LogJSON)
+	level, _ := logging.ParseLevel(cfg.LogLevel) // checked by Validate
+	log := logging.New(cfg.LogJSON, level)
 
 	// Banner: plain stdout for acceptance test compatibility
 	fmt.Println("Docker-Guardian (Go rewrite)")
 	fmt.Println("=============================================")
 	cfg.PrintBanner()
 
-	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	// First signal: stop taking new work and drain; second: abort in-flight actions
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+	stop, ctx, cancel := shutdown.Watch(context.Background(), sigs, log)
 	defer cancel()
 
-	client, err := docker.NewClient(cfg.DockerSock)
+	client, err := docker.NewClient(cfg.DockerEndpoint(),
+		docker.WithRateLimit(cfg.APIRateLimit),
+		docker.WithTLS(cfg.DockerTLSCA, cfg.DockerTLSCert, cfg.DockerTLSKey))
 	if err != nil {
 		log.Error("failed to create Docker client", "error", err)
 		os.Exit(1)
@@ -56,12 +77,27 @@ func main() {
 	metrics.Serve(cfg.MetricsPort)
 
 	g := guardian.New(cfg, client, dispatcher, log)
+	httpapi.Serve(cfg.StatusPort, g, cfg.AdminToken)
+	go reloadOnHangup(cfg, g, dispatcher, log)
+
+	// Socket access and listeners are in place; give up root if configured
+	if cfg.RunAsUID > 0 {
+		gid := cfg.RunAsGID
+		if gid == 0 {
+			gid = cfg.RunAsUID
+		}
+		if err := privdrop.Drop(cfg.RunAsUID, gid, cfg.DockerEndpoint()); err != nil {
+			log.Error("failed to drop privileges", "error", err)
+			os.Exit(1)
+		}
+		log.Info("dropped privileges", "uid", os.Geteuid(), "gid", os.Getegid())
+	}
 
 	if cfg.StartPeriod > 0 {
 		fmt.Printf("Monitoring containers in %d second(s)\n", cfg.StartPeriod)
 		select {
 		case <-time.After(time.Duration(cfg.StartPeriod) * time.Second):
-		case <-ctx.Done():
+		case <-stop.Done():
 			return
 		}
 	}
@@ -69,10 +105,52 @@ func main() {
 	dispatcher.Startup(fmt.Sprintf("Docker-Guardian started. Monitoring active. Services: %s",
 		dispatcher.ConfiguredServices()))
 
+	go func() {
+		<-stop.Done()
+		g.Stop()
+	}()
 	if err := g.Run(ctx); err != nil {
 		log.Error("guardian exited with error", "error", err)
 		os.Exit(1)
 	}
 
+	drained := g.Drain(ctx)
+	if !drained {
+		log.Warn("forced exit, in-flight actions abandoned")
+	}
+	// Even on a forced exit, keep the tracker history and send what is queued
+	if err := g.Tracker().Save(); err != nil {
+		log.Warn("failed to save state file", "path", cfg.StateFile, "error", err)
+	}
+	if drained {
+		log.Info("in-flight actions finished, flushing notifications")
+	}
 	dispatcher.Close()
+	if !drained {
+		os.Exit(1)
+	}
+}
+
+// reloadOnHangup re-reads AUTOHEAL_CONFIG_FILE on each SIGHUP and applies the
+// hot-reloadable settings. The process environment cannot change from
+// outside, so without the file there is nothing new to read. An invalid
+// result is logged and ignored, leaving the running config in place.
+func reloadOnHangup(cfg *config.Config, g *guardian.Guardian, dispatcher *notify.Dispatcher, log *logging.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if cfg.ConfigFile == "" {
+			log.Warn("ignoring SIGHUP: AUTOHEAL_CONFIG_FILE is not set")
+			continue
+		}
+		next := cfg.Reload(config.Load())
+		if err := next.Validate(); err != nil {
+			log.Warn("ignoring config reload", "error", err)
+			continue
+		}
+		cfg = next
+		g.Reload(cfg)
+		dispatcher.Reload(cfg)
+		log.Info("reloaded config", "notifications", dispatcher.ConfiguredServices())
+	}
 }
@@ -5,180 +5,592 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 )
 
 // Config holds all Docker-Guardian configuration from environment variables.
 // Every field maps 1:1 to the shell version's env vars for backward compatibility.
 type Config struct {
+	// Env file read over the environment, and re-read on SIGHUP
+	ConfigFile string `env:"AUTOHEAL_CONFIG_FILE" desc:"Env file of KEY=VALUE lines that override the environment; re-read on SIGHUP to apply reloadable settings"`
+
 	// Docker connection
-	DockerSock  string
-	CurlTimeout int // seconds (kept for env var compat, used as HTTP timeout)
+	DockerSock   string  `env:"DOCKER_SOCK" default:"/var/run/docker.sock" desc:"Docker socket path or tcp://host:port"`
+	CurlTimeout  int     `env:"CURL_TIMEOUT" default:"30" desc:"HTTP timeout in seconds for Docker API and notification requests"`
+	APIRateLimit float64 `env:"DOCKER_API_RATE_LIMIT" default:"0" desc:"Max Docker API requests per second (0 = unlimited)"`
+
+	// Remote Docker endpoint and its client TLS files (all three or none)
+	DockerHost    string `env:"DOCKER_HOST" desc:"Docker endpoint such as tcp://host:2376; overrides DOCKER_SOCK"`
+	DockerTLSCert string `env:"DOCKER_TLS_CERT" desc:"Client certificate for a TLS tcp:// Docker endpoint"`
+	DockerTLSKey  string `env:"DOCKER_TLS_KEY" secret:"true" desc:"Client key for a TLS tcp:// Docker endpoint"`
+	DockerTLSCA   string `env:"DOCKER_TLS_CA" desc:"CA certificate for a TLS tcp:// Docker endpoint"`
+
+	// Observation mode: log intended actions without changing any container
+	DryRun bool `env:"AUTOHEAL_DRY_RUN" default:"false" desc:"Log and notify intended actions without restarting, stopping or starting containers"`
 
 	// Core autoheal
-	ContainerLabel     string // "all" or label name
-	StartPeriod        int    // seconds
-	Interval           int    // seconds
-	DefaultStopTimeout int    // seconds
-	OnlyMonitorRunning bool
+	ContainerLabel        string `env:"AUTOHEAL_CONTAINER_LABEL" default:"autoheal" desc:"Label selecting monitored containers (all = every container)"`
+	StartPeriod           int    `env:"AUTOHEAL_START_PERIOD" default:"0" desc:"Seconds to wait before the first check"`
+	StartupLookback       int    `env:"AUTOHEAL_STARTUP_LOOKBACK" default:"0" desc:"Seconds of Docker event history replayed on startup (0 = disabled)"`
+	Interval              int    `env:"AUTOHEAL_INTERVAL" default:"5" desc:"Poll interval in seconds, and full-scan interval in event mode"`
+	DefaultStopTimeout    int    `env:"AUTOHEAL_DEFAULT_STOP_TIMEOUT" default:"10" desc:"Default stop timeout in seconds for restarts"`
+	OnlyMonitorRunning    bool   `env:"AUTOHEAL_ONLY_MONITOR_RUNNING" default:"false" desc:"Only monitor running containers for health"`
+	NotifyHealthcheckLoss bool   `env:"AUTOHEAL_NOTIFY_HEALTHCHECK_LOSS" default:"false" desc:"Notify once when a monitored container loses its health check"`
 
 	// Docker-Guardian extensions
-	MonitorDependencies  bool
-	DependencyStartDelay int // seconds
-	BackupLabel          string
-	BackupContainer      string
-	BackupTimeout        int    // seconds (0 = disabled)
-	GracePeriod          int    // seconds
-	WatchtowerCooldown   int    // seconds
-	WatchtowerScope      string // "all" or "affected"
-	WatchtowerEvents     string // "orchestration" or "all"
+	MonitorDependencies  bool   `env:"AUTOHEAL_MONITOR_DEPENDENCIES" default:"true" desc:"Start exited container:X dependents whose parent is running"`
+	DependencyStartDelay int    `env:"AUTOHEAL_DEPENDENCY_START_DELAY" default:"5" desc:"Seconds to wait before starting an orphaned dependent"`
+	DependencyScanLimit  int    `env:"DEPENDENCY_SCAN_LIMIT" default:"0" desc:"Max exited dependents inspected per cycle (0 = unlimited)"`
+	DependencyDieGrace   int    `env:"AUTOHEAL_DEPENDENCY_DIE_GRACE" default:"0" desc:"Seconds to let a die event settle before the orphan scan (0 = disabled)"`
+	DependencyMode       string `env:"AUTOHEAL_DEPENDENCY_MODE" default:"netns" desc:"How orphaned dependents are linked to what they need: netns (container:X network), compose (depends_on labels) or both"`
+	BackupLabel          string `env:"AUTOHEAL_BACKUP_LABEL" default:"docker-volume-backup.stop-during-backup" desc:"Label marking backup-managed containers"`
+	BackupContainer      string `env:"AUTOHEAL_BACKUP_CONTAINER" desc:"Backup container name (empty = auto-detect by image)"`
+	BackupActiveLabel    string `env:"BACKUP_ACTIVE_LABEL" desc:"Pause all actions while any running container has this label"`
+	BackupTimeout        int    `env:"AUTOHEAL_BACKUP_TIMEOUT" default:"600" desc:"Seconds a backup-managed container may stay stopped before it is acted on (0 = disabled)"`
+	GracePeriod          int    `env:"AUTOHEAL_GRACE_PERIOD" default:"300" desc:"Skip containers stopped within this many seconds" reload:"true"`
+	WatchtowerCooldown   int    `env:"AUTOHEAL_WATCHTOWER_COOLDOWN" default:"300" desc:"Skip actions within this many seconds of orchestration activity (0 = disabled)"`
+	WatchtowerScope      string `env:"AUTOHEAL_WATCHTOWER_SCOPE" default:"all" desc:"Containers skipped during orchestration: all or affected"`
+	WatchtowerEvents     string `env:"AUTOHEAL_WATCHTOWER_EVENTS" default:"orchestration" desc:"Events counted as orchestration: orchestration, all, or digest (orchestration plus image changes)"`
+	OrchestrationNotify  bool   `env:"AUTOHEAL_ORCHESTRATION_NOTIFY" default:"false" desc:"Still notify (rate-limited) when an action is deferred for orchestration activity"`
+	SwarmServices        bool   `env:"AUTOHEAL_SWARM_SERVICES" default:"false" desc:"Force-update Swarm services instead of restarting task containers"`
+	PauseOnNodeDrain     bool   `env:"AUTOHEAL_PAUSE_ON_NODE_DRAIN" default:"false" desc:"Pause all actions while this Swarm node is drained or paused"`
+	RestartExited        bool   `env:"AUTOHEAL_RESTART_EXITED" default:"false" desc:"Start monitored containers that exited with a non-zero code"`
+
+	// Host overload guard: defer actions while the host is thrashing
+	MaxHostLoad     float64 `env:"AUTOHEAL_MAX_HOST_LOAD" default:"0" desc:"Defer actions while the 1-minute load average is above this (0 = disabled)"`
+	MinHostMemoryMB int     `env:"AUTOHEAL_MIN_HOST_MEMORY_MB" default:"0" desc:"Defer actions while available memory is below this many MB (0 = disabled)"`
+
+	// Event stream
+	EventLivenessFailures int `env:"AUTOHEAL_EVENT_LIVENESS_FAILURES" default:"0" desc:"Silent 60s event windows before degrading to polling (0 = disabled)"`
+
+	// Global cap on events handled per second; die events are never dropped
+	EventProcessRate float64 `env:"EVENT_PROCESS_RATE" default:"0" desc:"Max Docker events handled per second, excess dropped (0 = unlimited)"`
+
+	// Run the startup scan in the background so events are handled immediately
+	StartupScanAsync bool `env:"AUTOHEAL_STARTUP_SCAN_ASYNC" default:"false" desc:"Handle events while the startup scan runs instead of after it"`
+
+	// Crash-loop detection after a successful restart
+	CrashloopWindow int `env:"AUTOHEAL_CRASHLOOP_WINDOW" default:"0" desc:"Seconds after a restart to check for a crash loop (0 = disabled)"`
+
+	// Post-restart recovery loop: wait for healthy, re-restart a bounded number of times
+	VerifyTimeout     int `env:"VERIFY_TIMEOUT" default:"0" desc:"Seconds to wait for a restarted container to become healthy (0 = disabled)"`
+	VerifyMaxRestarts int `env:"VERIFY_MAX_RESTARTS" default:"2" desc:"Extra restarts the recovery loop attempts before giving up"`
+
+	// Escalation to recreate after repeated restart failures
+	RecreateAfterFailures int `env:"AUTOHEAL_RECREATE_AFTER_FAILURES" default:"0" desc:"Consecutive failed restarts before recreating the container (0 = disabled)"`
+
+	// Pause between the stop and start of autoheal.action=hardrestart
+	HardRestartPause int `env:"AUTOHEAL_HARD_RESTART_PAUSE" default:"5" desc:"Seconds between stopping and starting a container for autoheal.action=hardrestart"`
+
+	// Removal of containers the guardian quarantined (action=stop)
+	QuarantineRemoveAfter int `env:"QUARANTINE_REMOVE_AFTER" default:"0" desc:"Seconds before a guardian-quarantined container is removed (0 = never)"`
+
+	// Misconfiguration audits re-run every AUDIT_INTERVAL seconds (0 = boot only)
+	AuditInterval int `env:"AUDIT_INTERVAL" default:"0" desc:"Seconds between misconfiguration audits (0 = at startup only)"`
+
+	// Decision history (ring buffer exposed via /status and metrics)
+	DecisionHistory int `env:"AUTOHEAL_DECISION_HISTORY" default:"100" desc:"Recent decisions kept for /status (0 = disabled)"`
+
+	// State persisted across guardian restarts (empty = in-memory only)
+	StateFile string `env:"AUTOHEAL_STATE_FILE" desc:"JSON file persisting state across guardian restarts (empty = in-memory only)"`
 
 	// Unhealthy threshold
-	UnhealthyThreshold int // consecutive unhealthy checks before action (1 = immediate)
+	UnhealthyThreshold       int `env:"AUTOHEAL_UNHEALTHY_THRESHOLD" default:"1" desc:"Consecutive unhealthy checks before action (1 = immediate)"`
+	NotifyUnhealthyThreshold int `env:"NOTIFY_UNHEALTHY_THRESHOLD" default:"1" desc:"Consecutive unhealthy checks before an early notification"`
+	UnhealthyMinDuration     int `env:"AUTOHEAL_UNHEALTHY_MIN_DURATION" default:"0" desc:"Seconds a failing health streak must span before action (0 = disabled)"`
+
+	// Host-level alarm on a sustained high unhealthy count
+	UnhealthyAlarmCount    int `env:"AUTOHEAL_UNHEALTHY_ALARM_COUNT" default:"0" desc:"Alarm when more than this many containers stay unhealthy (0 = disabled)"`
+	UnhealthyAlarmDuration int `env:"AUTOHEAL_UNHEALTHY_ALARM_DURATION" default:"60" desc:"Seconds the unhealthy count must stay above the alarm threshold"`
 
 	// Circuit breaker / backoff
-	BackoffMultiplier float64
-	BackoffMax        int // seconds
-	BackoffResetAfter int // seconds
-	RestartBudget     int
-	RestartWindow     int // seconds
+	BackoffMultiplier float64 `env:"AUTOHEAL_BACKOFF_MULTIPLIER" default:"2" desc:"Multiplier for exponential backoff between restarts" reload:"true"`
+	BackoffMax        int     `env:"AUTOHEAL_BACKOFF_MAX" default:"300" desc:"Maximum backoff delay in seconds" reload:"true"`
+	BackoffResetAfter int     `env:"AUTOHEAL_BACKOFF_RESET_AFTER" default:"600" desc:"Seconds without a restart before backoff resets" reload:"true"`
+	BackoffJitter     float64 `env:"AUTOHEAL_BACKOFF_JITTER" default:"0" desc:"Fraction (0-1) by which each backoff wait is randomised either way (0 = exact)" reload:"true"`
+	RestartBudget     int     `env:"AUTOHEAL_RESTART_BUDGET" default:"5" desc:"Maximum restarts per rolling window (0 = unlimited)" reload:"true"`
+	RestartWindow     int     `env:"AUTOHEAL_RESTART_WINDOW" default:"300" desc:"Rolling window for the restart budget in seconds" reload:"true"`
+
+	// Minimum wait after a restart so a container still booting is not restarted again
+	PostRestartCooldown int `env:"AUTOHEAL_POST_RESTART_COOLDOWN" default:"0" desc:"Seconds after a restart during which the container is not restarted again, whatever the backoff (0 = disabled)" reload:"true"`
+
+	// Notify when an open circuit auto-closes because the restart window elapsed
+	NotifyCircuitClose bool `env:"NOTIFY_CIRCUIT_CLOSE" default:"true" desc:"Notify when an open circuit auto-closes after the restart window"`
 
 	// Post-restart script
-	PostRestartScript string
+	PostRestartScript              string `env:"POST_RESTART_SCRIPT" desc:"Script run after a container restart or start"`
+	PostRestartScriptJSON          bool   `env:"POST_RESTART_SCRIPT_JSON" default:"false" desc:"Pass the post-restart script a JSON object on stdin instead of positional arguments"`
+	PostRestartScriptTimeout       int    `env:"POST_RESTART_SCRIPT_TIMEOUT" default:"30" desc:"Seconds a post-restart script may run before it and its children are killed (0 = no limit)"`
+	PostRestartScriptMaxConcurrent int    `env:"POST_RESTART_SCRIPT_MAX_CONCURRENT" default:"4" desc:"Maximum post-restart scripts running at once; further runs wait"`
 
 	// Notification events
-	NotifyEvents    string
-	NotifyRateLimit int    // seconds (0 = unlimited)
-	NotifyHostname  string // prepended to all notifications as [hostname]
+	NotifyEvents      string         `env:"NOTIFY_EVENTS" default:"actions" desc:"Notification categories to send" reload:"true"`
+	NotifyRateLimit   int            `env:"NOTIFY_RATE_LIMIT" default:"60" desc:"Minimum seconds between notifications per container (0 = unlimited)" reload:"true"`
+	NotifyDedupWindow int            `env:"NOTIFY_DEDUP_WINDOW" default:"0" desc:"Seconds an identical notification is suppressed (0 = disabled)" reload:"true"`
+	HeartbeatInterval int            `env:"HEARTBEAT_INTERVAL" default:"0" desc:"Seconds between heartbeat notifications (0 = disabled)"`
+	NotifyEnvironment string         `env:"NOTIFY_ENVIRONMENT" desc:"Environment tag prepended to all notifications, before the cluster"`
+	NotifyCluster     string         `env:"NOTIFY_CLUSTER" desc:"Cluster tag prepended to all notifications, before the hostname"`
+	NotifyHostname    string         `env:"NOTIFY_HOSTNAME" desc:"Hostname prepended to all notifications"`
+	NotifyFooter      string         `env:"NOTIFY_FOOTER" desc:"Template line appended to all notifications"`
+	NotifyMaxLength   int            `env:"NOTIFY_MAX_LENGTH" default:"0" desc:"Max message characters for every service (0 = per-service limits only)"`
+	NotifyLogsHint    string         `env:"NOTIFY_LOGS_HINT" desc:"Template line appended to container action notifications"`
+	NotifyTimeouts    map[string]int `env:"NOTIFY_<SERVICE>_TIMEOUT" desc:"Per-service notification timeout in seconds (default CURL_TIMEOUT)"`
+
+	// Notifications allowed per NOTIFY_RATE_LIMIT window before limiting
+	NotifyRateLimitBurst int `env:"NOTIFY_RATE_LIMIT_BURST" default:"1" desc:"Notifications allowed per container and event type within each NOTIFY_RATE_LIMIT window" reload:"true"`
+
+	// Digest mode: action notifications collected over a window and sent as one
+	NotifyBatchWindow int `env:"NOTIFY_BATCH_WINDOW" default:"0" desc:"Seconds to collect action notifications into one summary message (0 = send each immediately)"`
+
+	// Message templates replacing the built-in texts (empty = built-in)
+	NotifyTemplateAction  string `env:"NOTIFY_TEMPLATE_ACTION" desc:"Template for action notifications (empty = built-in message)"`
+	NotifyTemplateSkip    string `env:"NOTIFY_TEMPLATE_SKIP" desc:"Template for skip notifications (empty = built-in message)"`
+	NotifyTemplateStartup string `env:"NOTIFY_TEMPLATE_STARTUP" desc:"Template for startup notifications (empty = built-in message)"`
+
+	// Emergency scope overrides: container IDs (or ID prefixes) and name patterns
+	ContainerIDAllowlist []string `env:"CONTAINER_ID_ALLOWLIST" desc:"Container IDs or name globs that are the only ones managed"`
+	ContainerIDDenylist  []string `env:"CONTAINER_ID_DENYLIST" desc:"Container IDs or name globs that are never managed"`
+
+	// Exclusions for containers that flap by design (e.g. batch jobs)
+	ExcludeLabel string   `env:"AUTOHEAL_EXCLUDE_LABEL" desc:"Label marking containers the guardian never acts on"`
+	ExcludeNames []string `env:"AUTOHEAL_EXCLUDE_NAMES" desc:"Container name globs the guardian never acts on"`
+
+	// Daily windows (HH:MM-HH:MM, local time per TZ) in which no action is taken
+	MaintenanceWindows []string `env:"AUTOHEAL_MAINTENANCE_WINDOWS" desc:"Daily HH:MM-HH:MM windows (local time, see TZ) in which unhealthy containers are not restarted"`
+
+	// Event-driven behaviours enabled ("health", "orphan"; empty = all)
+	EventActions []string `env:"AUTOHEAL_EVENT_ACTIONS" desc:"Event-driven behaviours enabled: health, orphan, oom, crash (empty = all)"`
+
+	// Global notification cap across all containers (0 = unlimited)
+	NotifyMaxPerWindow int `env:"NOTIFY_MAX_PER_WINDOW" default:"0" desc:"Global cap on notifications per window (0 = unlimited)" reload:"true"`
+	NotifyGlobalWindow int `env:"NOTIFY_GLOBAL_WINDOW" default:"60" desc:"Window in seconds for NOTIFY_MAX_PER_WINDOW" reload:"true"`
+
+	// Append "Service X in project Y" to notifications about Compose containers
+	NotifyComposeNames bool `env:"NOTIFY_COMPOSE_NAMES" default:"false" desc:"Add the Compose service and project to container notifications"`
+
+	// Append the last healthcheck output to unhealthy-container notifications
+	NotifyIncludeHealthLog bool `env:"NOTIFY_INCLUDE_HEALTH_LOG" default:"true" desc:"Add the last healthcheck output to unhealthy container notifications"`
+
+	// Self-error alert: repeated Docker list/inspect failures within a window
+	NotifyErrorThreshold int `env:"NOTIFY_ERROR_THRESHOLD" default:"5" desc:"Internal errors within the window before a self-error alert (0 = disabled)"`
+	NotifyErrorWindow    int `env:"NOTIFY_ERROR_WINDOW" default:"300" desc:"Window in seconds for NOTIFY_ERROR_THRESHOLD"`
+
+	// Docker daemon unreachable alert: consecutive failed pings before it fires
+	NotifyDockerDownThreshold int `env:"NOTIFY_DOCKER_DOWN_THRESHOLD" default:"3" desc:"Consecutive failed daemon pings before a Docker unreachable alert (0 = disabled)"`
+
+	// Notification delivery pool: concurrent sends and queued sends per worker
+	NotifyWorkers   int `env:"NOTIFY_WORKERS" default:"4" desc:"Concurrent notification sends"`
+	NotifyQueueSize int `env:"NOTIFY_QUEUE_SIZE" default:"100" desc:"Pending notification sends per worker before dropping"`
 
 	// Notification services
-	WebhookURL     string
-	WebhookJSONKey string
-	AppriseURL     string
+	WebhookURL     string `env:"WEBHOOK_URL" desc:"Generic webhook URL" reload:"true"`
+	WebhookJSONKey string `env:"WEBHOOK_JSON_KEY" default:"text" desc:"JSON key holding the message for WEBHOOK_URL" reload:"true"`
+	WebhookSecret  string `env:"WEBHOOK_SECRET" secret:"true" desc:"Key for the X-Guardian-Signature HMAC-SHA256 header on WEBHOOK_URL posts" reload:"true"`
+	AppriseURL     string `env:"APPRISE_URL" desc:"Apprise API notify URL" reload:"true"`
+
+	GotifyURL   string `env:"NOTIFY_GOTIFY_URL" desc:"Gotify server URL" reload:"true"`
+	GotifyToken string `env:"NOTIFY_GOTIFY_TOKEN" secret:"true" desc:"Gotify application token" reload:"true"`
 
-	GotifyURL   string
-	GotifyToken string
+	DiscordWebhook string `env:"NOTIFY_DISCORD_WEBHOOK" secret:"true" desc:"Discord webhook URL" reload:"true"`
+	SlackWebhook   string `env:"NOTIFY_SLACK_WEBHOOK" secret:"true" desc:"Slack webhook URL" reload:"true"`
 
-	DiscordWebhook string
-	SlackWebhook   string
+	TelegramToken    string `env:"NOTIFY_TELEGRAM_TOKEN" secret:"true" desc:"Telegram bot token" reload:"true"`
+	TelegramChatID   string `env:"NOTIFY_TELEGRAM_CHAT_ID" desc:"Telegram chat ID, or a comma-separated list to send to each" reload:"true"`
+	TelegramThreadID int    `env:"NOTIFY_TELEGRAM_THREAD_ID" default:"0" desc:"Topic (message_thread_id) to post in for forum groups (0 = none)" reload:"true"`
 
-	TelegramToken  string
-	TelegramChatID string
+	PushoverToken string `env:"NOTIFY_PUSHOVER_TOKEN" secret:"true" desc:"Pushover application token" reload:"true"`
+	PushoverUser  string `env:"NOTIFY_PUSHOVER_USER" secret:"true" desc:"Pushover user key" reload:"true"`
 
-	PushoverToken string
-	PushoverUser  string
+	PushbulletToken string `env:"NOTIFY_PUSHBULLET_TOKEN" secret:"true" desc:"Pushbullet access token" reload:"true"`
+	LunaSeaWebhook  string `env:"NOTIFY_LUNASEA_WEBHOOK" secret:"true" desc:"LunaSea webhook URL" reload:"true"`
 
-	PushbulletToken string
-	LunaSeaWebhook  string
+	TeamsWebhook string `env:"NOTIFY_TEAMS_WEBHOOK" secret:"true" desc:"Microsoft Teams incoming webhook URL" reload:"true"`
 
-	EmailSMTP string
-	EmailFrom string
-	EmailTo   string
-	EmailUser string
-	EmailPass string
+	NtfyURL   string `env:"NOTIFY_NTFY_URL" secret:"true" desc:"ntfy server URL, e.g. https://ntfy.sh" reload:"true"`
+	NtfyTopic string `env:"NOTIFY_NTFY_TOPIC" desc:"ntfy topic to publish to" reload:"true"`
+
+	EmailSMTP string `env:"NOTIFY_EMAIL_SMTP" desc:"SMTP server as host:port" reload:"true"`
+	EmailFrom string `env:"NOTIFY_EMAIL_FROM" desc:"Email sender address" reload:"true"`
+	EmailTo   string `env:"NOTIFY_EMAIL_TO" desc:"Email recipient address" reload:"true"`
+	EmailUser string `env:"NOTIFY_EMAIL_USER" desc:"SMTP username (empty = no auth)" reload:"true"`
+	EmailPass string `env:"NOTIFY_EMAIL_PASS" secret:"true" desc:"SMTP password" reload:"true"`
 
 	// Metrics
-	MetricsPort int
+	MetricsPort int `env:"METRICS_PORT" default:"0" desc:"Prometheus metrics port (0 = disabled)"`
+
+	// HTTP API (healthz/readyz/status, control endpoints)
+	StatusPort int    `env:"STATUS_PORT" default:"0" desc:"HTTP API port for health, status and control endpoints (0 = disabled)"`
+	AdminToken string `env:"ADMIN_TOKEN" secret:"true" desc:"Bearer token for control endpoints (empty = control disabled)"`
+
+	// POST /restart-all: containers restarted at once
+	BulkRestartConcurrency int `env:"BULK_RESTART_CONCURRENCY" default:"4" desc:"Containers restarted at once by POST /restart-all"`
+
+	// Panic button: start with all actions halted
+	Disabled bool `env:"GUARDIAN_DISABLED" default:"false" desc:"Start with all actions halted"`
+
+	// Privilege dropping: switch to this user once the socket and listeners are open
+	RunAsUID int `env:"RUN_AS_UID" default:"0" desc:"UID to drop privileges to after startup (0 = keep current user)"`
+	RunAsGID int `env:"RUN_AS_GID" default:"0" desc:"GID to drop privileges to (0 = same as RUN_AS_UID)"`
 
 	// Logging
-	LogJSON bool
+	LogJSON  bool   `env:"LOG_JSON" default:"false" desc:"Log as JSON instead of text"`
+	LogLevel string `env:"LOG_LEVEL" default:"info" desc:"Minimum log level: debug, info, warn or error"`
+
+	// Values Load could not parse and replaced with the default; Validate
+	// reports them
+	parseErrs []error
 }
 
 // Load reads all configuration from environment variables with defaults
-// matching the shell version exactly.
+// matching the shell version exactly. Variables set in AUTOHEAL_CONFIG_FILE
+// take precedence over the environment. A value that does not parse falls
+// back to its default and is reported by Validate, as is an unreadable file.
 func Load() *Config {
-	return &Config{
-		DockerSock:  envStr("DOCKER_SOCK", "/var/run/docker.sock"),
-		CurlTimeout: envInt("CURL_TIMEOUT", 30),
+	env := &envReader{lookup: os.Getenv}
+	configFile := os.Getenv("AUTOHEAL_CONFIG_FILE")
+	if configFile != "" {
+		values, err := readEnvFile(configFile)
+		if err != nil {
+			env.errs = append(env.errs, fmt.Errorf("AUTOHEAL_CONFIG_FILE: %w", err))
+		} else {
+			env.lookup = func(key string) string {
+				if v, ok := values[key]; ok {
+					return v
+				}
+				return os.Getenv(key)
+			}
+		}
+	}
+
+	c := &Config{
+		ConfigFile: configFile,
+
+		DockerSock:   env.str("DOCKER_SOCK", "/var/run/docker.sock"),
+		CurlTimeout:  env.int("CURL_TIMEOUT", 30),
+		APIRateLimit: env.float("DOCKER_API_RATE_LIMIT", 0),
+
+		DockerHost:    env.str("DOCKER_HOST", ""),
+		DockerTLSCert: env.str("DOCKER_TLS_CERT", ""),
+		DockerTLSKey:  env.str("DOCKER_TLS_KEY", ""),
+		DockerTLSCA:   env.str("DOCKER_TLS_CA", ""),
+
+		DryRun: env.bool("AUTOHEAL_DRY_RUN", false),
+
+		ContainerLabel:        env.str("AUTOHEAL_CONTAINER_LABEL", "autoheal"),
+		StartPeriod:           env.int("AUTOHEAL_START_PERIOD", 0),
+		StartupLookback:       env.int("AUTOHEAL_STARTUP_LOOKBACK", 0),
+		Interval:              env.int("AUTOHEAL_INTERVAL", 5),
+		DefaultStopTimeout:    env.int("AUTOHEAL_DEFAULT_STOP_TIMEOUT", 10),
+		OnlyMonitorRunning:    env.bool("AUTOHEAL_ONLY_MONITOR_RUNNING", false),
+		NotifyHealthcheckLoss: env.bool("AUTOHEAL_NOTIFY_HEALTHCHECK_LOSS", false),
+
+		ContainerIDAllowlist: env.list("CONTAINER_ID_ALLOWLIST"),
+		ContainerIDDenylist:  env.list("CONTAINER_ID_DENYLIST"),
+
+		ExcludeLabel: env.str("AUTOHEAL_EXCLUDE_LABEL", ""),
+		ExcludeNames: env.list("AUTOHEAL_EXCLUDE_NAMES"),
+
+		MaintenanceWindows: env.list("AUTOHEAL_MAINTENANCE_WINDOWS"),
+
+		EventActions: env.list("AUTOHEAL_EVENT_ACTIONS"),
+
+		MonitorDependencies:  env.bool("AUTOHEAL_MONITOR_DEPENDENCIES", true),
+		DependencyStartDelay: env.int("AUTOHEAL_DEPENDENCY_START_DELAY", 5),
+		DependencyScanLimit:  env.int("DEPENDENCY_SCAN_LIMIT", 0),
+		DependencyDieGrace:   env.int("AUTOHEAL_DEPENDENCY_DIE_GRACE", 0),
+		DependencyMode:       env.str("AUTOHEAL_DEPENDENCY_MODE", "netns"),
+		BackupLabel:          env.str("AUTOHEAL_BACKUP_LABEL", "docker-volume-backup.stop-during-backup"),
+		BackupContainer:      env.str("AUTOHEAL_BACKUP_CONTAINER", ""),
+		BackupActiveLabel:    env.str("BACKUP_ACTIVE_LABEL", ""),
+		BackupTimeout:        env.int("AUTOHEAL_BACKUP_TIMEOUT", 600),
+		GracePeriod:          env.int("AUTOHEAL_GRACE_PERIOD", 300),
+		WatchtowerCooldown:   env.int("AUTOHEAL_WATCHTOWER_COOLDOWN", 300),
+		WatchtowerScope:      env.str("AUTOHEAL_WATCHTOWER_SCOPE", "all"),
+		WatchtowerEvents:     env.str("AUTOHEAL_WATCHTOWER_EVENTS", "orchestration"),
+		OrchestrationNotify:  env.bool("AUTOHEAL_ORCHESTRATION_NOTIFY", false),
+		SwarmServices:        env.bool("AUTOHEAL_SWARM_SERVICES", false),
+		PauseOnNodeDrain:     env.bool("AUTOHEAL_PAUSE_ON_NODE_DRAIN", false),
+		RestartExited:        env.bool("AUTOHEAL_RESTART_EXITED", false),
+
+		MaxHostLoad:     env.float("AUTOHEAL_MAX_HOST_LOAD", 0),
+		MinHostMemoryMB: env.int("AUTOHEAL_MIN_HOST_MEMORY_MB", 0),
+
+		EventLivenessFailures: env.int("AUTOHEAL_EVENT_LIVENESS_FAILURES", 0),
+		StartupScanAsync:      env.bool("AUTOHEAL_STARTUP_SCAN_ASYNC", false),
+		EventProcessRate:      env.float("EVENT_PROCESS_RATE", 0),
+
+		CrashloopWindow: env.int("AUTOHEAL_CRASHLOOP_WINDOW", 0),
+		AuditInterval:   env.int("AUDIT_INTERVAL", 0),
+		DecisionHistory: env.int("AUTOHEAL_DECISION_HISTORY", 100),
+		StateFile:       env.str("AUTOHEAL_STATE_FILE", ""),
+
+		VerifyTimeout:     env.int("VERIFY_TIMEOUT", 0),
+		VerifyMaxRestarts: env.int("VERIFY_MAX_RESTARTS", 2),
 
-		ContainerLabel:     envStr("AUTOHEAL_CONTAINER_LABEL", "autoheal"),
-		StartPeriod:        envInt("AUTOHEAL_START_PERIOD", 0),
-		Interval:           envInt("AUTOHEAL_INTERVAL", 5),
-		DefaultStopTimeout: envInt("AUTOHEAL_DEFAULT_STOP_TIMEOUT", 10),
-		OnlyMonitorRunning: envBool("AUTOHEAL_ONLY_MONITOR_RUNNING", false),
+		RecreateAfterFailures: env.int("AUTOHEAL_RECREATE_AFTER_FAILURES", 0),
+		QuarantineRemoveAfter: env.int("QUARANTINE_REMOVE_AFTER", 0),
 
-		MonitorDependencies:  envBool("AUTOHEAL_MONITOR_DEPENDENCIES", true),
-		DependencyStartDelay: envInt("AUTOHEAL_DEPENDENCY_START_DELAY", 5),
-		BackupLabel:          envStr("AUTOHEAL_BACKUP_LABEL", "docker-volume-backup.stop-during-backup"),
-		BackupContainer:      envStr("AUTOHEAL_BACKUP_CONTAINER", ""),
-		BackupTimeout:        envInt("AUTOHEAL_BACKUP_TIMEOUT", 600),
-		GracePeriod:          envInt("AUTOHEAL_GRACE_PERIOD", 300),
-		WatchtowerCooldown:   envInt("AUTOHEAL_WATCHTOWER_COOLDOWN", 300),
-		WatchtowerScope:      envStr("AUTOHEAL_WATCHTOWER_SCOPE", "all"),
-		WatchtowerEvents:     envStr("AUTOHEAL_WATCHTOWER_EVENTS", "orchestration"),
+		HardRestartPause: env.int("AUTOHEAL_HARD_RESTART_PAUSE", 5),
 
-		UnhealthyThreshold: envInt("AUTOHEAL_UNHEALTHY_THRESHOLD", 1),
+		UnhealthyThreshold:       env.int("AUTOHEAL_UNHEALTHY_THRESHOLD", 1),
+		NotifyUnhealthyThreshold: env.int("NOTIFY_UNHEALTHY_THRESHOLD", 1),
+		UnhealthyMinDuration:     env.int("AUTOHEAL_UNHEALTHY_MIN_DURATION", 0),
 
-		BackoffMultiplier: envFloat("AUTOHEAL_BACKOFF_MULTIPLIER", 2),
-		BackoffMax:        envInt("AUTOHEAL_BACKOFF_MAX", 300),
-		BackoffResetAfter: envInt("AUTOHEAL_BACKOFF_RESET_AFTER", 600),
-		RestartBudget:     envInt("AUTOHEAL_RESTART_BUDGET", 5),
-		RestartWindow:     envInt("AUTOHEAL_RESTART_WINDOW", 300),
+		UnhealthyAlarmCount:    env.int("AUTOHEAL_UNHEALTHY_ALARM_COUNT", 0),
+		UnhealthyAlarmDuration: env.int("AUTOHEAL_UNHEALTHY_ALARM_DURATION", 60),
 
-		PostRestartScript: envStr("POST_RESTART_SCRIPT", ""),
-		NotifyEvents:      envStr("NOTIFY_EVENTS", "actions"),
-		NotifyRateLimit:   envInt("NOTIFY_RATE_LIMIT", 60),
-		NotifyHostname:    envStr("NOTIFY_HOSTNAME", ""),
+		BackoffMultiplier: env.float("AUTOHEAL_BACKOFF_MULTIPLIER", 2),
+		BackoffMax:        env.int("AUTOHEAL_BACKOFF_MAX", 300),
+		BackoffResetAfter: env.int("AUTOHEAL_BACKOFF_RESET_AFTER", 600),
+		BackoffJitter:     env.float("AUTOHEAL_BACKOFF_JITTER", 0),
+		RestartBudget:     env.int("AUTOHEAL_RESTART_BUDGET", 5),
+		RestartWindow:     env.int("AUTOHEAL_RESTART_WINDOW", 300),
 
-		WebhookURL:     envStr("WEBHOOK_URL", ""),
-		WebhookJSONKey: envStr("WEBHOOK_JSON_KEY", "text"),
-		AppriseURL:     envStr("APPRISE_URL", ""),
+		PostRestartCooldown: env.int("AUTOHEAL_POST_RESTART_COOLDOWN", 0),
 
-		GotifyURL:   envStr("NOTIFY_GOTIFY_URL", ""),
-		GotifyToken: envStr("NOTIFY_GOTIFY_TOKEN", ""),
+		NotifyCircuitClose: env.bool("NOTIFY_CIRCUIT_CLOSE", true),
 
-		DiscordWebhook: envStr("NOTIFY_DISCORD_WEBHOOK", ""),
-		SlackWebhook:   envStr("NOTIFY_SLACK_WEBHOOK", ""),
+		PostRestartScriptJSON:          env.bool("POST_RESTART_SCRIPT_JSON", false),
+		PostRestartScriptTimeout:       env.int("POST_RESTART_SCRIPT_TIMEOUT", 30),
+		PostRestartScriptMaxConcurrent: env.int("POST_RESTART_SCRIPT_MAX_CONCURRENT", 4),
 
-		TelegramToken:  envStr("NOTIFY_TELEGRAM_TOKEN", ""),
-		TelegramChatID: envStr("NOTIFY_TELEGRAM_CHAT_ID", ""),
+		PostRestartScript: env.str("POST_RESTART_SCRIPT", ""),
+		NotifyEvents:      env.str("NOTIFY_EVENTS", "actions"),
+		NotifyRateLimit:   env.int("NOTIFY_RATE_LIMIT", 60),
+		NotifyDedupWindow: env.int("NOTIFY_DEDUP_WINDOW", 0),
+		HeartbeatInterval: env.int("HEARTBEAT_INTERVAL", 0),
+		NotifyEnvironment: env.str("NOTIFY_ENVIRONMENT", ""),
+		NotifyCluster:     env.str("NOTIFY_CLUSTER", ""),
+		NotifyHostname:    env.str("NOTIFY_HOSTNAME", ""),
+		NotifyFooter:      env.str("NOTIFY_FOOTER", ""),
+		NotifyMaxLength:   env.int("NOTIFY_MAX_LENGTH", 0),
+		NotifyLogsHint:    env.str("NOTIFY_LOGS_HINT", ""),
+		NotifyTimeouts:    env.notifyTimeouts(),
 
-		PushoverToken: envStr("NOTIFY_PUSHOVER_TOKEN", ""),
-		PushoverUser:  envStr("NOTIFY_PUSHOVER_USER", ""),
+		NotifyRateLimitBurst: env.int("NOTIFY_RATE_LIMIT_BURST", 1),
 
-		PushbulletToken: envStr("NOTIFY_PUSHBULLET_TOKEN", ""),
-		LunaSeaWebhook:  envStr("NOTIFY_LUNASEA_WEBHOOK", ""),
+		NotifyBatchWindow: env.int("NOTIFY_BATCH_WINDOW", 0),
 
-		EmailSMTP: envStr("NOTIFY_EMAIL_SMTP", ""),
-		EmailFrom: envStr("NOTIFY_EMAIL_FROM", ""),
-		EmailTo:   envStr("NOTIFY_EMAIL_TO", ""),
-		EmailUser: envStr("NOTIFY_EMAIL_USER", ""),
-		EmailPass: envStr("NOTIFY_EMAIL_PASS", ""),
+		NotifyTemplateAction:  env.str("NOTIFY_TEMPLATE_ACTION", ""),
+		NotifyTemplateSkip:    env.str("NOTIFY_TEMPLATE_SKIP", ""),
+		NotifyTemplateStartup: env.str("NOTIFY_TEMPLATE_STARTUP", ""),
 
-		MetricsPort: envInt("METRICS_PORT", 0),
+		NotifyMaxPerWindow: env.int("NOTIFY_MAX_PER_WINDOW", 0),
+		NotifyGlobalWindow: env.int("NOTIFY_GLOBAL_WINDOW", 60),
 
-		LogJSON: envBool("LOG_JSON", false),
+		NotifyComposeNames: env.bool("NOTIFY_COMPOSE_NAMES", false),
+
+		NotifyIncludeHealthLog: env.bool("NOTIFY_INCLUDE_HEALTH_LOG", true),
+
+		NotifyErrorThreshold: env.int("NOTIFY_ERROR_THRESHOLD", 5),
+		NotifyErrorWindow:    env.int("NOTIFY_ERROR_WINDOW", 300),
+
+		NotifyDockerDownThreshold: env.int("NOTIFY_DOCKER_DOWN_THRESHOLD", 3),
+
+		NotifyWorkers:   env.int("NOTIFY_WORKERS", 4),
+		NotifyQueueSize: env.int("NOTIFY_QUEUE_SIZE", 100),
+
+		WebhookURL:     env.str("WEBHOOK_URL", ""),
+		WebhookJSONKey: env.str("WEBHOOK_JSON_KEY", "text"),
+		WebhookSecret:  env.str("WEBHOOK_SECRET", ""),
+		AppriseURL:     env.str("APPRISE_URL", ""),
+
+		GotifyURL:   env.str("NOTIFY_GOTIFY_URL", ""),
+		GotifyToken: env.str("NOTIFY_GOTIFY_TOKEN", ""),
+
+		DiscordWebhook: env.str("NOTIFY_DISCORD_WEBHOOK", ""),
+		SlackWebhook:   env.str("NOTIFY_SLACK_WEBHOOK", ""),
+
+		TelegramToken:    env.str("NOTIFY_TELEGRAM_TOKEN", ""),
+		TelegramChatID:   env.str("NOTIFY_TELEGRAM_CHAT_ID", ""),
+		TelegramThreadID: env.int("NOTIFY_TELEGRAM_THREAD_ID", 0),
+
+		PushoverToken: env.str("NOTIFY_PUSHOVER_TOKEN", ""),
+		PushoverUser:  env.str("NOTIFY_PUSHOVER_USER", ""),
+
+		PushbulletToken: env.str("NOTIFY_PUSHBULLET_TOKEN", ""),
+		LunaSeaWebhook:  env.str("NOTIFY_LUNASEA_WEBHOOK", ""),
+
+		TeamsWebhook: env.str("NOTIFY_TEAMS_WEBHOOK", ""),
+
+		NtfyURL:   env.str("NOTIFY_NTFY_URL", ""),
+		NtfyTopic: env.str("NOTIFY_NTFY_TOPIC", ""),
+
+		EmailSMTP: env.str("NOTIFY_EMAIL_SMTP", ""),
+		EmailFrom: env.str("NOTIFY_EMAIL_FROM", ""),
+		EmailTo:   env.str("NOTIFY_EMAIL_TO", ""),
+		EmailUser: env.str("NOTIFY_EMAIL_USER", ""),
+		EmailPass: env.str("NOTIFY_EMAIL_PASS", ""),
+
+		MetricsPort: env.int("METRICS_PORT", 0),
+		StatusPort:  env.int("STATUS_PORT", 0),
+		AdminToken:  env.str("ADMIN_TOKEN", ""),
+
+		BulkRestartConcurrency: env.int("BULK_RESTART_CONCURRENCY", 4),
+
+		Disabled: env.bool("GUARDIAN_DISABLED", false),
+
+		RunAsUID: env.int("RUN_AS_UID", 0),
+		RunAsGID: env.int("RUN_AS_GID", 0),
+
+		LogJSON:  env.bool("LOG_JSON", false),
+		LogLevel: env.str("LOG_LEVEL", "info"),
 	}
+	c.parseErrs = env.errs
+	return c
+}
+
+// Reload returns a copy of c with the hot-reloadable settings (fields tagged
+// reload:"true") taken from next. Everything else keeps its startup value and
+// needs a restart to change.
+func (c *Config) Reload(next *Config) *Config {
+	merged := *c
+	dst, src := reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem()
+	for i := range dst.NumField() {
+		if dst.Type().Field(i).Tag.Get("reload") == "true" {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	merged.parseErrs = next.parseErrs
+	return &merged
 }
 
 // PrintBanner outputs configuration to stdout in the shell-compatible format
 // that acceptance tests grep for (e.g. "AUTOHEAL_CONTAINER_LABEL=autoheal").
 func (c *Config) PrintBanner() {
 	fmt.Println("AUTOHEAL_CONTAINER_LABEL=" + c.ContainerLabel)
+	fmt.Println("AUTOHEAL_DRY_RUN=" + strconv.FormatBool(c.DryRun))
 	fmt.Println("AUTOHEAL_START_PERIOD=" + strconv.Itoa(c.StartPeriod))
+	fmt.Println("AUTOHEAL_STARTUP_LOOKBACK=" + strconv.Itoa(c.StartupLookback))
 	fmt.Println("AUTOHEAL_INTERVAL=" + strconv.Itoa(c.Interval))
 	fmt.Println("AUTOHEAL_DEFAULT_STOP_TIMEOUT=" + strconv.Itoa(c.DefaultStopTimeout))
 	fmt.Println("AUTOHEAL_ONLY_MONITOR_RUNNING=" + strconv.FormatBool(c.OnlyMonitorRunning))
+	fmt.Println("AUTOHEAL_NOTIFY_HEALTHCHECK_LOSS=" + strconv.FormatBool(c.NotifyHealthcheckLoss))
 	fmt.Println("AUTOHEAL_MONITOR_DEPENDENCIES=" + strconv.FormatBool(c.MonitorDependencies))
 	fmt.Println("AUTOHEAL_DEPENDENCY_START_DELAY=" + strconv.Itoa(c.DependencyStartDelay))
+	fmt.Println("AUTOHEAL_DEPENDENCY_DIE_GRACE=" + strconv.Itoa(c.DependencyDieGrace))
+	fmt.Println("AUTOHEAL_DEPENDENCY_MODE=" + c.DependencyMode)
 	fmt.Println("AUTOHEAL_BACKUP_LABEL=" + c.BackupLabel)
 	fmt.Println("AUTOHEAL_BACKUP_CONTAINER=" + c.BackupContainer)
+	if c.BackupActiveLabel != "" {
+		fmt.Println("BACKUP_ACTIVE_LABEL=" + c.BackupActiveLabel)
+	}
 	fmt.Println("AUTOHEAL_BACKUP_TIMEOUT=" + strconv.Itoa(c.BackupTimeout))
 	fmt.Println("AUTOHEAL_GRACE_PERIOD=" + strconv.Itoa(c.GracePeriod))
 	fmt.Println("AUTOHEAL_WATCHTOWER_COOLDOWN=" + strconv.Itoa(c.WatchtowerCooldown))
 	fmt.Println("AUTOHEAL_WATCHTOWER_SCOPE=" + c.WatchtowerScope)
 	fmt.Println("AUTOHEAL_WATCHTOWER_EVENTS=" + c.WatchtowerEvents)
+	if c.OrchestrationNotify {
+		fmt.Println("AUTOHEAL_ORCHESTRATION_NOTIFY=true")
+	}
+	fmt.Println("AUTOHEAL_SWARM_SERVICES=" + strconv.FormatBool(c.SwarmServices))
+	if c.PauseOnNodeDrain {
+		fmt.Println("AUTOHEAL_PAUSE_ON_NODE_DRAIN=true")
+	}
+	if c.RestartExited {
+		fmt.Println("AUTOHEAL_RESTART_EXITED=true")
+	}
+	if c.HeartbeatInterval > 0 {
+		fmt.Println("HEARTBEAT_INTERVAL=" + strconv.Itoa(c.HeartbeatInterval))
+	}
+	if c.NotifyBatchWindow > 0 {
+		fmt.Println("NOTIFY_BATCH_WINDOW=" + strconv.Itoa(c.NotifyBatchWindow))
+	}
+	if c.MaxHostLoad > 0 || c.MinHostMemoryMB > 0 {
+		fmt.Printf("AUTOHEAL_MAX_HOST_LOAD=%g AUTOHEAL_MIN_HOST_MEMORY_MB=%d\n", c.MaxHostLoad, c.MinHostMemoryMB)
+	}
+	fmt.Println("AUTOHEAL_EVENT_LIVENESS_FAILURES=" + strconv.Itoa(c.EventLivenessFailures))
+	if c.StartupScanAsync {
+		fmt.Println("AUTOHEAL_STARTUP_SCAN_ASYNC=true")
+	}
+	if c.EventProcessRate > 0 {
+		fmt.Printf("EVENT_PROCESS_RATE=%g\n", c.EventProcessRate)
+	}
+	fmt.Println("AUTOHEAL_CRASHLOOP_WINDOW=" + strconv.Itoa(c.CrashloopWindow))
+	fmt.Println("AUTOHEAL_DECISION_HISTORY=" + strconv.Itoa(c.DecisionHistory))
+	if c.ConfigFile != "" {
+		fmt.Println("AUTOHEAL_CONFIG_FILE=" + c.ConfigFile)
+	}
+	if c.StateFile != "" {
+		fmt.Println("AUTOHEAL_STATE_FILE=" + c.StateFile)
+	}
+	if c.AuditInterval > 0 {
+		fmt.Println("AUDIT_INTERVAL=" + strconv.Itoa(c.AuditInterval))
+	}
+	if c.VerifyTimeout > 0 {
+		fmt.Printf("VERIFY_TIMEOUT=%d VERIFY_MAX_RESTARTS=%d\n", c.VerifyTimeout, c.VerifyMaxRestarts)
+	}
+	fmt.Println("AUTOHEAL_RECREATE_AFTER_FAILURES=" + strconv.Itoa(c.RecreateAfterFailures))
+	fmt.Println("AUTOHEAL_HARD_RESTART_PAUSE=" + strconv.Itoa(c.HardRestartPause))
+	if c.QuarantineRemoveAfter > 0 {
+		fmt.Println("QUARANTINE_REMOVE_AFTER=" + strconv.Itoa(c.QuarantineRemoveAfter))
+	}
 	fmt.Println("AUTOHEAL_UNHEALTHY_THRESHOLD=" + strconv.Itoa(c.UnhealthyThreshold))
+	fmt.Println("AUTOHEAL_UNHEALTHY_MIN_DURATION=" + strconv.Itoa(c.UnhealthyMinDuration))
+	if c.UnhealthyAlarmCount > 0 {
+		fmt.Printf("AUTOHEAL_UNHEALTHY_ALARM_COUNT=%d AUTOHEAL_UNHEALTHY_ALARM_DURATION=%d\n", c.UnhealthyAlarmCount, c.UnhealthyAlarmDuration)
+	}
 	fmt.Printf("AUTOHEAL_BACKOFF_MULTIPLIER=%g\n", c.BackoffMultiplier)
 	fmt.Println("AUTOHEAL_BACKOFF_MAX=" + strconv.Itoa(c.BackoffMax))
 	fmt.Println("AUTOHEAL_BACKOFF_RESET_AFTER=" + strconv.Itoa(c.BackoffResetAfter))
+	fmt.Printf("AUTOHEAL_BACKOFF_JITTER=%g\n", c.BackoffJitter)
 	fmt.Println("AUTOHEAL_RESTART_BUDGET=" + strconv.Itoa(c.RestartBudget))
 	fmt.Println("AUTOHEAL_RESTART_WINDOW=" + strconv.Itoa(c.RestartWindow))
+	if c.PostRestartCooldown > 0 {
+		fmt.Println("AUTOHEAL_POST_RESTART_COOLDOWN=" + strconv.Itoa(c.PostRestartCooldown))
+	}
+	if c.Disabled {
+		fmt.Println("GUARDIAN_DISABLED=true")
+	}
+	if c.LogLevel != "" && c.LogLevel != "info" {
+		fmt.Println("LOG_LEVEL=" + c.LogLevel)
+	}
+	if c.RunAsUID > 0 {
+		fmt.Printf("RUN_AS_UID=%d RUN_AS_GID=%d\n", c.RunAsUID, c.RunAsGID)
+	}
+	if len(c.ContainerIDAllowlist) > 0 {
+		fmt.Println("CONTAINER_ID_ALLOWLIST=" + strings.Join(c.ContainerIDAllowlist, ","))
+	}
+	if len(c.ContainerIDDenylist) > 0 {
+		fmt.Println("CONTAINER_ID_DENYLIST=" + strings.Join(c.ContainerIDDenylist, ","))
+	}
+	if c.ExcludeLabel != "" {
+		fmt.Println("AUTOHEAL_EXCLUDE_LABEL=" + c.ExcludeLabel)
+	}
+	if len(c.ExcludeNames) > 0 {
+		fmt.Println("AUTOHEAL_EXCLUDE_NAMES=" + strings.Join(c.ExcludeNames, ","))
+	}
+	if len(c.MaintenanceWindows) > 0 {
+		fmt.Println("AUTOHEAL_MAINTENANCE_WINDOWS=" + strings.Join(c.MaintenanceWindows, ","))
+	}
+	if len(c.EventActions) > 0 {
+		fmt.Println("AUTOHEAL_EVENT_ACTIONS=" + strings.Join(c.EventActions, ","))
+	}
 }
 
-// ResolvedNotifyEvents returns the normalised event categories.
+// EventActionEnabled reports whether an event-driven behaviour ("health",
+// "orphan", "oom" or "crash") is enabled by AUTOHEAL_EVENT_ACTIONS. An empty list enables all.
+func (c *Config) EventActionEnabled(action string) bool {
+	return len(c.EventActions) == 0 || slices.Contains(c.EventActions, action)
+}
+
+// ResolvedNotifyEvents returns the normalised event categories. Setting
+// HEARTBEAT_INTERVAL opts in to the heartbeat category.
 func (c *Config) ResolvedNotifyEvents() []string {
+	result := c.resolveNotifyEvents()
+	if c.HeartbeatInterval > 0 && !slices.Contains(result, "heartbeat") {
+		result = append(result, "heartbeat")
+	}
+	return result
+}
+
+func (c *Config) resolveNotifyEvents() []string {
 	raw := strings.TrimSpace(c.NotifyEvents)
 	switch raw {
 	case "all":
@@ -201,6 +613,12 @@ func (c *Config) ResolvedNotifyEvents() []string {
 			result = append(result, "skips")
 		case "5", "debug":
 			result = append(result, "startup", "actions", "skips", "debug")
+		case "heartbeat":
+			result = append(result, "heartbeat")
+		case "errors":
+			result = append(result, "errors")
+		case "recovery":
+			result = append(result, "recovery")
 		case "all":
 			result = append(result, "startup", "actions", "skips")
 		}
@@ -208,26 +626,299 @@ func (c *Config) ResolvedNotifyEvents() []string {
 	return result
 }
 
+// TimeWindow is a daily time range in minutes after midnight. A window whose
+// End is before its Start wraps past midnight (e.g. 23:00-01:00).
+type TimeWindow struct {
+	Start, End int
+}
+
+// ParseTimeWindow parses an HH:MM-HH:MM range.
+func ParseTimeWindow(s string) (TimeWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", s)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid window %q (want HH:MM-HH:MM)", s)
+	}
+	w := TimeWindow{Start: start.Hour()*60 + start.Minute(), End: end.Hour()*60 + end.Minute()}
+	if w.Start == w.End {
+		return TimeWindow{}, fmt.Errorf("invalid window %q: start and end are the same", s)
+	}
+	return w, nil
+}
+
+// Contains reports whether t's wall-clock time falls inside the window. The
+// start minute is included and the end minute excluded.
+func (w TimeWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// MaintenanceSchedule returns the parsed AUTOHEAL_MAINTENANCE_WINDOWS,
+// skipping entries that fail to parse (Validate reports those).
+func (c *Config) MaintenanceSchedule() []TimeWindow {
+	var windows []TimeWindow
+	for _, s := range c.MaintenanceWindows {
+		if w, err := ParseTimeWindow(s); err == nil {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// DockerEndpoint is the Docker daemon to connect to: DOCKER_HOST when set,
+// otherwise DOCKER_SOCK.
+func (c *Config) DockerEndpoint() string {
+	if c.DockerHost != "" {
+		return c.DockerHost
+	}
+	return c.DockerSock
+}
+
+// NotifierProblems lists notification services that are only partly
+// configured and so will fail or never send.
+func (c *Config) NotifierProblems() []string {
+	var problems []string
+	missing := func(set, unset, have, lack string) {
+		if have != "" && lack == "" {
+			problems = append(problems, set+" is set without "+unset)
+		}
+	}
+	missing("NOTIFY_GOTIFY_URL", "NOTIFY_GOTIFY_TOKEN", c.GotifyURL, c.GotifyToken)
+	missing("NOTIFY_TELEGRAM_TOKEN", "NOTIFY_TELEGRAM_CHAT_ID", c.TelegramToken, c.TelegramChatID)
+	missing("NOTIFY_PUSHOVER_TOKEN", "NOTIFY_PUSHOVER_USER", c.PushoverToken, c.PushoverUser)
+	missing("NOTIFY_NTFY_URL", "NOTIFY_NTFY_TOPIC", c.NtfyURL, c.NtfyTopic)
+	missing("NOTIFY_EMAIL_SMTP", "NOTIFY_EMAIL_FROM", c.EmailSMTP, c.EmailFrom)
+	missing("NOTIFY_EMAIL_SMTP", "NOTIFY_EMAIL_TO", c.EmailSMTP, c.EmailTo)
+	missing("NOTIFY_EMAIL_USER", "NOTIFY_EMAIL_PASS", c.EmailUser, c.EmailPass)
+	return problems
+}
+
 // Validate checks configuration for invalid or dangerous values.
 func (c *Config) Validate() error {
-	var errs []error
+	errs := slices.Clone(c.parseErrs)
 	if c.Interval <= 0 {
 		errs = append(errs, fmt.Errorf("AUTOHEAL_INTERVAL must be > 0, got %d", c.Interval))
 	}
+	switch strings.ToLower(c.LogLevel) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel))
+	}
+	if c.RunAsUID < 0 || c.RunAsGID < 0 {
+		errs = append(errs, fmt.Errorf("RUN_AS_UID/RUN_AS_GID must be >= 0, got %d/%d", c.RunAsUID, c.RunAsGID))
+	}
+	if c.RunAsGID > 0 && c.RunAsUID == 0 {
+		errs = append(errs, errors.New("RUN_AS_GID requires RUN_AS_UID"))
+	}
+	if c.MaxHostLoad < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_MAX_HOST_LOAD must be >= 0, got %g", c.MaxHostLoad))
+	}
+	if c.AuditInterval < 0 {
+		errs = append(errs, fmt.Errorf("AUDIT_INTERVAL must be >= 0, got %d", c.AuditInterval))
+	}
+	if c.EventProcessRate < 0 {
+		errs = append(errs, fmt.Errorf("EVENT_PROCESS_RATE must be >= 0, got %g", c.EventProcessRate))
+	}
+	if c.MinHostMemoryMB < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_MIN_HOST_MEMORY_MB must be >= 0, got %d", c.MinHostMemoryMB))
+	}
+	if c.NotifyFooter != "" {
+		if _, err := template.New("footer").Parse(c.NotifyFooter); err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_FOOTER is not a valid template: %w", err))
+		}
+	}
+	for _, svc := range NotifyServices {
+		if t, ok := c.NotifyTimeouts[svc]; ok && t <= 0 {
+			errs = append(errs, fmt.Errorf("NOTIFY_%s_TIMEOUT must be > 0, got %d", strings.ToUpper(svc), t))
+		}
+	}
+	if c.NotifyLogsHint != "" {
+		if _, err := template.New("logs-hint").Parse(c.NotifyLogsHint); err != nil {
+			errs = append(errs, fmt.Errorf("NOTIFY_LOGS_HINT is not a valid template: %w", err))
+		}
+	}
+	for _, t := range []struct{ name, text string }{
+		{"NOTIFY_TEMPLATE_ACTION", c.NotifyTemplateAction},
+		{"NOTIFY_TEMPLATE_SKIP", c.NotifyTemplateSkip},
+		{"NOTIFY_TEMPLATE_STARTUP", c.NotifyTemplateStartup},
+	} {
+		if t.text == "" {
+			continue
+		}
+		if _, err := template.New(t.name).Parse(t.text); err != nil {
+			errs = append(errs, fmt.Errorf("%s is not a valid template: %w", t.name, err))
+		}
+	}
+	if c.NotifyMaxLength < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_MAX_LENGTH must be >= 0, got %d", c.NotifyMaxLength))
+	}
+	if c.HeartbeatInterval < 0 {
+		errs = append(errs, fmt.Errorf("HEARTBEAT_INTERVAL must be >= 0, got %d", c.HeartbeatInterval))
+	}
+	if c.DockerTLSCert != "" || c.DockerTLSKey != "" || c.DockerTLSCA != "" {
+		var missing []string
+		for _, f := range []struct{ name, val string }{
+			{"DOCKER_TLS_CERT", c.DockerTLSCert},
+			{"DOCKER_TLS_KEY", c.DockerTLSKey},
+			{"DOCKER_TLS_CA", c.DockerTLSCA},
+		} {
+			if f.val == "" {
+				missing = append(missing, f.name)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("DOCKER_TLS_CERT, DOCKER_TLS_KEY and DOCKER_TLS_CA must be set together, missing %s", strings.Join(missing, ", ")))
+		}
+		if endpoint := c.DockerEndpoint(); !strings.HasPrefix(endpoint, "tcp://") && !strings.HasPrefix(endpoint, "tcps://") {
+			errs = append(errs, fmt.Errorf("DOCKER_TLS_* require a tcp:// Docker endpoint, got %q", endpoint))
+		}
+	}
+	if c.APIRateLimit < 0 {
+		errs = append(errs, fmt.Errorf("DOCKER_API_RATE_LIMIT must be >= 0, got %g", c.APIRateLimit))
+	}
+	if c.StartupLookback < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_STARTUP_LOOKBACK must be >= 0, got %d", c.StartupLookback))
+	}
+	if c.DependencyDieGrace < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_DEPENDENCY_DIE_GRACE must be >= 0, got %d", c.DependencyDieGrace))
+	}
+	if c.DependencyScanLimit < 0 {
+		errs = append(errs, fmt.Errorf("DEPENDENCY_SCAN_LIMIT must be >= 0, got %d", c.DependencyScanLimit))
+	}
 	if c.GracePeriod < 0 {
 		errs = append(errs, fmt.Errorf("AUTOHEAL_GRACE_PERIOD must be >= 0, got %d", c.GracePeriod))
 	}
+	if c.CrashloopWindow < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_CRASHLOOP_WINDOW must be >= 0, got %d", c.CrashloopWindow))
+	}
+	if c.VerifyTimeout < 0 {
+		errs = append(errs, fmt.Errorf("VERIFY_TIMEOUT must be >= 0, got %d", c.VerifyTimeout))
+	}
+	if c.BulkRestartConcurrency < 1 {
+		errs = append(errs, fmt.Errorf("BULK_RESTART_CONCURRENCY must be >= 1, got %d", c.BulkRestartConcurrency))
+	}
+	if c.VerifyMaxRestarts < 0 {
+		errs = append(errs, fmt.Errorf("VERIFY_MAX_RESTARTS must be >= 0, got %d", c.VerifyMaxRestarts))
+	}
+	if c.PostRestartScriptTimeout < 0 {
+		errs = append(errs, fmt.Errorf("POST_RESTART_SCRIPT_TIMEOUT must be >= 0, got %d", c.PostRestartScriptTimeout))
+	}
+	if c.PostRestartScriptMaxConcurrent < 1 {
+		errs = append(errs, fmt.Errorf("POST_RESTART_SCRIPT_MAX_CONCURRENT must be >= 1, got %d", c.PostRestartScriptMaxConcurrent))
+	}
+	if c.PostRestartCooldown < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_POST_RESTART_COOLDOWN must be >= 0, got %d", c.PostRestartCooldown))
+	}
+	if c.BackoffJitter < 0 || c.BackoffJitter > 1 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_BACKOFF_JITTER must be between 0 and 1, got %g", c.BackoffJitter))
+	}
+	if c.HardRestartPause < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_HARD_RESTART_PAUSE must be >= 0, got %d", c.HardRestartPause))
+	}
+	if c.RecreateAfterFailures < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_RECREATE_AFTER_FAILURES must be >= 0, got %d", c.RecreateAfterFailures))
+	}
+	if c.QuarantineRemoveAfter < 0 {
+		errs = append(errs, fmt.Errorf("QUARANTINE_REMOVE_AFTER must be >= 0, got %d", c.QuarantineRemoveAfter))
+	}
+	if c.UnhealthyAlarmCount < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_UNHEALTHY_ALARM_COUNT must be >= 0, got %d", c.UnhealthyAlarmCount))
+	}
+	if c.UnhealthyAlarmDuration < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_UNHEALTHY_ALARM_DURATION must be >= 0, got %d", c.UnhealthyAlarmDuration))
+	}
+	if c.DecisionHistory < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_DECISION_HISTORY must be >= 0, got %d", c.DecisionHistory))
+	}
 	if c.UnhealthyThreshold < 1 {
 		errs = append(errs, fmt.Errorf("AUTOHEAL_UNHEALTHY_THRESHOLD must be >= 1, got %d", c.UnhealthyThreshold))
 	}
+	if c.UnhealthyMinDuration < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_UNHEALTHY_MIN_DURATION must be >= 0, got %d", c.UnhealthyMinDuration))
+	}
+	if c.NotifyUnhealthyThreshold < 1 {
+		errs = append(errs, fmt.Errorf("NOTIFY_UNHEALTHY_THRESHOLD must be >= 1, got %d", c.NotifyUnhealthyThreshold))
+	}
+	if c.EventLivenessFailures < 0 {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_EVENT_LIVENESS_FAILURES must be >= 0, got %d", c.EventLivenessFailures))
+	}
+	if c.NotifyMaxPerWindow < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_MAX_PER_WINDOW must be >= 0, got %d", c.NotifyMaxPerWindow))
+	}
+	if c.NotifyMaxPerWindow > 0 && c.NotifyGlobalWindow <= 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_GLOBAL_WINDOW must be > 0 when NOTIFY_MAX_PER_WINDOW is set, got %d", c.NotifyGlobalWindow))
+	}
+	if c.NotifyRateLimitBurst < 1 {
+		errs = append(errs, fmt.Errorf("NOTIFY_RATE_LIMIT_BURST must be >= 1, got %d", c.NotifyRateLimitBurst))
+	}
+	if c.NotifyBatchWindow < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_BATCH_WINDOW must be >= 0, got %d", c.NotifyBatchWindow))
+	}
+	if c.NotifyDedupWindow < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_DEDUP_WINDOW must be >= 0, got %d", c.NotifyDedupWindow))
+	}
+	if c.NotifyDockerDownThreshold < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_DOCKER_DOWN_THRESHOLD must be >= 0, got %d", c.NotifyDockerDownThreshold))
+	}
+	if c.NotifyErrorThreshold < 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_ERROR_THRESHOLD must be >= 0, got %d", c.NotifyErrorThreshold))
+	}
+	if c.NotifyErrorThreshold > 0 && c.NotifyErrorWindow <= 0 {
+		errs = append(errs, fmt.Errorf("NOTIFY_ERROR_WINDOW must be > 0 when NOTIFY_ERROR_THRESHOLD is set, got %d", c.NotifyErrorWindow))
+	}
+	if c.NotifyWorkers < 1 {
+		errs = append(errs, fmt.Errorf("NOTIFY_WORKERS must be >= 1, got %d", c.NotifyWorkers))
+	}
+	if c.NotifyQueueSize < 1 {
+		errs = append(errs, fmt.Errorf("NOTIFY_QUEUE_SIZE must be >= 1, got %d", c.NotifyQueueSize))
+	}
 	if c.DefaultStopTimeout < 0 {
 		errs = append(errs, fmt.Errorf("AUTOHEAL_DEFAULT_STOP_TIMEOUT must be >= 0, got %d", c.DefaultStopTimeout))
 	}
+	for _, l := range []struct {
+		name    string
+		entries []string
+	}{
+		{"CONTAINER_ID_ALLOWLIST", c.ContainerIDAllowlist},
+		{"CONTAINER_ID_DENYLIST", c.ContainerIDDenylist},
+		{"AUTOHEAL_EXCLUDE_NAMES", c.ExcludeNames},
+	} {
+		for _, e := range l.entries {
+			if _, err := path.Match(e, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid pattern %q: %w", l.name, e, err))
+			}
+		}
+	}
+	for _, w := range c.MaintenanceWindows {
+		if _, err := ParseTimeWindow(w); err != nil {
+			errs = append(errs, fmt.Errorf("AUTOHEAL_MAINTENANCE_WINDOWS: %w", err))
+		}
+	}
+	for _, a := range c.EventActions {
+		if !slices.Contains([]string{"health", "orphan", "oom", "crash"}, a) {
+			errs = append(errs, fmt.Errorf("AUTOHEAL_EVENT_ACTIONS: unknown behaviour %q (want health, orphan, oom, crash)", a))
+		}
+	}
+	switch c.DependencyMode {
+	case "netns", "compose", "both":
+	default:
+		errs = append(errs, fmt.Errorf("AUTOHEAL_DEPENDENCY_MODE must be \"netns\", \"compose\" or \"both\", got %q", c.DependencyMode))
+	}
 	if c.WatchtowerScope != "all" && c.WatchtowerScope != "affected" {
 		errs = append(errs, fmt.Errorf("AUTOHEAL_WATCHTOWER_SCOPE must be \"all\" or \"affected\", got %q", c.WatchtowerScope))
 	}
-	if c.WatchtowerEvents != "orchestration" && c.WatchtowerEvents != "all" {
-		errs = append(errs, fmt.Errorf("AUTOHEAL_WATCHTOWER_EVENTS must be \"orchestration\" or \"all\", got %q", c.WatchtowerEvents))
+	if c.WatchtowerEvents != "orchestration" && c.WatchtowerEvents != "all" && c.WatchtowerEvents != "digest" {
+		errs = append(errs, fmt.Errorf("AUTOHEAL_WATCHTOWER_EVENTS must be \"orchestration\", \"all\" or \"digest\", got %q", c.WatchtowerEvents))
 	}
 	for _, u := range []struct {
 		name, val string
@@ -238,6 +929,8 @@ func (c *Config) Validate() error {
 		{"NOTIFY_DISCORD_WEBHOOK", c.DiscordWebhook},
 		{"NOTIFY_SLACK_WEBHOOK", c.SlackWebhook},
 		{"NOTIFY_LUNASEA_WEBHOOK", c.LunaSeaWebhook},
+		{"NOTIFY_TEAMS_WEBHOOK", c.TeamsWebhook},
+		{"NOTIFY_NTFY_URL", c.NtfyURL},
 	} {
 		if u.val != "" {
 			if _, err := url.Parse(u.val); err != nil {
@@ -248,44 +941,103 @@ func (c *Config) Validate() error {
 	return errors.Join(errs...)
 }
 
-func envStr(key, def string) string {
-	if v := os.Getenv(key); v != "" {
+// readEnvFile parses a file in the docker run --env-file format: one
+// KEY=VALUE per line, blank lines and lines starting with # ignored.
+func readEnvFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, i+1)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// envReader reads settings for Load, collecting the values that fail to parse
+// so Validate can report them.
+type envReader struct {
+	lookup func(string) string
+	errs   []error
+}
+
+func (e *envReader) str(key, def string) string {
+	if v := e.lookup(key); v != "" {
 		return v
 	}
 	return def
 }
 
-func envInt(key string, def int) int {
-	v := os.Getenv(key)
+func (e *envReader) int(key string, def int) int {
+	v := e.lookup(key)
 	if v == "" {
 		return def
 	}
 	n, err := strconv.Atoi(v)
 	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be an integer, got %q", key, v))
 		return def
 	}
 	return n
 }
 
-func envFloat(key string, def float64) float64 {
-	v := os.Getenv(key)
+func (e *envReader) float(key string, def float64) float64 {
+	v := e.lookup(key)
 	if v == "" {
 		return def
 	}
 	f, err := strconv.ParseFloat(v, 64)
 	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a number, got %q", key, v))
 		return def
 	}
 	return f
 }
 
-func envBool(key string, def bool) bool {
-	v := os.Getenv(key)
+// NotifyServices names the notification services, as used in
+// NOTIFY_<SERVICE>_TIMEOUT and the autoheal.notify.only label.
+var NotifyServices = []string{"webhook", "apprise", "gotify", "discord", "slack", "telegram", "pushover", "pushbullet", "lunasea", "teams", "ntfy", "email"}
+
+// notifyTimeouts reads the NOTIFY_<SERVICE>_TIMEOUT overrides that are set.
+func (e *envReader) notifyTimeouts() map[string]int {
+	timeouts := make(map[string]int)
+	for _, svc := range NotifyServices {
+		key := "NOTIFY_" + strings.ToUpper(svc) + "_TIMEOUT"
+		if e.lookup(key) != "" {
+			timeouts[svc] = e.int(key, 0)
+		}
+	}
+	return timeouts
+}
+
+// list splits a comma-separated variable into trimmed, non-empty entries.
+func (e *envReader) list(key string) []string {
+	var result []string
+	for _, item := range strings.Split(e.lookup(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func (e *envReader) bool(key string, def bool) bool {
+	v := e.lookup(key)
 	if v == "" {
 		return def
 	}
 	b, err := strconv.ParseBool(v)
 	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be true or false, got %q", key, v))
 		return def
 	}
 	return b
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -18,7 +22,10 @@ func TestResolvedNotifyEvents(t *testing.T) {
 		{"numeric 5", "5", []string{"startup", "actions", "skips", "debug"}},
 		{"csv", "startup,actions,skips", []string{"startup", "actions", "skips"}},
 		{"failures category", "failures", []string{"failures"}},
+		{"errors category", "errors,actions", []string{"errors", "actions"}},
 		{"mixed csv", "1,2", []string{"startup", "actions"}},
+		{"heartbeat category", "actions,heartbeat", []string{"actions", "heartbeat"}},
+		{"recovery category", "actions,recovery", []string{"actions", "recovery"}},
 	}
 
 	for _, tt := range tests {
@@ -37,15 +44,24 @@ func TestResolvedNotifyEvents(t *testing.T) {
 	}
 }
 
+func TestResolvedNotifyEvents_HeartbeatIntervalOptsIn(t *testing.T) {
+	cfg := &Config{NotifyEvents: "actions", HeartbeatInterval: 86400}
+	got := cfg.ResolvedNotifyEvents()
+	if len(got) != 2 || got[1] != "heartbeat" {
+		t.Errorf("got %v, want [actions heartbeat]", got)
+	}
+}
+
 func TestEnvStr(t *testing.T) {
 	const key = "DG_TEST_ENV_STR"
 	os.Setenv(key, "custom")
 	defer os.Unsetenv(key)
 
-	if got := envStr(key, "default"); got != "custom" {
+	env := &envReader{lookup: os.Getenv}
+	if got := env.str(key, "default"); got != "custom" {
 		t.Errorf("got %q, want %q", got, "custom")
 	}
-	if got := envStr("DG_TEST_MISSING", "fallback"); got != "fallback" {
+	if got := env.str("DG_TEST_MISSING", "fallback"); got != "fallback" {
 		t.Errorf("got %q, want %q", got, "fallback")
 	}
 }
@@ -55,14 +71,21 @@ func TestEnvInt(t *testing.T) {
 
 	os.Setenv(key, "42")
 	defer os.Unsetenv(key)
-	if got := envInt(key, 0); got != 42 {
+	env := &envReader{lookup: os.Getenv}
+	if got := env.int(key, 0); got != 42 {
 		t.Errorf("got %d, want 42", got)
 	}
+	if len(env.errs) != 0 {
+		t.Errorf("valid value recorded errors: %v", env.errs)
+	}
 
 	os.Setenv(key, "notanumber")
-	if got := envInt(key, 99); got != 99 {
+	if got := env.int(key, 99); got != 99 {
 		t.Errorf("got %d, want 99 (default on parse failure)", got)
 	}
+	if len(env.errs) != 1 {
+		t.Errorf("expected the parse failure recorded once, got %v", env.errs)
+	}
 }
 
 func TestEnvBool(t *testing.T) {
@@ -70,12 +93,250 @@ func TestEnvBool(t *testing.T) {
 
 	os.Setenv(key, "true")
 	defer os.Unsetenv(key)
-	if got := envBool(key, false); !got {
+	env := &envReader{lookup: os.Getenv}
+	if got := env.bool(key, false); !got {
 		t.Errorf("got false, want true")
 	}
 
 	os.Setenv(key, "invalid")
-	if got := envBool(key, true); !got {
+	if got := env.bool(key, true); !got {
 		t.Errorf("got false, want true (default on parse failure)")
 	}
+	if len(env.errs) != 1 {
+		t.Errorf("expected the parse failure recorded once, got %v", env.errs)
+	}
+}
+
+func TestLoad_CollectsParseErrors(t *testing.T) {
+	t.Setenv("AUTOHEAL_INTERVAL", "fifteen")
+	t.Setenv("AUTOHEAL_DRY_RUN", "maybe")
+	t.Setenv("AUTOHEAL_BACKOFF_MULTIPLIER", "double")
+	t.Setenv("NOTIFY_GOTIFY_TIMEOUT", "5s")
+
+	cfg := Load()
+	if cfg.Interval != 5 || cfg.DryRun || cfg.BackoffMultiplier != 2 {
+		t.Errorf("unparseable values should keep their defaults, got interval %d dry run %v multiplier %g", cfg.Interval, cfg.DryRun, cfg.BackoffMultiplier)
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected parse failures to fail validation")
+	}
+	for _, want := range []string{
+		`AUTOHEAL_INTERVAL must be an integer, got "fifteen"`,
+		`AUTOHEAL_DRY_RUN must be true or false, got "maybe"`,
+		`AUTOHEAL_BACKOFF_MULTIPLIER must be a number, got "double"`,
+		`NOTIFY_GOTIFY_TIMEOUT must be an integer, got "5s"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validation error missing %q: %v", want, err)
+		}
+	}
+
+	// Failures belong to the Load that saw them
+	t.Setenv("AUTOHEAL_INTERVAL", "15")
+	t.Setenv("AUTOHEAL_DRY_RUN", "true")
+	t.Setenv("AUTOHEAL_BACKOFF_MULTIPLIER", "3")
+	t.Setenv("NOTIFY_GOTIFY_TIMEOUT", "5")
+	running := Load()
+	if err := running.Validate(); err != nil {
+		t.Errorf("valid values rejected: %v", err)
+	}
+	// and are carried through a reload, which then fails validation
+	t.Setenv("AUTOHEAL_INTERVAL", "fifteen")
+	if err := running.Reload(Load()).Validate(); err == nil || !strings.Contains(err.Error(), "AUTOHEAL_INTERVAL must be an integer") {
+		t.Errorf("expected reload to report the parse failure, got %v", err)
+	}
+}
+
+func TestSchema_KnownFields(t *testing.T) {
+	byEnv := make(map[string]Option)
+	for _, o := range Schema() {
+		byEnv[o.Env] = o
+	}
+
+	tests := []struct {
+		env, typ, def string
+		secret        bool
+	}{
+		{"AUTOHEAL_INTERVAL", "int", "5", false},
+		{"AUTOHEAL_CONTAINER_LABEL", "string", "autoheal", false},
+		{"AUTOHEAL_MONITOR_DEPENDENCIES", "bool", "true", false},
+		{"AUTOHEAL_BACKOFF_MULTIPLIER", "float", "2", false},
+		{"CONTAINER_ID_ALLOWLIST", "list", "", false},
+		{"NOTIFY_<SERVICE>_TIMEOUT", "map", "", false},
+		{"ADMIN_TOKEN", "string", "", true},
+		{"NOTIFY_GOTIFY_TOKEN", "string", "", true},
+		{"NOTIFY_EMAIL_PASS", "string", "", true},
+		{"NOTIFY_GOTIFY_URL", "string", "", false},
+	}
+	for _, tt := range tests {
+		o, ok := byEnv[tt.env]
+		if !ok {
+			t.Errorf("schema missing %s", tt.env)
+			continue
+		}
+		if o.Type != tt.typ || o.Default != tt.def || o.Secret != tt.secret {
+			t.Errorf("%s = {type %q, default %q, secret %v}, want {%q, %q, %v}", tt.env, o.Type, o.Default, o.Secret, tt.typ, tt.def, tt.secret)
+		}
+	}
+}
+
+// TestSchema_MatchesLoad keeps the struct tags in step with Load.
+func TestSchema_MatchesLoad(t *testing.T) {
+	for _, o := range Schema() {
+		if o.Env == "" || o.Description == "" {
+			t.Errorf("field %s has no env or desc tag", o.Field)
+		}
+		if o.Type != "map" {
+			t.Setenv(o.Env, "")
+		}
+	}
+
+	cfg := reflect.ValueOf(*Load())
+	for _, o := range Schema() {
+		if o.Type == "list" || o.Type == "map" {
+			continue
+		}
+		if got := fmt.Sprint(cfg.FieldByName(o.Field).Interface()); got != o.Default && !(o.Default == "" && o.Type == "string") {
+			t.Errorf("%s: Load default %q, schema default %q", o.Env, got, o.Default)
+		}
+	}
+}
+
+func TestValidate_NotifyTemplates(t *testing.T) {
+	t.Setenv("NOTIFY_TEMPLATE_ACTION", "{{.Container}} {{.Action}}={{.Result}}")
+	if err := Load().Validate(); err != nil {
+		t.Fatalf("valid template rejected: %v", err)
+	}
+
+	t.Setenv("NOTIFY_TEMPLATE_SKIP", "{{.Container")
+	err := Load().Validate()
+	if err == nil || !strings.Contains(err.Error(), "NOTIFY_TEMPLATE_SKIP is not a valid template") {
+		t.Errorf("expected malformed NOTIFY_TEMPLATE_SKIP to fail validation, got %v", err)
+	}
+}
+
+func TestValidate_LogLevel(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "DEBUG")
+	if err := Load().Validate(); err != nil {
+		t.Fatalf("valid LOG_LEVEL rejected: %v", err)
+	}
+
+	t.Setenv("LOG_LEVEL", "verbose")
+	err := Load().Validate()
+	if err == nil || !strings.Contains(err.Error(), "LOG_LEVEL must be debug, info, warn or error") {
+		t.Errorf("expected unknown LOG_LEVEL to fail validation, got %v", err)
+	}
+}
+
+func TestValidate_EventActions(t *testing.T) {
+	t.Setenv("AUTOHEAL_EVENT_ACTIONS", "health,orphan,oom,crash")
+	if err := Load().Validate(); err != nil {
+		t.Fatalf("valid AUTOHEAL_EVENT_ACTIONS rejected: %v", err)
+	}
+
+	t.Setenv("AUTOHEAL_EVENT_ACTIONS", "health,restart")
+	err := Load().Validate()
+	if err == nil || !strings.Contains(err.Error(), `AUTOHEAL_EVENT_ACTIONS: unknown behaviour "restart"`) {
+		t.Errorf("expected unknown behaviour to fail validation, got %v", err)
+	}
+}
+
+func TestValidate_DockerTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"unix socket without TLS", map[string]string{}, ""},
+		{"tcp with all TLS files", map[string]string{"DOCKER_HOST": "tcp://docker.example.com:2376", "DOCKER_TLS_CERT": "/certs/cert.pem", "DOCKER_TLS_KEY": "/certs/key.pem", "DOCKER_TLS_CA": "/certs/ca.pem"}, ""},
+		{"tcp with partial TLS", map[string]string{"DOCKER_HOST": "tcp://docker.example.com:2376", "DOCKER_TLS_CERT": "/certs/cert.pem"}, "missing DOCKER_TLS_KEY, DOCKER_TLS_CA"},
+		{"TLS on a unix socket", map[string]string{"DOCKER_TLS_CERT": "/certs/cert.pem", "DOCKER_TLS_KEY": "/certs/key.pem", "DOCKER_TLS_CA": "/certs/ca.pem"}, "require a tcp:// Docker endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			err := Load().Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	w, err := ParseTimeWindow("23:30-01:15")
+	if err != nil || w.Start != 23*60+30 || w.End != 75 {
+		t.Errorf("ParseTimeWindow = %+v, %v", w, err)
+	}
+	for _, bad := range []string{"02:00", "2am-4am", "25:00-26:00", "03:00-03:00"} {
+		if _, err := ParseTimeWindow(bad); err == nil {
+			t.Errorf("ParseTimeWindow(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoad_ConfigFileReloadsSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guardian.env")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("AUTOHEAL_CONFIG_FILE", path)
+	t.Setenv("WEBHOOK_URL", "https://env.example/hook")
+	t.Setenv("AUTOHEAL_GRACE_PERIOD", "120")
+	write("# guardian settings\nWEBHOOK_URL=https://old.example/hook\n\nAUTOHEAL_INTERVAL=10\n")
+
+	running := Load()
+	if err := running.Validate(); err != nil {
+		t.Fatalf("valid file rejected: %v", err)
+	}
+	if running.WebhookURL != "https://old.example/hook" || running.Interval != 10 || running.GracePeriod != 120 {
+		t.Errorf("file should override the environment, which fills the rest: webhook %q interval %d grace %d", running.WebhookURL, running.Interval, running.GracePeriod)
+	}
+
+	// What SIGHUP does: re-read the file and apply the reloadable settings
+	write("WEBHOOK_URL=https://new.example/hook\nAUTOHEAL_INTERVAL=30\n")
+	got := running.Reload(Load())
+	if got.WebhookURL != "https://new.example/hook" {
+		t.Errorf("reload did not pick up the new webhook, got %q", got.WebhookURL)
+	}
+	if got.Interval != 10 {
+		t.Errorf("restart-only AUTOHEAL_INTERVAL changed on reload: %d", got.Interval)
+	}
+
+	write("WEBHOOK_URL\n")
+	if err := running.Reload(Load()).Validate(); err == nil || !strings.Contains(err.Error(), ":1: expected KEY=VALUE") {
+		t.Errorf("expected a malformed file to fail validation, got %v", err)
+	}
+	t.Setenv("AUTOHEAL_CONFIG_FILE", filepath.Join(t.TempDir(), "missing.env"))
+	if err := Load().Validate(); err == nil || !strings.Contains(err.Error(), "AUTOHEAL_CONFIG_FILE") {
+		t.Errorf("expected a missing file to fail validation, got %v", err)
+	}
+}
+
+func TestReload_CopiesOnlyReloadableFields(t *testing.T) {
+	running := &Config{ContainerLabel: "autoheal", Interval: 5, GracePeriod: 300, WebhookURL: "https://old.example/hook", BackoffMax: 300}
+	next := &Config{ContainerLabel: "all", Interval: 30, GracePeriod: 60, WebhookURL: "https://new.example/hook", BackoffMax: 120}
+
+	got := running.Reload(next)
+	if got == running {
+		t.Fatal("Reload must return a copy")
+	}
+	if got.GracePeriod != 60 || got.WebhookURL != "https://new.example/hook" || got.BackoffMax != 120 {
+		t.Errorf("reloadable fields not applied: %+v", got)
+	}
+	if got.ContainerLabel != "autoheal" || got.Interval != 5 {
+		t.Errorf("restart-only fields changed: label %q, interval %d", got.ContainerLabel, got.Interval)
+	}
+	if running.GracePeriod != 300 {
+		t.Error("Reload modified the running config")
+	}
 }
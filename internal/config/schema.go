@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+)
+
+// Option describes one configuration setting, as emitted by the
+// config-schema subcommand for tooling (config generators, UIs, linters).
+type Option struct {
+	Env         string `json:"env"`
+	Field       string `json:"field"`
+	Type        string `json:"type"` // string, int, float, bool, list or map
+	Default     string `json:"default"`
+	Secret      bool   `json:"secret"`
+	Reloadable  bool   `json:"reloadable"` // applied on SIGHUP without a restart
+	Description string `json:"description"`
+}
+
+// Schema returns every Config field in declaration order, built from the
+// env, default, secret, reload and desc struct tags.
+func Schema() []Option {
+	t := reflect.TypeFor[Config]()
+	opts := make([]Option, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		opts = append(opts, Option{
+			Env:         f.Tag.Get("env"),
+			Field:       f.Name,
+			Type:        schemaType(f.Type),
+			Default:     f.Tag.Get("default"),
+			Secret:      f.Tag.Get("secret") == "true",
+			Reloadable:  f.Tag.Get("reload") == "true",
+			Description: f.Tag.Get("desc"),
+		})
+	}
+	return opts
+}
+
+func schemaType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int:
+		return "int"
+	case reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Slice:
+		return "list"
+	case reflect.Map:
+		return "map"
+	default:
+		return "string"
+	}
+}
@@ -7,40 +7,112 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
 	"github.com/moby/moby/client"
+	"golang.org/x/time/rate"
 )
 
 // Client wraps the Docker API client.
 type Client struct {
-	api *client.Client
+	api     *client.Client
+	limiter *rate.Limiter // nil = unlimited
+	apiOpts []client.Opt  // extra options for creating api, set by Options
 }
 
-// NewClient creates a Docker client connected to the given socket or TCP endpoint.
-func NewClient(dockerSock string) (*Client, error) {
-	var opts []client.Opt
+// Option configures a Client.
+type Option func(*Client)
 
+// WithRateLimit paces Docker API calls to at most rps requests per second.
+// Calls block (respecting context cancellation) rather than fail when limited.
+// A value <= 0 disables rate limiting.
+func WithRateLimit(rps float64) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			return
+		}
+		burst := int(rps)
+		if burst < 1 {
+			burst = 1
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// WithTLS configures client certificate TLS for a tcp:// endpoint from PEM
+// files. It is a no-op unless all three paths are set, and is ignored for
+// unix sockets.
+func WithTLS(caPath, certPath, keyPath string) Option {
+	return func(c *Client) {
+		if caPath == "" || certPath == "" || keyPath == "" {
+			return
+		}
+		c.apiOpts = append(c.apiOpts, client.WithTLSClientConfig(caPath, certPath, keyPath))
+	}
+}
+
+// parseEndpoint splits a DOCKER_SOCK/DOCKER_HOST value into the client host
+// and, for unix sockets, the socket path. Bare paths are unix sockets.
+func parseEndpoint(endpoint string) (host, socketPath string) {
 	switch {
-	case strings.HasPrefix(dockerSock, "tcp://"), strings.HasPrefix(dockerSock, "tcps://"):
-		opts = append(opts, client.WithHost(dockerSock))
+	case strings.HasPrefix(endpoint, "tcp://"), strings.HasPrefix(endpoint, "tcps://"):
+		return endpoint, ""
+	case strings.HasPrefix(endpoint, "unix://"):
+		return endpoint, strings.TrimPrefix(endpoint, "unix://")
 	default:
-		opts = append(opts,
-			client.WithHost("unix://"+dockerSock),
-			client.WithHTTPClient(&http.Client{
-				Transport: &http.Transport{
-					DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-						return net.DialTimeout("unix", dockerSock, 30*time.Second)
-					},
+		return "unix://" + endpoint, endpoint
+	}
+}
+
+// NewClient creates a Docker client connected to the given socket or TCP endpoint.
+func NewClient(dockerSock string, options ...Option) (*Client, error) {
+	c := &Client{}
+	for _, o := range options {
+		o(c)
+	}
+
+	host, socketPath := parseEndpoint(dockerSock)
+	opts := []client.Opt{client.WithHost(host)}
+	if socketPath == "" {
+		opts = append(opts, c.apiOpts...)
+	} else {
+		opts = append(opts, client.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return net.DialTimeout("unix", socketPath, 30*time.Second)
 				},
-			}),
-		)
+			},
+		}))
 	}
 
 	api, err := client.New(opts...)
 	if err != nil {
 		return nil, err
 	}
+	c.api = api
+	return c, nil
+}
 
-	return &Client{api: api}, nil
+// wait blocks until the rate limiter admits another API call.
+// Calls that had to wait are counted as throttled.
+func (c *Client) wait(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	r := c.limiter.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	metrics.DockerAPIThrottledTotal.Inc()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
 }
 
 // Close releases the Docker client resources.
@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimit_PacesCalls(t *testing.T) {
+	c := &Client{}
+	WithRateLimit(20)(c)
+
+	// Drain the initial burst so subsequent calls are paced at 20/s
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		if err := c.wait(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := c.wait(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 calls at 20/s took %v, expected >= ~250ms", elapsed)
+	}
+}
+
+func TestRateLimit_HonoursContextCancel(t *testing.T) {
+	c := &Client{}
+	WithRateLimit(0.5)(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := c.wait(ctx); err != nil {
+		t.Fatalf("first call should be admitted: %v", err)
+	}
+
+	cancel()
+	if err := c.wait(ctx); err == nil {
+		t.Error("expected context error while throttled")
+	}
+}
+
+func TestRateLimit_DisabledByDefault(t *testing.T) {
+	c := &Client{}
+	WithRateLimit(0)(c)
+	if c.limiter != nil {
+		t.Error("rate limit 0 should leave the limiter disabled")
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint, host, socket string
+	}{
+		{"/var/run/docker.sock", "unix:///var/run/docker.sock", "/var/run/docker.sock"},
+		{"unix:///run/user/1000/docker.sock", "unix:///run/user/1000/docker.sock", "/run/user/1000/docker.sock"},
+		{"tcp://docker.example.com:2376", "tcp://docker.example.com:2376", ""},
+		{"tcps://docker.example.com:2376", "tcps://docker.example.com:2376", ""},
+	}
+	for _, tt := range tests {
+		host, socket := parseEndpoint(tt.endpoint)
+		if host != tt.host || socket != tt.socket {
+			t.Errorf("parseEndpoint(%q) = %q, %q; want %q, %q", tt.endpoint, host, socket, tt.host, tt.socket)
+		}
+	}
+}
+
+func TestWithTLS_RequiresAllFiles(t *testing.T) {
+	c := &Client{}
+	WithTLS("/certs/ca.pem", "/certs/cert.pem", "")(c)
+	if len(c.apiOpts) != 0 {
+		t.Error("partial TLS files should not configure TLS")
+	}
+	WithTLS("/certs/ca.pem", "/certs/cert.pem", "/certs/key.pem")(c)
+	if len(c.apiOpts) != 1 {
+		t.Errorf("expected one TLS option, got %d", len(c.apiOpts))
+	}
+}
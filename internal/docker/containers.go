@@ -2,16 +2,22 @@ package docker
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"time"
 
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
 )
 
 // UnhealthyContainers returns containers with health status "unhealthy",
 // optionally filtered by label and running status.
 func (c *Client) UnhealthyContainers(ctx context.Context, label string, onlyRunning bool) ([]container.Summary, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
 	opts := client.ContainerListOptions{
 		Filters: make(client.Filters).Add("health", "unhealthy"),
 	}
@@ -30,6 +36,9 @@ func (c *Client) UnhealthyContainers(ctx context.Context, label string, onlyRunn
 
 // ExitedContainers returns all containers with status "exited".
 func (c *Client) ExitedContainers(ctx context.Context) ([]container.Summary, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
 	opts := client.ContainerListOptions{
 		All:     true,
 		Filters: make(client.Filters).Add("status", "exited"),
@@ -43,6 +52,9 @@ func (c *Client) ExitedContainers(ctx context.Context) ([]container.Summary, err
 
 // RunningContainers returns all containers with status "running".
 func (c *Client) RunningContainers(ctx context.Context) ([]container.Summary, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
 	opts := client.ContainerListOptions{
 		Filters: make(client.Filters).Add("status", "running"),
 	}
@@ -55,6 +67,9 @@ func (c *Client) RunningContainers(ctx context.Context) ([]container.Summary, er
 
 // InspectContainer returns full container details by ID.
 func (c *Client) InspectContainer(ctx context.Context, id string) (container.InspectResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return container.InspectResponse{}, err
+	}
 	result, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
 	if err != nil {
 		return container.InspectResponse{}, err
@@ -64,24 +79,60 @@ func (c *Client) InspectContainer(ctx context.Context, id string) (container.Ins
 
 // RestartContainer restarts a container with the given timeout.
 func (c *Client) RestartContainer(ctx context.Context, id string, timeout int) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
 	_, err := c.api.ContainerRestart(ctx, id, client.ContainerRestartOptions{Timeout: &timeout})
 	return err
 }
 
 // StartContainer starts a stopped container.
 func (c *Client) StartContainer(ctx context.Context, id string) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
 	_, err := c.api.ContainerStart(ctx, id, client.ContainerStartOptions{})
 	return err
 }
 
 // StopContainer stops a running container with the given timeout.
 func (c *Client) StopContainer(ctx context.Context, id string, timeout int) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
 	_, err := c.api.ContainerStop(ctx, id, client.ContainerStopOptions{Timeout: &timeout})
 	return err
 }
 
+// StopContainerSignal stops a running container like StopContainer, but sends
+// signal (a name such as SIGINT, or a number) instead of the container's stop
+// signal. An empty signal sends SIGTERM. SIGKILL still follows after timeout.
+func (c *Client) StopContainerSignal(ctx context.Context, id, signal string, timeout int) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	if signal == "" {
+		signal = "SIGTERM"
+	}
+	_, err := c.api.ContainerStop(ctx, id, client.ContainerStopOptions{Signal: signal, Timeout: &timeout})
+	return err
+}
+
+// RemoveContainer removes a stopped container. It is not forced, so a
+// container that is running again is refused by the daemon.
+func (c *Client) RemoveContainer(ctx context.Context, id string) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	_, err := c.api.ContainerRemove(ctx, id, client.ContainerRemoveOptions{})
+	return err
+}
+
 // ContainerStatus returns the current status string of a container.
 func (c *Client) ContainerStatus(ctx context.Context, id string) (string, error) {
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
 	info, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
 	if err != nil {
 		return "", err
@@ -92,6 +143,9 @@ func (c *Client) ContainerStatus(ctx context.Context, id string) (string, error)
 // ContainerHealthLog returns the output from the last healthcheck log entry.
 // Returns empty string if no health log is available.
 func (c *Client) ContainerHealthLog(ctx context.Context, id string) (string, error) {
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
 	info, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
 	if err != nil {
 		return "", err
@@ -109,6 +163,9 @@ func (c *Client) ContainerHealthLog(ctx context.Context, id string) (string, err
 
 // ContainerFinishedAt returns when the container last stopped.
 func (c *Client) ContainerFinishedAt(ctx context.Context, id string) (time.Time, error) {
+	if err := c.wait(ctx); err != nil {
+		return time.Time{}, err
+	}
 	info, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
 	if err != nil {
 		return time.Time{}, err
@@ -119,3 +176,93 @@ func (c *Client) ContainerFinishedAt(ctx context.Context, id string) (time.Time,
 	}
 	return t, nil
 }
+
+// ContainerImageDigest returns the content digest (image ID) of the image the
+// container runs. It changes whenever the container is moved to a new image,
+// even one pulled under the same tag.
+func (c *Client) ContainerImageDigest(ctx context.Context, id string) (string, error) {
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+	info, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return "", err
+	}
+	return info.Container.Image, nil
+}
+
+// RecreateContainer replaces a container with a fresh one built from the same
+// name, config, host config and network attachments (equivalent to
+// `docker rm -f` followed by `docker run` with the original settings).
+// Returns the ID of the new container. A failure once the original has been
+// removed wraps ErrOriginalRemoved; if the replacement was created but did
+// not start, its ID is returned along with the error.
+func (c *Client) RecreateContainer(ctx context.Context, id string, timeout int) (string, error) {
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+	result, err := c.api.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return "", err
+	}
+	info := result.Container
+
+	// Carry over user configuration only; operational data (IPs, endpoint IDs)
+	// belongs to the old container and is reassigned by the daemon.
+	var netCfg *network.NetworkingConfig
+	if info.NetworkSettings != nil && len(info.NetworkSettings.Networks) > 0 {
+		netCfg = &network.NetworkingConfig{EndpointsConfig: make(map[string]*network.EndpointSettings)}
+		for name, ep := range info.NetworkSettings.Networks {
+			if ep == nil {
+				continue
+			}
+			netCfg.EndpointsConfig[name] = &network.EndpointSettings{
+				IPAMConfig: ep.IPAMConfig,
+				Links:      ep.Links,
+				Aliases:    ep.Aliases,
+				DriverOpts: ep.DriverOpts,
+				GwPriority: ep.GwPriority,
+			}
+		}
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+	if _, err := c.api.ContainerStop(ctx, id, client.ContainerStopOptions{Timeout: &timeout}); err != nil {
+		return "", err
+	}
+	if err := c.wait(ctx); err != nil {
+		return "", err
+	}
+	if _, err := c.api.ContainerRemove(ctx, id, client.ContainerRemoveOptions{Force: true}); err != nil {
+		return "", err
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrOriginalRemoved, err)
+	}
+	created, err := c.api.ContainerCreate(ctx, client.ContainerCreateOptions{
+		Name:             strings.TrimPrefix(info.Name, "/"),
+		Config:           info.Config,
+		HostConfig:       info.HostConfig,
+		NetworkingConfig: netCfg,
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: creating replacement: %w", ErrOriginalRemoved, err)
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return created.ID, fmt.Errorf("%w: %w", ErrOriginalRemoved, err)
+	}
+	if _, err := c.api.ContainerStart(ctx, created.ID, client.ContainerStartOptions{}); err != nil {
+		return created.ID, fmt.Errorf("%w: starting replacement: %w", ErrOriginalRemoved, err)
+	}
+	return created.ID, nil
+}
+
+// IsNotFound reports whether err means the container (or other object) no
+// longer exists, e.g. it was removed between detection and action.
+func IsNotFound(err error) bool {
+	return cerrdefs.IsNotFound(err)
+}
@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+
+	cerrdefs "github.com/containerd/errdefs"
+)
+
+// ErrOriginalRemoved marks a RecreateContainer failure that happened after
+// the original container was removed, so nothing is left running under it.
+var ErrOriginalRemoved = errors.New("original container removed")
+
+// ErrorClass is a coarse category for a failed Docker operation, used to
+// decide how the guardian reacts to it.
+type ErrorClass string
+
+const (
+	ErrorNotFound   ErrorClass = "not-found"  // container removed since detection
+	ErrorPermission ErrorClass = "permission" // daemon refused us (socket access, authz plugin)
+	ErrorConflict   ErrorClass = "conflict"   // container state clash, e.g. already running or being removed
+	ErrorTimeout    ErrorClass = "timeout"    // daemon or container did not respond in time
+	ErrorOther      ErrorClass = "other"
+)
+
+// ClassifyError maps an error returned by the Docker client to an ErrorClass.
+func ClassifyError(err error) ErrorClass {
+	var netErr net.Error
+	switch {
+	case cerrdefs.IsNotFound(err):
+		return ErrorNotFound
+	case cerrdefs.IsPermissionDenied(err), cerrdefs.IsUnauthorized(err), errors.Is(err, fs.ErrPermission):
+		return ErrorPermission
+	case cerrdefs.IsConflict(err), cerrdefs.IsNotModified(err):
+		return ErrorConflict
+	case cerrdefs.IsDeadlineExceeded(err), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	default:
+		return ErrorOther
+	}
+}
+
+// Describe returns a short human-readable description for notifications.
+func (c ErrorClass) Describe() string {
+	switch c {
+	case ErrorNotFound:
+		return "container no longer exists"
+	case ErrorPermission:
+		return "permission denied"
+	case ErrorConflict:
+		return "conflicting container state"
+	case ErrorTimeout:
+		return "timed out"
+	default:
+		return "unexpected error"
+	}
+}
+
+// Transient reports whether the failure says nothing about the container's
+// own health, so it should not count towards its circuit breaker.
+// Permission errors are a guardian/daemon configuration problem and
+// conflicts mean something else is already acting on the container.
+func (c ErrorClass) Transient() bool {
+	return c == ErrorPermission || c == ErrorConflict
+}
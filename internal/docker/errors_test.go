@@ -0,0 +1,42 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+
+	cerrdefs "github.com/containerd/errdefs"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		want      ErrorClass
+		transient bool
+	}{
+		{"not found", fmt.Errorf("restart: %w", cerrdefs.ErrNotFound), ErrorNotFound, false},
+		{"daemon forbidden", cerrdefs.ErrPermissionDenied, ErrorPermission, true},
+		{"unauthorized", cerrdefs.ErrUnauthenticated, ErrorPermission, true},
+		{"socket permission", &fs.PathError{Op: "dial", Path: "/var/run/docker.sock", Err: fs.ErrPermission}, ErrorPermission, true},
+		{"conflict", fmt.Errorf("removal in progress: %w", cerrdefs.ErrConflict), ErrorConflict, true},
+		{"already started", cerrdefs.ErrNotModified, ErrorConflict, true},
+		{"context deadline", fmt.Errorf("stop: %w", context.DeadlineExceeded), ErrorTimeout, false},
+		{"network timeout", os.ErrDeadlineExceeded, ErrorTimeout, false},
+		{"other", errors.New("boom"), ErrorOther, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyError(tt.err)
+			if got != tt.want {
+				t.Errorf("ClassifyError = %q, want %q", got, tt.want)
+			}
+			if got.Transient() != tt.transient {
+				t.Errorf("Transient = %v, want %v", got.Transient(), tt.transient)
+			}
+		})
+	}
+}
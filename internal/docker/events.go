@@ -13,6 +13,9 @@ import (
 // If orchestrationOnly is true, only destroy+create events are returned
 // (the Watchtower signature).
 func (c *Client) ContainerEvents(ctx context.Context, since time.Time, until time.Time, orchestrationOnly bool) ([]events.Message, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
 	opts := client.EventsListOptions{
 		Since:   since.Format(time.RFC3339Nano),
 		Until:   until.Format(time.RFC3339Nano),
@@ -18,10 +18,17 @@ type API interface {
 	RestartContainer(ctx context.Context, id string, timeout int) error
 	StartContainer(ctx context.Context, id string) error
 	StopContainer(ctx context.Context, id string, timeout int) error
+	StopContainerSignal(ctx context.Context, id, signal string, timeout int) error
+	RemoveContainer(ctx context.Context, id string) error
+	RecreateContainer(ctx context.Context, id string, timeout int) (string, error)
 	ContainerStatus(ctx context.Context, id string) (string, error)
 	ContainerFinishedAt(ctx context.Context, id string) (time.Time, error)
+	ContainerImageDigest(ctx context.Context, id string) (string, error)
 	ContainerHealthLog(ctx context.Context, id string) (string, error)
 	ContainerEvents(ctx context.Context, since, until time.Time, orchestrationOnly bool) ([]events.Message, error)
+	ForceServiceUpdate(ctx context.Context, serviceID string) error
+	Info(ctx context.Context) (NodeInfo, error)
+	Ping(ctx context.Context) error
 	Close() error
 }
 
@@ -0,0 +1,27 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/moby/moby/client"
+)
+
+// ForceServiceUpdate triggers a rolling restart of a Swarm service by bumping
+// its ForceUpdate counter (equivalent to `docker service update --force`).
+func (c *Client) ForceServiceUpdate(ctx context.Context, serviceID string) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	result, err := c.api.ServiceInspect(ctx, serviceID, client.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	svc := result.Service
+	spec := svc.Spec
+	spec.TaskTemplate.ForceUpdate++
+	_, err = c.api.ServiceUpdate(ctx, svc.ID, client.ServiceUpdateOptions{
+		Version: svc.Version,
+		Spec:    spec,
+	})
+	return err
+}
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/moby/moby/api/types/swarm"
+	"github.com/moby/moby/client"
+)
+
+// NodeInfo is the daemon's Swarm membership and, where it can be read, this
+// node's scheduling availability.
+type NodeInfo struct {
+	SwarmState   string // LocalNodeState: "inactive", "pending", "active", "error", "locked"
+	Availability string // "active", "pause", "drain"; empty when not readable (not a Swarm manager)
+}
+
+// Ping checks that the daemon is reachable and answering API requests.
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	_, err := c.api.Ping(ctx, client.PingOptions{})
+	return err
+}
+
+// Info returns the local node's Swarm state. Availability requires a node
+// inspect, which only managers may perform; on workers it is left empty.
+func (c *Client) Info(ctx context.Context) (NodeInfo, error) {
+	if err := c.wait(ctx); err != nil {
+		return NodeInfo{}, err
+	}
+	result, err := c.api.Info(ctx, client.InfoOptions{})
+	if err != nil {
+		return NodeInfo{}, err
+	}
+	sw := result.Info.Swarm
+	info := NodeInfo{SwarmState: string(sw.LocalNodeState)}
+	if sw.LocalNodeState != swarm.LocalNodeStateActive || !sw.ControlAvailable || sw.NodeID == "" {
+		return info, nil
+	}
+
+	if err := c.wait(ctx); err != nil {
+		return info, err
+	}
+	node, err := c.api.NodeInspect(ctx, sw.NodeID, client.NodeInspectOptions{})
+	if err != nil {
+		return info, err
+	}
+	info.Availability = string(node.Node.Spec.Availability)
+	return info, nil
+}
@@ -2,6 +2,10 @@ package docker
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 
 	"github.com/moby/moby/api/types/events"
@@ -12,8 +16,9 @@ import (
 type ContainerEvent struct {
 	ContainerID   string
 	ContainerName string
-	Action        string // "health_status", "die", "start", "destroy", "create"
+	Action        string // "health_status", "die", "oom", "kill", "start", "destroy", "create"
 	HealthStatus  string // "unhealthy", "healthy" (only for health_status events)
+	ExitCode      int    // container exit code (only for die events)
 	Timestamp     time.Time
 }
 
@@ -22,11 +27,24 @@ type WatcherAPI interface {
 	Watch(ctx context.Context) <-chan ContainerEvent
 }
 
+// eventSource is the part of the Docker client the watcher uses.
+type eventSource interface {
+	Events(ctx context.Context, options client.EventsListOptions) client.EventsResult
+	Ping(ctx context.Context, options client.PingOptions) (client.PingResult, error)
+}
+
+// livenessProbeTimeout bounds the ping and the event replay sent when the
+// liveness window expires.
+const livenessProbeTimeout = 10 * time.Second
+
 // Watcher subscribes to the Docker event stream and emits ContainerEvents.
 type Watcher struct {
-	api            *client.Client
+	api            eventSource
 	reconnectMax   time.Duration
 	livenessWindow time.Duration
+	onLiveness     func()
+	onConnect      func()
+	onDisconnect   func()
 }
 
 // NewWatcher creates a Watcher connected to the Docker event stream.
@@ -38,6 +56,26 @@ func NewWatcher(c *Client) *Watcher {
 	}
 }
 
+// SetLivenessHandler enables the liveness window: if no event arrives within
+// the window the daemon is pinged and asked to replay the window's events.
+// The stream is treated as dead, fn called, and the watcher reconnected when
+// the ping fails or the replay turns up events the stream never delivered.
+// A quiet host with nothing to replay keeps its stream. Must be called before
+// Watch.
+func (w *Watcher) SetLivenessHandler(fn func()) {
+	w.onLiveness = fn
+}
+
+// SetConnectionHandlers registers callbacks for the stream state. onConnect
+// runs once a subscription is established, confirmed by the first event or by
+// the daemon answering a ping right after subscribing; onDisconnect runs when
+// an established subscription ends. Either may be nil. Must be called before
+// Watch.
+func (w *Watcher) SetConnectionHandlers(onConnect, onDisconnect func()) {
+	w.onConnect = onConnect
+	w.onDisconnect = onDisconnect
+}
+
 // Watch starts watching Docker events. It reconnects automatically on disconnect.
 // Returns a channel of ContainerEvents. The channel is closed when ctx is cancelled.
 func (w *Watcher) Watch(ctx context.Context) <-chan ContainerEvent {
@@ -77,21 +115,61 @@ func (w *Watcher) Watch(ctx context.Context) <-chan ContainerEvent {
 }
 
 func (w *Watcher) streamEvents(ctx context.Context, ch chan<- ContainerEvent) {
+	// Ends the subscription when the stream is abandoned for a reconnect
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	opts := client.EventsListOptions{
 		Filters: make(client.Filters).
 			Add("type", "container").
-			Add("event", "health_status", "die", "start", "destroy", "create"),
+			Add("event", "health_status", "die", "oom", "kill", "start", "destroy", "create"),
 	}
 
 	result := w.api.Events(ctx, opts)
+	since := time.Now() // start of the window a replay would cover
+
+	connected := false
+	markConnected := func() {
+		if connected {
+			return
+		}
+		connected = true
+		if w.onConnect != nil {
+			w.onConnect()
+		}
+	}
+	defer func() {
+		if connected && w.onDisconnect != nil {
+			w.onDisconnect()
+		}
+	}()
+	// A quiet host may not send an event for a long time, so a ping
+	// confirms the subscription instead of waiting for the first one
+	if w.daemonAnswers(ctx) {
+		markConnected()
+	}
+
+	var liveness <-chan time.Time
+	var livenessTimer *time.Timer
+	if w.onLiveness != nil {
+		livenessTimer = time.NewTimer(w.livenessWindow)
+		defer livenessTimer.Stop()
+		liveness = livenessTimer.C
+	}
 
-	// Reset backoff on successful connection
 	for {
 		select {
 		case msg, ok := <-result.Messages:
 			if !ok {
 				return // stream closed
 			}
+			markConnected()
+			if livenessTimer != nil {
+				livenessTimer.Reset(w.livenessWindow)
+			}
+			if at := time.Unix(0, msg.TimeNano); msg.TimeNano != 0 && !at.Before(since) {
+				since = at.Add(time.Nanosecond)
+			}
 			evt := parseEvent(msg)
 			if evt != nil {
 				select {
@@ -102,12 +180,57 @@ func (w *Watcher) streamEvents(ctx context.Context, ch chan<- ContainerEvent) {
 			}
 		case <-result.Err:
 			return // stream error — caller will reconnect
+		case <-liveness:
+			if w.daemonAnswers(ctx) && !w.missedEvents(ctx, opts, since) {
+				since = time.Now()
+				livenessTimer.Reset(w.livenessWindow)
+				continue // just a quiet host
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			w.onLiveness()
+			return // daemon down or stream stalled — caller will reconnect
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// daemonAnswers reports whether the daemon replies to a ping within
+// livenessProbeTimeout.
+func (w *Watcher) daemonAnswers(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, livenessProbeTimeout)
+	defer cancel()
+	_, err := w.api.Ping(ctx, client.PingOptions{})
+	return err == nil
+}
+
+// missedEvents asks the daemon to replay the events matching opts since the
+// given time and reports whether there were any: the subscription should
+// have delivered them, so it has stalled. A replay that fails also counts,
+// as the daemon is not serving events.
+func (w *Watcher) missedEvents(ctx context.Context, opts client.EventsListOptions, since time.Time) bool {
+	ctx, cancel := context.WithTimeout(ctx, livenessProbeTimeout)
+	defer cancel()
+	opts.Since = eventTimestamp(since)
+	opts.Until = eventTimestamp(time.Now())
+	replay := w.api.Events(ctx, opts)
+	select {
+	case <-replay.Messages:
+		return true
+	case err := <-replay.Err:
+		return err != nil && !errors.Is(err, io.EOF)
+	case <-ctx.Done():
+		return true
+	}
+}
+
+// eventTimestamp formats t the way the events API takes since and until.
+func eventTimestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
 func parseEvent(msg events.Message) *ContainerEvent {
 	evt := &ContainerEvent{
 		ContainerID:   msg.Actor.ID,
@@ -115,6 +238,9 @@ func parseEvent(msg events.Message) *ContainerEvent {
 		Action:        string(msg.Action),
 		Timestamp:     time.Unix(msg.Time, msg.TimeNano%1e9),
 	}
+	if code, err := strconv.Atoi(msg.Actor.Attributes["exitCode"]); err == nil {
+		evt.ExitCode = code
+	}
 
 	// Docker sends health_status events as "health_status: unhealthy" or "health_status: healthy"
 	if action := string(msg.Action); len(action) > 15 && action[:14] == "health_status:" {
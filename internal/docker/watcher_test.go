@@ -0,0 +1,180 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+)
+
+// quietSource is an event stream that only delivers msgs (never, when nil),
+// on a daemon whose pings fail when pingErr is set. A replay (Since set)
+// returns missed: events the daemon saw but the stream never delivered.
+type quietSource struct {
+	msgs    chan events.Message
+	missed  []events.Message
+	pingErr error
+	pings   atomic.Int32
+	replays atomic.Int32
+}
+
+func (q *quietSource) Events(_ context.Context, opts client.EventsListOptions) client.EventsResult {
+	if opts.Since != "" {
+		q.replays.Add(1)
+		msgs, errs := make(chan events.Message), make(chan error, 1)
+		go func() {
+			for _, m := range q.missed {
+				msgs <- m
+			}
+			errs <- io.EOF
+		}()
+		return client.EventsResult{Messages: msgs, Err: errs}
+	}
+	msgs := q.msgs
+	if msgs == nil {
+		msgs = make(chan events.Message)
+	}
+	return client.EventsResult{Messages: msgs, Err: make(chan error)}
+}
+
+func (q *quietSource) Ping(context.Context, client.PingOptions) (client.PingResult, error) {
+	q.pings.Add(1)
+	return client.PingResult{}, q.pingErr
+}
+
+func TestStreamEvents_LivenessWindow(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		pingErr  error
+		missed   []events.Message
+		wantDead bool
+	}{
+		{"daemon answers ping, nothing to replay", nil, nil, false},
+		{"daemon answers ping, stream stalled", nil, []events.Message{{Type: events.ContainerEventType, Action: events.ActionDie}}, true},
+		{"daemon does not answer", errors.New("connection refused"), nil, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &quietSource{pingErr: tt.pingErr, missed: tt.missed}
+			var timeouts atomic.Int32
+			w := &Watcher{api: src, reconnectMax: time.Second, livenessWindow: 10 * time.Millisecond}
+			w.SetLivenessHandler(func() { timeouts.Add(1) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			w.streamEvents(ctx, make(chan ContainerEvent, 1))
+
+			// One ping confirms the subscription, the next is the liveness probe
+			if src.pings.Load() < 2 {
+				t.Fatal("expected the expired liveness window to ping the daemon")
+			}
+			if tt.pingErr == nil && src.replays.Load() == 0 {
+				t.Fatal("expected a daemon that answers to be asked to replay the window")
+			}
+			if dead := timeouts.Load() > 0; dead != tt.wantDead {
+				t.Errorf("liveness failure reported = %v, want %v", dead, tt.wantDead)
+			}
+		})
+	}
+}
+
+func TestStreamEvents_ConnectionHandlers(t *testing.T) {
+	t.Run("ping confirms a quiet stream", func(t *testing.T) {
+		var connected, disconnected atomic.Int32
+		w := &Watcher{api: &quietSource{}, reconnectMax: time.Second}
+		w.SetConnectionHandlers(func() { connected.Add(1) }, func() { disconnected.Add(1) })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			w.streamEvents(ctx, make(chan ContainerEvent, 1))
+		}()
+		deadline := time.Now().Add(time.Second)
+		for connected.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if connected.Load() != 1 || disconnected.Load() != 0 {
+			t.Fatalf("before any event: connected=%d disconnected=%d, want 1 and 0", connected.Load(), disconnected.Load())
+		}
+		cancel()
+		<-done
+		if disconnected.Load() != 1 {
+			t.Errorf("expected a disconnect once the stream ended, got %d", disconnected.Load())
+		}
+	})
+
+	t.Run("first event confirms the stream", func(t *testing.T) {
+		msgs := make(chan events.Message, 1)
+		msgs <- events.Message{Type: events.ContainerEventType, Action: events.ActionStart, Actor: events.Actor{ID: "abc"}}
+		close(msgs)
+		var order []string
+		w := &Watcher{api: &quietSource{msgs: msgs, pingErr: errors.New("timeout")}, reconnectMax: time.Second}
+		w.SetConnectionHandlers(func() { order = append(order, "connect") }, func() { order = append(order, "disconnect") })
+
+		w.streamEvents(context.Background(), make(chan ContainerEvent, 1))
+
+		if len(order) != 2 || order[0] != "connect" || order[1] != "disconnect" {
+			t.Errorf("got %v, want [connect disconnect]", order)
+		}
+	})
+
+	t.Run("never connected is never disconnected", func(t *testing.T) {
+		msgs := make(chan events.Message)
+		close(msgs)
+		var calls atomic.Int32
+		w := &Watcher{api: &quietSource{msgs: msgs, pingErr: errors.New("connection refused")}, reconnectMax: time.Second}
+		w.SetConnectionHandlers(func() { calls.Add(1) }, func() { calls.Add(1) })
+
+		w.streamEvents(context.Background(), make(chan ContainerEvent, 1))
+
+		if calls.Load() != 0 {
+			t.Errorf("expected no connection callbacks, got %d", calls.Load())
+		}
+	})
+}
+
+func TestParseEvent(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	tests := []struct {
+		name       string
+		action     events.Action
+		attrs      map[string]string
+		wantAction string
+		wantHealth string
+		wantExit   int
+	}{
+		{"oom", events.ActionOOM, nil, "oom", "", 0},
+		{"kill", events.ActionKill, map[string]string{"signal": "9"}, "kill", "", 0},
+		{"die", events.ActionDie, map[string]string{"exitCode": "137"}, "die", "", 137},
+		{"health status in action", "health_status: unhealthy", nil, "health_status", "unhealthy", 0},
+		{"health status in attributes", events.ActionHealthStatus, map[string]string{"health_status": "healthy"}, "health_status", "healthy", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]string{"name": "web"}
+			for k, v := range tt.attrs {
+				attrs[k] = v
+			}
+			evt := parseEvent(events.Message{
+				Type:   events.ContainerEventType,
+				Action: tt.action,
+				Actor:  events.Actor{ID: "abcdef1234567890abcdef", Attributes: attrs},
+				Time:   at.Unix(),
+			})
+			if evt == nil {
+				t.Fatal("parseEvent returned nil")
+			}
+			if evt.Action != tt.wantAction || evt.HealthStatus != tt.wantHealth || evt.ExitCode != tt.wantExit {
+				t.Errorf("got action %q health %q exit %d, want %q %q %d", evt.Action, evt.HealthStatus, evt.ExitCode, tt.wantAction, tt.wantHealth, tt.wantExit)
+			}
+			if evt.ContainerID != "abcdef1234567890abcdef" || evt.ContainerName != "web" || !evt.Timestamp.Equal(at) {
+				t.Errorf("unexpected event fields: %+v", evt)
+			}
+		})
+	}
+}
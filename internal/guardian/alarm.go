@@ -0,0 +1,50 @@
+package guardian
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+)
+
+// observeUnhealthyCount feeds the unhealthy container count into the
+// host-level alarm. Many containers unhealthy at once for a sustained period
+// usually means the host itself is in trouble (disk full, daemon issues), so
+// a single [CRITICAL] notification fires once the count has stayed above
+// AUTOHEAL_UNHEALTHY_ALARM_COUNT for AUTOHEAL_UNHEALTHY_ALARM_DURATION, and a
+// recovery notification once it drops back.
+func (g *Guardian) observeUnhealthyCount(count int) {
+	g.unhealthyNow.Store(int64(count)) // for /status
+
+	threshold := g.cfg().UnhealthyAlarmCount
+	if threshold <= 0 {
+		return
+	}
+
+	g.alarmMu.Lock()
+	defer g.alarmMu.Unlock()
+
+	now := g.clock.Now()
+	if count <= threshold {
+		if g.alarmFired {
+			g.log.Infof("Unhealthy container count back to %d (threshold %d) - host alarm cleared", count, threshold)
+			g.notifier.Action(notify.Event{Text: fmt.Sprintf("Host alarm cleared: %d containers unhealthy (threshold %d)", count, threshold)})
+		}
+		g.alarmSince = time.Time{}
+		g.alarmFired = false
+		return
+	}
+
+	if g.alarmSince.IsZero() {
+		g.alarmSince = now
+	}
+	duration := time.Duration(g.cfg().UnhealthyAlarmDuration) * time.Second
+	if g.alarmFired || now.Sub(g.alarmSince) < duration {
+		return
+	}
+
+	g.alarmFired = true
+	g.log.Warnf("%d containers unhealthy for over %ds (threshold %d) - possible host-level problem", count, g.cfg().UnhealthyAlarmDuration, threshold)
+	g.notifier.Action(notify.Event{Text: fmt.Sprintf("[CRITICAL] %d containers unhealthy for over %ds (threshold %d) - possible host-level problem",
+		count, g.cfg().UnhealthyAlarmDuration, threshold)})
+}
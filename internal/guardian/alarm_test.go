@@ -0,0 +1,92 @@
+package guardian
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+)
+
+func alarmGuardian() (*Guardian, *mockNotifier, *mockClock) {
+	cfg := &config.Config{UnhealthyAlarmCount: 3, UnhealthyAlarmDuration: 60}
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	return newTestGuardian(cfg, newMockDocker(), notif, clk), notif, clk
+}
+
+func TestUnhealthyAlarm_FiresOnceWhenSustained(t *testing.T) {
+	g, notif, clk := alarmGuardian()
+
+	g.observeUnhealthyCount(5)
+	clk.Advance(30 * time.Second)
+	g.observeUnhealthyCount(5)
+	if len(notif.actions) != 0 {
+		t.Fatalf("should not alarm before the duration elapses, got %v", notif.actions)
+	}
+
+	clk.Advance(30 * time.Second)
+	g.observeUnhealthyCount(4)
+	clk.Advance(30 * time.Second)
+	g.observeUnhealthyCount(6)
+
+	if len(notif.actions) != 1 {
+		t.Fatalf("expected a single alarm, got %v", notif.actions)
+	}
+	if !strings.HasPrefix(notif.actions[0], "[CRITICAL] 4 containers unhealthy") {
+		t.Errorf("unexpected alarm text: %q", notif.actions[0])
+	}
+}
+
+func TestUnhealthyAlarm_DipResetsTimer(t *testing.T) {
+	g, notif, clk := alarmGuardian()
+
+	g.observeUnhealthyCount(5)
+	clk.Advance(50 * time.Second)
+	g.observeUnhealthyCount(3) // at threshold, not above
+	clk.Advance(20 * time.Second)
+	g.observeUnhealthyCount(5)
+
+	if len(notif.actions) != 0 {
+		t.Errorf("count dipped to the threshold, timer should restart; got %v", notif.actions)
+	}
+}
+
+func TestUnhealthyAlarm_ClearsOnRecovery(t *testing.T) {
+	g, notif, clk := alarmGuardian()
+
+	g.observeUnhealthyCount(5)
+	clk.Advance(time.Minute)
+	g.observeUnhealthyCount(5)
+	g.observeUnhealthyCount(1)
+	g.observeUnhealthyCount(0)
+
+	if len(notif.actions) != 2 {
+		t.Fatalf("expected alarm then a single clear, got %v", notif.actions)
+	}
+	if !strings.HasPrefix(notif.actions[1], "Host alarm cleared: 1 containers unhealthy") {
+		t.Errorf("unexpected clear text: %q", notif.actions[1])
+	}
+
+	// A new sustained breach alarms again
+	g.observeUnhealthyCount(5)
+	clk.Advance(time.Minute)
+	g.observeUnhealthyCount(5)
+	if len(notif.actions) != 3 {
+		t.Errorf("expected a second alarm after recovery, got %v", notif.actions)
+	}
+}
+
+func TestUnhealthyAlarm_DisabledByDefault(t *testing.T) {
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(&config.Config{}, newMockDocker(), notif, clk)
+
+	g.observeUnhealthyCount(100)
+	clk.Advance(time.Hour)
+	g.observeUnhealthyCount(100)
+
+	if len(notif.actions) != 0 {
+		t.Errorf("alarm should be disabled without a threshold, got %v", notif.actions)
+	}
+}
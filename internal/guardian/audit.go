@@ -0,0 +1,155 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+	"github.com/moby/moby/api/types/container"
+)
+
+// hasHealthcheck reports whether a listed container has a health check.
+// known is false when the daemon doesn't report health in list results
+// and the status string gives no hint either.
+func hasHealthcheck(c container.Summary) (has, known bool) {
+	if c.Health != nil {
+		return c.Health.Status != container.NoHealthcheck && c.Health.Status != "", true
+	}
+	// Older daemons: health only appears in the human-readable status
+	if strings.Contains(c.Status, "(healthy)") || strings.Contains(c.Status, "(unhealthy)") ||
+		strings.Contains(c.Status, "(health: starting)") {
+		return true, true
+	}
+	return false, false
+}
+
+// checkHealthcheckLoss notifies once when a monitored container that previously
+// had a health check is seen running without one (e.g. its image was updated
+// to one without HEALTHCHECK), since it silently drops out of health monitoring.
+// Containers are tracked by name so recreated containers are matched.
+func (g *Guardian) checkHealthcheckLoss(ctx context.Context) {
+	if !g.cfg().NotifyHealthcheckLoss {
+		return
+	}
+
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list running containers", err)
+		return
+	}
+
+	g.healthcheckMu.Lock()
+	defer g.healthcheckMu.Unlock()
+	if g.healthchecked == nil {
+		g.healthchecked = make(map[string]bool)
+	}
+
+	for _, c := range running {
+		if len(c.Names) == 0 || !g.isMonitored(c.Labels) {
+			continue
+		}
+		name := displayName(c.Names)
+		has, known := hasHealthcheck(c)
+		if !known {
+			continue
+		}
+		if has {
+			g.healthchecked[name] = true
+			continue
+		}
+		if g.healthchecked[name] {
+			delete(g.healthchecked, name)
+			g.logFor(ctx).Warnf("Container %s (%s) no longer has a health check - health monitoring lost", name, c.ID[:12])
+			g.notifier.Action(g.notifyEvent(withNotifyContext(ctx, c.Labels), name, c.ID, fmt.Sprintf("Container %s (%s) lost its health check and is no longer monitored for health", name, c.ID[:12])))
+		}
+	}
+}
+
+// auditFinding is one misconfiguration found by runAudits.
+type auditFinding struct {
+	key    string // stable identity for de-duplication
+	name   string // container name, empty for guardian-level findings
+	id     string
+	labels map[string]string
+	msg    string
+}
+
+// startAudits re-runs the misconfiguration audits every AUDIT_INTERVAL
+// seconds; the startup scan always runs them once.
+func (g *Guardian) startAudits(ctx context.Context) {
+	if g.cfg().AuditInterval <= 0 {
+		return
+	}
+	interval := time.Duration(g.cfg().AuditInterval) * time.Second
+	go func() {
+		for {
+			select {
+			case <-g.clock.After(interval):
+				g.runAudits(ctx)
+			case <-g.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// runAudits looks for setups that silently defeat the guardian: containers
+// explicitly opted in with AUTOHEAL_CONTAINER_LABEL that have no health check
+// (they can never be seen as unhealthy), and partly configured notification
+// services. Each finding is notified once; one that clears and comes back is
+// notified again.
+func (g *Guardian) runAudits(ctx context.Context) {
+	var findings []auditFinding
+	for _, p := range g.cfg().NotifierProblems() {
+		findings = append(findings, auditFinding{key: "notify:" + p, msg: "Notification config incomplete: " + p})
+	}
+	if g.cfg().ContainerLabel != "all" {
+		running, err := g.docker.RunningContainers(ctx)
+		if err != nil {
+			g.internalError(ctx, "failed to list running containers", err)
+			return
+		}
+		for _, c := range running {
+			if len(c.Names) == 0 || !g.isMonitored(c.Labels) {
+				continue
+			}
+			if has, known := hasHealthcheck(c); !known || has {
+				continue
+			}
+			name := displayName(c.Names)
+			findings = append(findings, auditFinding{
+				key:    "healthcheck:" + name,
+				name:   name,
+				id:     c.ID,
+				labels: c.Labels,
+				msg: fmt.Sprintf("Container %s (%s) is labelled %s=true but has no health check - it will never be restarted for being unhealthy",
+					name, shortContainerID(c.ID), g.cfg().ContainerLabel),
+			})
+		}
+	}
+
+	g.auditMu.Lock()
+	seen := make(map[string]bool, len(findings))
+	var fresh []auditFinding
+	for _, f := range findings {
+		seen[f.key] = true
+		if !g.auditReported[f.key] {
+			fresh = append(fresh, f)
+		}
+	}
+	g.auditReported = seen
+	g.auditMu.Unlock()
+
+	for _, f := range fresh {
+		g.logFor(ctx).Infof("Audit: %s", f.msg)
+		if f.name == "" {
+			g.notifier.Action(notify.Event{Text: "Audit: " + f.msg})
+			continue
+		}
+		g.notifier.Action(g.notifyEvent(withNotifyContext(ctx, f.labels), f.name, f.id, "Audit: "+f.msg))
+	}
+}
@@ -0,0 +1,122 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestHasHealthcheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		summary   container.Summary
+		has       bool
+		wantKnown bool
+	}{
+		{"health healthy", container.Summary{Health: &container.HealthSummary{Status: container.Healthy}}, true, true},
+		{"health none", container.Summary{Health: &container.HealthSummary{Status: container.NoHealthcheck}}, false, true},
+		{"legacy status", container.Summary{Status: "Up 5 minutes (healthy)"}, true, true},
+		{"legacy no hint", container.Summary{Status: "Up 5 minutes"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			has, known := hasHealthcheck(tt.summary)
+			if has != tt.has || known != tt.wantKnown {
+				t.Errorf("got (%v, %v), want (%v, %v)", has, known, tt.has, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestCheckHealthcheckLoss_NotifiesOnce(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyHealthcheckLoss: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	withCheck := container.Summary{
+		ID:     "app0001234567890abcdef",
+		Names:  []string{"/app"},
+		Health: &container.HealthSummary{Status: container.Healthy},
+	}
+	dock.runningContainers = []container.Summary{withCheck}
+	g.checkHealthcheckLoss(context.Background())
+	if len(notif.actions) != 0 {
+		t.Fatalf("expected no notification while health check present, got %v", notif.actions)
+	}
+
+	// Recreated from an image without HEALTHCHECK
+	withoutCheck := container.Summary{
+		ID:     "app0002234567890abcdef",
+		Names:  []string{"/app"},
+		Health: &container.HealthSummary{Status: container.NoHealthcheck},
+	}
+	dock.runningContainers = []container.Summary{withoutCheck}
+	g.checkHealthcheckLoss(context.Background())
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "lost its health check") {
+		t.Fatalf("expected one loss notification, got %v", notif.actions)
+	}
+
+	g.checkHealthcheckLoss(context.Background())
+	if len(notif.actions) != 1 {
+		t.Errorf("expected loss to be notified only once, got %v", notif.actions)
+	}
+}
+
+func TestCheckHealthcheckLoss_Disabled(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all"}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	g.healthchecked = map[string]bool{"app": true}
+	dock.runningContainers = []container.Summary{{
+		ID:     "app0002234567890abcdef",
+		Names:  []string{"/app"},
+		Health: &container.HealthSummary{Status: container.NoHealthcheck},
+	}}
+	g.checkHealthcheckLoss(context.Background())
+	if len(notif.actions) != 0 {
+		t.Errorf("expected no notification when disabled, got %v", notif.actions)
+	}
+}
+
+func TestRunAudits_NotifiesNewMisconfigurationOnce(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal"}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	healthy := container.Summary{
+		ID:     "web0001234567890abcdef",
+		Names:  []string{"/web"},
+		Labels: map[string]string{"autoheal": "true"},
+		Health: &container.HealthSummary{Status: container.Healthy},
+	}
+	dock.runningContainers = []container.Summary{healthy}
+	g.runAudits(context.Background())
+	if len(notif.actions) != 0 {
+		t.Fatalf("expected a clean first audit, got %v", notif.actions)
+	}
+
+	// A labelled container without a health check appears between runs
+	dock.runningContainers = append(dock.runningContainers, container.Summary{
+		ID:     "app0002234567890abcdef",
+		Names:  []string{"/app"},
+		Labels: map[string]string{"autoheal": "true"},
+		Health: &container.HealthSummary{Status: container.NoHealthcheck},
+	})
+	g.runAudits(context.Background())
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "app") || !strings.Contains(notif.actions[0], "no health check") {
+		t.Fatalf("expected one audit notification for app, got %v", notif.actions)
+	}
+
+	g.runAudits(context.Background())
+	if len(notif.actions) != 1 {
+		t.Errorf("expected an already-reported finding not to be re-notified, got %v", notif.actions)
+	}
+}
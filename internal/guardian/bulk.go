@@ -0,0 +1,120 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+	"github.com/moby/moby/api/types/container"
+)
+
+// ErrDisabled is returned by control actions while GUARDIAN_DISABLED is in effect.
+var ErrDisabled = errors.New("guardian actions are disabled")
+
+// BulkResult is the outcome of one container in a bulk restart.
+type BulkResult struct {
+	Container string `json:"container"`
+	ID        string `json:"id"`
+	Result    string `json:"result"` // success, failure, or the skip reason
+}
+
+// RestartAll runs the configured action (restart, stop, hardrestart, recreate
+// or a Swarm service update) on every running monitored container,
+// BULK_RESTART_CONCURRENCY at a time. Scope rules, exclusions, action=none,
+// action=notify and maintenance are always honoured; circuit breaker and
+// backoff are too unless force is set. Containers owned by a recovery loop
+// are left alone.
+func (g *Guardian) RestartAll(ctx context.Context, force bool) ([]BulkResult, error) {
+	if g.Disabled() {
+		return nil, ErrDisabled
+	}
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing running containers: %w", err)
+	}
+
+	limit := g.cfg().BulkRestartConcurrency
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []BulkResult
+	)
+	for _, c := range orderByDependency(running) {
+		if len(c.Names) == 0 || !g.isMonitored(c.Labels) {
+			continue
+		}
+		name := displayName(c.Names)
+		if action := containerAction(c.Labels); !g.inScope(c.ID, name) || g.excluded(ctx, name, c.Labels) || action == "none" || action == "notify" {
+			continue
+		}
+		if g.inMaintenance(ctx, c.ID, name, c.Labels) {
+			mu.Lock()
+			results = append(results, BulkResult{Container: name, ID: shortContainerID(c.ID), Result: "maintenance"})
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result := g.bulkRestart(withNotifyContext(ctx, c.Labels), c, name, force)
+			mu.Lock()
+			results = append(results, BulkResult{Container: name, ID: shortContainerID(c.ID), Result: result})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var restarted, failed, skipped int
+	for _, r := range results {
+		switch r.Result {
+		case "success":
+			restarted++
+		case "failure":
+			failed++
+		default:
+			skipped++
+		}
+	}
+	msg := fmt.Sprintf("Bulk restart: %d restarted, %d failed, %d skipped", restarted, failed, skipped)
+	if force {
+		msg += " (forced)"
+	}
+	g.logFor(ctx).Info(msg)
+	g.notifier.Action(notify.Event{Text: msg})
+	return results, nil
+}
+
+// bulkRestart runs one container's configured action for RestartAll and
+// returns its result. Health and threshold guards don't apply: the container
+// is acted on because it was asked for, not because it is unhealthy.
+func (g *Guardian) bulkRestart(ctx context.Context, c container.Summary, name string, force bool) string {
+	if g.recovering(c.ID) {
+		return "recovery-in-progress"
+	}
+	if g.Disabled() {
+		metrics.SkipsTotal.WithLabelValues(name, "disabled").Inc()
+		return "disabled"
+	}
+	if !force {
+		if allowed, reason := g.tracker.ShouldRestart(c.ID); !allowed {
+			metrics.SkipsTotal.WithLabelValues(name, string(reason)).Inc()
+			g.recordDecision(ctx, name, c.ID, "skip", string(reason))
+			return string(reason)
+		}
+	}
+	return g.runAction(ctx, c, name, containerAction(c.Labels), actionTrigger{
+		reason: "included in restart-all",
+		notify: shouldNotify(c.Labels),
+	}, &scanSummary{})
+}
@@ -0,0 +1,146 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func bulkContainers() []container.Summary {
+	return []container.Summary{
+		{ID: "web1234567890abcdef1234", Names: []string{"/web"}, State: "running", Labels: map[string]string{"autoheal": "true"}},
+		{ID: "api1234567890abcdef1234", Names: []string{"/api"}, State: "running", Labels: map[string]string{"autoheal": "true"}},
+		{ID: "db12345678901abcdef1234", Names: []string{"/db"}, State: "running", Labels: map[string]string{"autoheal": "true"}},
+		{ID: "off1234567890abcdef1234", Names: []string{"/off"}, State: "running", Labels: map[string]string{"autoheal": "true", "autoheal.action": "none"}},
+		{ID: "oth1234567890abcdef1234", Names: []string{"/other"}, State: "running", Labels: map[string]string{}},
+	}
+}
+
+func TestRestartAll_RestartsMonitoredRespectingCircuit(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal", DefaultStopTimeout: 10, BulkRestartConcurrency: 2}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	dock.runningContainers = bulkContainers()
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	// db was just restarted and is in backoff
+	g.tracker.RecordRestart("db12345678901abcdef1234")
+
+	results, err := g.RestartAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("RestartAll: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Container] = r.Result
+	}
+	want := map[string]string{"web": "success", "api": "success", "db": "backoff"}
+	if len(got) != len(want) {
+		t.Fatalf("expected results for %v, got %v", want, got)
+	}
+	for name, result := range want {
+		if got[name] != result {
+			t.Errorf("%s: got %q, want %q", name, got[name], result)
+		}
+	}
+
+	slices.Sort(dock.restartCalls)
+	if !slices.Equal(dock.restartCalls, []string{"api1234567890abcdef1234", "web1234567890abcdef1234"}) {
+		t.Errorf("unexpected restarts: %v", dock.restartCalls)
+	}
+	// One notification per restarted container, then the summary
+	if len(notif.actions) != 3 || notif.actions[2] != "Bulk restart: 2 restarted, 0 failed, 1 skipped" {
+		t.Errorf("expected two restart notifications and a summary, got %v", notif.actions)
+	}
+}
+
+func TestRestartAll_ForceOverridesCircuit(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal", DefaultStopTimeout: 10, BulkRestartConcurrency: 4}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	dock.runningContainers = bulkContainers()
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.tracker.RecordRestart("db12345678901abcdef1234")
+
+	if _, err := g.RestartAll(context.Background(), true); err != nil {
+		t.Fatalf("RestartAll: %v", err)
+	}
+	if len(dock.restartCalls) != 3 {
+		t.Errorf("expected all three monitored containers restarted, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartAll_RefusedWhileDisabled(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal", BulkRestartConcurrency: 1}
+	dock := newMockDocker()
+	dock.runningContainers = bulkContainers()
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	g.disabled.Store(true)
+
+	if _, err := g.RestartAll(context.Background(), true); !errors.Is(err, ErrDisabled) {
+		t.Errorf("expected ErrDisabled, got %v", err)
+	}
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("expected no restarts while disabled, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartAll_HonoursExclusions(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal", DefaultStopTimeout: 10, BulkRestartConcurrency: 2,
+		ExcludeNames: []string{"web"}, ExcludeLabel: "autoheal.exclude"}
+	dock := newMockDocker()
+	dock.runningContainers = bulkContainers()
+	dock.runningContainers[1].Labels = map[string]string{"autoheal": "true", "autoheal.exclude": "true"}
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+	results, err := g.RestartAll(context.Background(), true)
+	if err != nil {
+		t.Fatalf("RestartAll: %v", err)
+	}
+	if len(results) != 1 || results[0].Container != "db" {
+		t.Errorf("expected only db in the results, got %v", results)
+	}
+	if !slices.Equal(dock.restartCalls, []string{"db12345678901abcdef1234"}) {
+		t.Errorf("excluded containers should not be restarted, even when forced, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartAll_UsesConfiguredAction(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "autoheal", DefaultStopTimeout: 10, BulkRestartConcurrency: 2}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	dock.runningContainers = bulkContainers()
+	dock.runningContainers[0].Labels = map[string]string{"autoheal": "true", "autoheal.action": "notify"}
+	dock.runningContainers[1].Labels = map[string]string{"autoheal": "true", "autoheal.action": "stop"}
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	results, err := g.RestartAll(context.Background(), false)
+	if err != nil {
+		t.Fatalf("RestartAll: %v", err)
+	}
+	got := map[string]string{}
+	for _, r := range results {
+		got[r.Container] = r.Result
+	}
+	if _, ok := got["web"]; ok || got["api"] != "success" || got["db"] != "success" {
+		t.Errorf("expected web (action=notify) left out and api, db acted on, got %v", got)
+	}
+	if !slices.Equal(dock.restartCalls, []string{"db12345678901abcdef1234"}) {
+		t.Errorf("only db should be restarted, got %v", dock.restartCalls)
+	}
+	if !slices.Equal(dock.stopCalls, []string{"api1234567890abcdef1234"}) {
+		t.Errorf("api (action=stop) should be stopped rather than restarted, got %v", dock.stopCalls)
+	}
+}
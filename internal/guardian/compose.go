@@ -0,0 +1,137 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+)
+
+// Compose labels set by docker compose on every container it creates.
+const (
+	composeProjectLabel   = "com.docker.compose.project"
+	composeServiceLabel   = "com.docker.compose.service"
+	composeDependsOnLabel = "com.docker.compose.depends_on"
+)
+
+// composeDependencies returns the services named in a container's
+// com.docker.compose.depends_on label ("db:service_started:false,..."), or
+// nil outside a Compose project.
+func composeDependencies(labels map[string]string) []string {
+	if labels[composeProjectLabel] == "" {
+		return nil
+	}
+	var services []string
+	for _, entry := range strings.Split(labels[composeDependsOnLabel], ",") {
+		service, _, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		if service != "" && !slices.Contains(services, service) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// composeServicesRunning reports whether every listed service of the Compose
+// project has at least one running container.
+func (g *Guardian) composeServicesRunning(ctx context.Context, project string, services []string) bool {
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list running containers", err)
+		return false
+	}
+	for _, service := range services {
+		if !slices.ContainsFunc(running, func(c container.Summary) bool {
+			return c.Labels[composeProjectLabel] == project && c.Labels[composeServiceLabel] == service
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// restartComposeSiblings restarts the other replicas of an unhealthy container's
+// Compose service when it carries autoheal.compose.restart-service=true.
+// Each sibling is still subject to its own opt-outs, exclusions, action label,
+// circuit breaker and backoff.
+func (g *Guardian) restartComposeSiblings(ctx context.Context, id, name string, labels map[string]string) {
+	if labels["autoheal.compose.restart-service"] != "true" {
+		return
+	}
+	project := labels[composeProjectLabel]
+	service := labels[composeServiceLabel]
+	if project == "" || service == "" {
+		return
+	}
+
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list running containers", err)
+		return
+	}
+
+	for _, s := range running {
+		if s.ID == id || len(s.Names) == 0 {
+			continue
+		}
+		if s.Labels[composeProjectLabel] != project || s.Labels[composeServiceLabel] != service {
+			continue
+		}
+
+		// The same opt-outs that would spare the sibling if it were the
+		// unhealthy one
+		sibName := displayName(s.Names)
+		sibShortID := s.ID[:12]
+		if !g.isMonitored(s.Labels) || !g.inScope(s.ID, sibName) || s.Labels["autoheal"] == "False" {
+			continue
+		}
+		ctx := withNotifyContext(ctx, s.Labels)
+		if g.excluded(ctx, sibName, s.Labels) || g.inMaintenance(ctx, s.ID, sibName, s.Labels) {
+			continue
+		}
+		if action := containerAction(s.Labels); action == "none" || action == "notify" {
+			continue
+		}
+		if g.recovering(s.ID) {
+			g.logFor(ctx).Infof("Container %s (%s) - recovery in progress, not restarting with replica %s", sibName, sibShortID, name)
+			continue
+		}
+
+		if allowed, reason := g.tracker.ShouldRestart(s.ID); !allowed {
+			msg := g.tracker.FormatSkipReason(s.ID, sibName, reason)
+			g.logFor(ctx).Info(msg)
+			metrics.SkipsTotal.WithLabelValues(sibName, string(reason)).Inc()
+			g.recordDecision(ctx, sibName, s.ID, "skip", string(reason))
+			continue
+		}
+
+		timeout := g.stopTimeout(s.Labels)
+		g.logFor(ctx).Infof("Container %s (%s) restarting with unhealthy replica %s (compose service %s/%s)", sibName, sibShortID, name, project, service)
+
+		notify := shouldNotify(s.Labels)
+		start := time.Now()
+		if err := g.docker.RestartContainer(ctx, s.ID, timeout); err != nil {
+			if g.containerGone(ctx, s.ID, sibName, err) {
+				continue
+			}
+			g.logFor(ctx).Error("failed to restart container", "container", sibName, "id", sibShortID, "error", err)
+			if notify {
+				g.notifier.Action(g.decisionEvent(ctx, sibName, s.ID, "restart", "failure", fmt.Sprintf("Container %s (%s) restarted with compose service %s/%s. Failed to restart the container!", sibName, sibShortID, project, service)))
+			}
+			metrics.RestartsTotal.WithLabelValues(sibName, "failure").Inc()
+			g.recordDecision(ctx, sibName, s.ID, "restart", "failure")
+		} else {
+			if notify {
+				g.notifier.Action(g.decisionEvent(ctx, sibName, s.ID, "restart", "success", fmt.Sprintf("Container %s (%s) restarted with compose service %s/%s. Successfully restarted the container!", sibName, sibShortID, project, service)))
+			}
+			metrics.RestartsTotal.WithLabelValues(sibName, "success").Inc()
+			g.recordDecision(ctx, sibName, s.ID, "restart", "success")
+		}
+		metrics.RestartDuration.WithLabelValues(sibName).Observe(time.Since(start).Seconds())
+
+		g.recordRestart(s.ID, sibName)
+	}
+}
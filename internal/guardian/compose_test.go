@@ -0,0 +1,129 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func composeReplicas(restartService bool) (unhealthy, running []container.Summary) {
+	labels := map[string]string{
+		"com.docker.compose.project": "shop",
+		"com.docker.compose.service": "web",
+	}
+	if restartService {
+		labels["autoheal.compose.restart-service"] = "true"
+	}
+	replica1 := container.Summary{ID: "replica1234567890abcdef", Names: []string{"/shop-web-1"}, State: "running", Labels: labels}
+	replica2 := container.Summary{ID: "replica2234567890abcdef", Names: []string{"/shop-web-2"}, State: "running", Labels: labels}
+	other := container.Summary{
+		ID:     "othersvc234567890abcdef",
+		Names:  []string{"/shop-db-1"},
+		State:  "running",
+		Labels: map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "db"},
+	}
+	return []container.Summary{replica1}, []container.Summary{replica1, replica2, other}
+}
+
+func TestRestartComposeService_RestartsAllReplicas(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers, dock.runningContainers = composeReplicas(true)
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 2 {
+		t.Fatalf("expected both replicas restarted, got %v", dock.restartCalls)
+	}
+	if dock.restartCalls[0] != "replica1234567890abcdef" || dock.restartCalls[1] != "replica2234567890abcdef" {
+		t.Errorf("unexpected restart order: %v", dock.restartCalls)
+	}
+}
+
+func TestRestartComposeService_SkipsSiblingsWhenRestartFails(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers, dock.runningContainers = composeReplicas(true)
+	dock.restartErr["replica1234567890abcdef"] = errors.New("boom")
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "replica1234567890abcdef" {
+		t.Errorf("expected no sibling restarts after the primary failed, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartComposeService_WithoutLabel(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers, dock.runningContainers = composeReplicas(false)
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "replica1234567890abcdef" {
+		t.Errorf("expected only the unhealthy replica restarted, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartComposeService_RespectsSiblingCircuit(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers, dock.runningContainers = composeReplicas(true)
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	// Sibling is in backoff from a recent restart
+	g.tracker.RecordRestart("replica2234567890abcdef")
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "replica1234567890abcdef" {
+		t.Errorf("expected sibling in backoff to be skipped, got %v", dock.restartCalls)
+	}
+}
+
+func TestRestartComposeService_RespectsSiblingOptOuts(t *testing.T) {
+	for _, tt := range []struct {
+		name, label, value string
+	}{
+		{"opted out", "autoheal", "False"},
+		{"excluded", "autoheal.exclude", "true"},
+		{"action none", "autoheal.action", "none"},
+		{"action notify", "autoheal.action", "notify"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, ExcludeLabel: "autoheal.exclude"}
+			dock := newMockDocker()
+
+			dock.unhealthyContainers, dock.runningContainers = composeReplicas(true)
+			dock.runningContainers[1].Labels = maps.Clone(dock.runningContainers[1].Labels)
+			dock.runningContainers[1].Labels[tt.label] = tt.value
+
+			g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+			g.checkUnhealthy(context.Background())
+
+			if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "replica1234567890abcdef" {
+				t.Errorf("expected only the unhealthy replica restarted, got %v", dock.restartCalls)
+			}
+		})
+	}
+}
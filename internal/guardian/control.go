@@ -0,0 +1,48 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+)
+
+// ErrUnknownContainer is returned by ResetContainer when Docker has no such container.
+var ErrUnknownContainer = errors.New("unknown container")
+
+// ResetResult reports a manual circuit reset.
+type ResetResult struct {
+	Container   string `json:"container"`
+	ID          string `json:"id"`
+	CircuitOpen bool   `json:"circuit_was_open"` // whether the reset closed an open circuit
+}
+
+// ResetContainer clears a container's restart history, backoff and circuit
+// breaker, as a healthy event would, for an operator who has fixed the
+// underlying problem. It is safe to call while the guardian is running.
+func (g *Guardian) ResetContainer(ctx context.Context, nameOrID string) (ResetResult, error) {
+	info, err := g.docker.InspectContainer(ctx, nameOrID)
+	if docker.IsNotFound(err) {
+		return ResetResult{}, ErrUnknownContainer
+	}
+	if err != nil {
+		return ResetResult{}, fmt.Errorf("inspecting container: %w", err)
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+	wasOpen := g.resetTracking(info.ID, name)
+
+	g.logFor(ctx).Info("container circuit reset via API", "container", name, "id", shortContainerID(info.ID), "circuit_was_open", wasOpen)
+	return ResetResult{Container: name, ID: shortContainerID(info.ID), CircuitOpen: wasOpen}, nil
+}
+
+// TriggerScan asks the monitoring loop for a full scan. The scan runs on the
+// loop's own goroutine, so it never overlaps a scheduled one; requests made
+// while one is already pending are merged into it.
+func (g *Guardian) TriggerScan() {
+	select {
+	case g.rescanCh <- struct{}{}:
+	default:
+	}
+}
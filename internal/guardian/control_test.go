@@ -0,0 +1,52 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestResetContainer_ClosesOpenCircuit(t *testing.T) {
+	clk := newMockClock(time.Now())
+	dock := newMockDocker()
+	g := newTestGuardian(&config.Config{ContainerLabel: "all"}, dock, &mockNotifier{}, clk)
+	g.tracker = NewRestartTracker(TrackerConfig{BackoffMultiplier: 2, BackoffMax: time.Second, RestartBudget: 1, RestartWindow: time.Hour}, clk)
+
+	id := "abcdef1234567890abcdef"
+	dock.inspectResults["app"] = container.InspectResponse{ID: id, Name: "/app"}
+	g.tracker.RecordRestart(id)
+	clk.Advance(2 * time.Second)
+	if allowed, _ := g.tracker.ShouldRestart(id); allowed {
+		t.Fatal("expected the circuit to be open")
+	}
+
+	got, err := g.ResetContainer(context.Background(), "app")
+	if err != nil {
+		t.Fatalf("ResetContainer: %v", err)
+	}
+	if got != (ResetResult{Container: "app", ID: "abcdef123456", CircuitOpen: true}) {
+		t.Errorf("result = %+v", got)
+	}
+	if allowed, _ := g.tracker.ShouldRestart(id); !allowed {
+		t.Error("expected restarts allowed after the reset")
+	}
+
+	dock.inspectErr["gone"] = cerrdefs.ErrNotFound
+	if _, err := g.ResetContainer(context.Background(), "gone"); !errors.Is(err, ErrUnknownContainer) {
+		t.Errorf("expected ErrUnknownContainer, got %v", err)
+	}
+}
+
+func TestTriggerScan_CoalescesPendingRequests(t *testing.T) {
+	g := newTestGuardian(&config.Config{}, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+	g.TriggerScan()
+	g.TriggerScan()
+	if n := len(g.rescanCh); n != 1 {
+		t.Errorf("expected one pending scan request, got %d", n)
+	}
+}
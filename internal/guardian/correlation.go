@@ -0,0 +1,97 @@
+package guardian
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+)
+
+type correlationKey struct{}
+
+type notifyContextKey struct{}
+
+// notifyContext is what a container's labels contribute to its notifications.
+type notifyContext struct {
+	services []string // autoheal.notify.only
+	project  string   // com.docker.compose.project
+	service  string   // com.docker.compose.service
+}
+
+// newCorrelationID returns a short random ID tying together the log records
+// and notifications produced while processing one Docker event.
+func newCorrelationID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// withCorrelationID returns a context carrying the given correlation ID.
+func withCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// correlationID returns the correlation ID carried by ctx, or "".
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// withNotifyContext returns a context carrying the notification services a
+// container is pinned to via autoheal.notify.only and its Compose project
+// and service, if any.
+func withNotifyContext(ctx context.Context, labels map[string]string) context.Context {
+	nc := notifyContext{
+		services: notifyServices(labels),
+		project:  labels[composeProjectLabel],
+		service:  labels[composeServiceLabel],
+	}
+	if len(nc.services) == 0 && nc.project == "" && nc.service == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, notifyContextKey{}, nc)
+}
+
+// logFor returns the guardian logger, tagged with the correlation ID from ctx if present.
+func (g *Guardian) logFor(ctx context.Context) *logging.Logger {
+	if id := correlationID(ctx); id != "" {
+		return g.log.With("correlation_id", id)
+	}
+	return g.log
+}
+
+// notifyEvent builds a notification event for a container, carrying the
+// correlation ID, any pinned notification services and the Compose project
+// and service from ctx. Service falls back to the container name outside
+// Compose.
+func (g *Guardian) notifyEvent(ctx context.Context, name, id, text string) notify.Event {
+	nc, _ := ctx.Value(notifyContextKey{}).(notifyContext)
+	service := nc.service
+	if service == "" {
+		service = name
+	}
+	if g.cfg().DryRun {
+		text = "[DRY RUN] " + text
+	}
+	return notify.Event{
+		Text:          text,
+		Container:     name,
+		ContainerID:   id,
+		Project:       nc.project,
+		Service:       service,
+		CorrelationID: correlationID(ctx),
+		Services:      nc.services,
+	}
+}
+
+// decisionEvent is notifyEvent for a notification reporting a decision, so
+// NOTIFY_TEMPLATE_* can use its action and result.
+func (g *Guardian) decisionEvent(ctx context.Context, name, id, action, result, text string) notify.Event {
+	evt := g.notifyEvent(ctx, name, id, text)
+	evt.Action, evt.Result = action, result
+	return evt
+}
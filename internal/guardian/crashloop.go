@@ -0,0 +1,47 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+)
+
+// watchCrashloop re-checks a container AUTOHEAL_CRASHLOOP_WINDOW seconds after
+// a successful restart. A container that is no longer running by then was
+// restarted but exited again — distinct from a failed restart — and is
+// reported as crash-looping.
+func (g *Guardian) watchCrashloop(ctx context.Context, id, name string, notify bool) {
+	if g.cfg().CrashloopWindow <= 0 {
+		return
+	}
+	g.verifying.Add(1)
+	go func() {
+		defer g.verifying.Done()
+
+		select {
+		case <-g.clock.After(time.Duration(g.cfg().CrashloopWindow) * time.Second):
+		case <-ctx.Done():
+			return
+		}
+
+		status, err := g.docker.ContainerStatus(ctx, id)
+		if err != nil {
+			g.logFor(ctx).Error("failed to verify restarted container", "container", name, "id", id[:12], "error", err)
+			return
+		}
+		if status == "running" {
+			return
+		}
+
+		shortID := id[:12]
+		g.logFor(ctx).Warnf("Container %s (%s) restarted but is %s within %ds - crash-looping", name, shortID, status, g.cfg().CrashloopWindow)
+		metrics.CrashloopDetectedTotal.WithLabelValues(name).Inc()
+		g.recordDecision(ctx, name, id, "verify", "crashloop")
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "verify", "crashloop", fmt.Sprintf("Container %s (%s) restarted but exited again within %ds (now %s) - crash-looping!",
+				name, shortID, g.cfg().CrashloopWindow, status)))
+		}
+	}()
+}
@@ -0,0 +1,56 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func crashloopGuardian(status string) (*Guardian, *mockDocker, *mockNotifier) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, CrashloopWindow: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/flaky-app"}, State: "running"}}
+	dock.statusResults[id] = status
+
+	return newTestGuardian(cfg, dock, notif, clk), dock, notif
+}
+
+func TestCrashloop_DetectedAfterRestart(t *testing.T) {
+	g, dock, notif := crashloopGuardian("exited")
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	if len(dock.restartCalls) != 1 {
+		t.Fatalf("expected 1 restart, got %d", len(dock.restartCalls))
+	}
+	notif.mu.Lock()
+	defer notif.mu.Unlock()
+	if len(notif.actions) != 2 || !strings.Contains(notif.actions[1], "crash-looping") {
+		t.Errorf("expected restart then crash-loop notification, got %v", notif.actions)
+	}
+	if !strings.Contains(notif.actions[0], "Successfully restarted") {
+		t.Errorf("restart itself should still report success, got %q", notif.actions[0])
+	}
+}
+
+func TestCrashloop_StableAfterRestart(t *testing.T) {
+	g, _, notif := crashloopGuardian("running")
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	notif.mu.Lock()
+	defer notif.mu.Unlock()
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Successfully restarted") {
+		t.Errorf("expected only the restart notification, got %v", notif.actions)
+	}
+}
@@ -3,37 +3,127 @@ package guardian
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
 )
 
+// dependencyLinks reports which links AUTOHEAL_DEPENDENCY_MODE follows:
+// container:X network namespaces (the default), Compose depends_on, or both.
+func (g *Guardian) dependencyLinks() (netns, compose bool) {
+	switch g.cfg().DependencyMode {
+	case "compose":
+		return false, true
+	case "both":
+		return true, true
+	default:
+		return true, false
+	}
+}
+
+// dependencyCandidates narrows the exited list to containers worth inspecting.
+// The list already carries the network mode and labels, so only container:X
+// dependents (an empty mode means unknown and is kept) and, in compose mode,
+// containers with depends_on labels are kept. With DEPENDENCY_SCAN_LIMIT set,
+// at most that many are returned per cycle, rotating through the rest on
+// later cycles.
+func (g *Guardian) dependencyCandidates(exited []container.Summary) []container.Summary {
+	netns, compose := g.dependencyLinks()
+	var candidates []container.Summary
+	for _, c := range exited {
+		mode := c.HostConfig.NetworkMode
+		switch {
+		case netns && (mode == "" || strings.HasPrefix(mode, "container:")),
+			compose && len(composeDependencies(c.Labels)) > 0:
+			candidates = append(candidates, c)
+		}
+	}
+
+	limit := g.cfg().DependencyScanLimit
+	if limit <= 0 || len(candidates) <= limit {
+		return candidates
+	}
+	// Each caller claims its own slice of the rotation
+	end := g.dependencyCursor.Add(int64(limit))
+	start := int((end - int64(limit)) % int64(len(candidates)))
+	batch := make([]container.Summary, 0, limit)
+	for i := range limit {
+		batch = append(batch, candidates[(start+i)%len(candidates)])
+	}
+	return batch
+}
+
+// orphanLink is what an exited dependent relies on: its container:X network
+// parent, or the Compose services it depends on.
+type orphanLink struct {
+	desc    string      // for logs, e.g. "parent 0123456789ab"
+	reason  string      // for notifications, e.g. "parent running"
+	running func() bool // whether everything linked is running now
+}
+
+// orphanLinkFor returns the link AUTOHEAL_DEPENDENCY_MODE follows for an
+// exited container, preferring container:X. Compose dependents that exited
+// cleanly (code 0), such as one-shot jobs, are left alone.
+func (g *Guardian) orphanLinkFor(ctx context.Context, info container.InspectResponse) (orphanLink, bool) {
+	netns, compose := g.dependencyLinks()
+	if parentID, ok := strings.CutPrefix(string(info.HostConfig.NetworkMode), "container:"); ok {
+		if !netns {
+			return orphanLink{}, false
+		}
+		return orphanLink{
+			desc:   "parent " + shortContainerID(parentID),
+			reason: "parent running",
+			running: func() bool {
+				status, err := g.docker.ContainerStatus(ctx, parentID)
+				return err == nil && status == "running"
+			},
+		}, true
+	}
+	labels := info.Config.Labels
+	services := composeDependencies(labels)
+	if !compose || len(services) == 0 || info.State.ExitCode == 0 {
+		return orphanLink{}, false
+	}
+	project := labels[composeProjectLabel]
+	return orphanLink{
+		desc:    fmt.Sprintf("compose dependencies %s/%s", project, strings.Join(services, ",")),
+		reason:  "compose dependencies running",
+		running: func() bool { return g.composeServicesRunning(ctx, project, services) },
+	}, true
+}
+
 // checkDependencyOrphans finds exited containers whose parent (via container:X
-// network mode) is still running, and starts them.
+// network mode) or Compose dependencies (AUTOHEAL_DEPENDENCY_MODE) are still
+// running, and starts them.
 func (g *Guardian) checkDependencyOrphans(ctx context.Context) {
-	if !g.cfg.MonitorDependencies {
+	if !g.cfg().MonitorDependencies {
 		return
 	}
 
 	exited, err := g.docker.ExitedContainers(ctx)
 	if err != nil {
-		g.log.Error("failed to list exited containers", "error", err)
+		g.internalError(ctx, "failed to list exited containers", err)
 		return
 	}
 
-	for _, c := range exited {
+	for _, c := range g.dependencyCandidates(exited) {
 		info, err := g.docker.InspectContainer(ctx, c.ID)
 		if err != nil {
+			if docker.ClassifyError(err) != docker.ErrorNotFound {
+				g.internalError(ctx, "failed to inspect container", err, "id", shortContainerID(c.ID))
+			}
 			continue
 		}
-
-		networkMode := string(info.HostConfig.NetworkMode)
-		if !strings.HasPrefix(networkMode, "container:") {
+		if info.HostConfig == nil || info.Config == nil || info.State == nil {
 			continue
 		}
 
-		parentID := strings.TrimPrefix(networkMode, "container:")
-		parentStatus, err := g.docker.ContainerStatus(ctx, parentID)
-		if err != nil || parentStatus != "running" {
+		link, ok := g.orphanLinkFor(ctx, info)
+		if !ok || !link.running() {
 			continue
 		}
 
@@ -41,28 +131,45 @@ func (g *Guardian) checkDependencyOrphans(ctx context.Context) {
 		name := strings.TrimPrefix(info.Name, "/")
 		exitCode := info.State.ExitCode
 		labels := info.Config.Labels
+		ctx := withNotifyContext(ctx, labels)
+
+		if !g.inScope(c.ID, name) || g.excluded(ctx, name, labels) {
+			continue
+		}
+
+		if v := labels["autoheal.dependency"]; v == "false" || v == "False" {
+			g.logFor(ctx).Infof("Container %s (%s) orphaned but opted out (autoheal.dependency=%s) - skipping", name, shortID, v)
+			continue
+		}
+
+		if g.inMaintenance(ctx, c.ID, name, labels) {
+			continue
+		}
+
+		if g.Disabled() {
+			g.logFor(ctx).Infof("Container %s (%s) orphaned - actions disabled, skipping", name, shortID)
+			metrics.SkipsTotal.WithLabelValues(name, "disabled").Inc()
+			continue
+		}
 
 		if g.shouldSkip(ctx, c.ID, name, labels) {
 			continue
 		}
 
-		now := g.clock.Now().Format("02-01-2006 15:04:05")
-		fmt.Printf("%s Container %s (%s) exited (code %d, orphaned dependent) - parent %s is running\n",
-			now, name, shortID, exitCode, parentID[:12])
+		g.logFor(ctx).Infof("Container %s (%s) exited (code %d, orphaned dependent) - %s running", name, shortID, exitCode, link.desc)
 
-		if g.cfg.DependencyStartDelay > 0 {
-			fmt.Printf("%s Waiting %ds before starting %s...\n", now, g.cfg.DependencyStartDelay, name)
+		if g.cfg().DependencyStartDelay > 0 {
+			g.logFor(ctx).Debugf("Waiting %ds before starting %s...", g.cfg().DependencyStartDelay, name)
 
 			select {
-			case <-time.After(time.Duration(g.cfg.DependencyStartDelay) * time.Second):
+			case <-time.After(time.Duration(g.cfg().DependencyStartDelay) * time.Second):
 			case <-ctx.Done():
 				return
 			}
 
 			// Re-check parent
-			parentStatus, err = g.docker.ContainerStatus(ctx, parentID)
-			if err != nil || parentStatus != "running" {
-				fmt.Printf("%s Parent %s no longer running after delay - skipping %s\n", now, parentID[:12], name)
+			if !link.running() {
+				g.logFor(ctx).Infof("%s no longer running after delay - skipping %s", link.desc, name)
 				continue
 			}
 		}
@@ -70,19 +177,68 @@ func (g *Guardian) checkDependencyOrphans(ctx context.Context) {
 		// Re-check container hasn't auto-recovered
 		currentStatus, err := g.docker.ContainerStatus(ctx, c.ID)
 		if err == nil && currentStatus != "exited" {
-			fmt.Printf("%s Container %s (%s) is now %s - no action needed\n", now, name, shortID, currentStatus)
+			g.logFor(ctx).Debugf("Container %s (%s) is now %s - no action needed", name, shortID, currentStatus)
 			continue
 		}
 
-		fmt.Printf("%s Starting orphaned dependent %s (%s)...\n", now, name, shortID)
+		g.logFor(ctx).Debugf("Starting orphaned dependent %s (%s)...", name, shortID)
+		result := "success"
 		if err := g.docker.StartContainer(ctx, c.ID); err != nil {
-			g.log.Error("failed to start container", "container", name, "id", shortID, "error", err)
-			g.notifier.Action(fmt.Sprintf("Container %s (%s) orphaned (parent running). Failed to start!", name, shortID))
+			if g.containerGone(ctx, c.ID, name, err) {
+				continue
+			}
+			result = "failure"
+			class, _ := g.actionFailed(name, "start", err)
+			g.logFor(ctx).Error("failed to start container", "container", name, "id", shortID, "class", class, "error", err)
+			g.notifier.Action(g.decisionEvent(ctx, name, c.ID, "start", "failure", fmt.Sprintf("Container %s (%s) orphaned (%s). Failed to start: %s!", name, shortID, link.reason, class.Describe())))
+			g.recordDecision(ctx, name, c.ID, "start", "failure")
 		} else {
-			fmt.Printf("%s Successfully started %s (%s)\n", now, name, shortID)
-			g.notifier.Action(fmt.Sprintf("Container %s (%s) orphaned (parent running). Successfully started!", name, shortID))
+			g.logFor(ctx).Infof("Successfully started %s (%s)", name, shortID)
+			g.notifier.Action(g.decisionEvent(ctx, name, c.ID, "start", "success", fmt.Sprintf("Container %s (%s) orphaned (%s). Successfully started!", name, shortID, link.reason)))
+			g.recordDecision(ctx, name, c.ID, "start", "success")
 		}
 
-		g.runPostRestartScript(name, shortID, "orphaned", 0)
+		g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: c.ID, Action: "start", Result: result, State: "orphaned"})
+	}
+}
+
+// orderByDependency returns containers sorted so that container:X network
+// parents come before their dependents, keeping the original order otherwise.
+// Restarting a dependent before its parent recovers just fails again.
+func orderByDependency(containers []container.Summary) []container.Summary {
+	parentOf := func(c container.Summary) int {
+		ref, ok := strings.CutPrefix(c.HostConfig.NetworkMode, "container:")
+		if !ok || ref == "" {
+			return -1
+		}
+		for i, p := range containers {
+			if p.ID == c.ID {
+				continue
+			}
+			if strings.HasPrefix(p.ID, ref) || (len(p.Names) > 0 && displayName(p.Names) == ref) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// depth = number of unhealthy ancestors; bounded by len to survive cycles
+	depth := make([]int, len(containers))
+	for i, c := range containers {
+		for p := parentOf(c); p >= 0 && depth[i] < len(containers); p = parentOf(containers[p]) {
+			depth[i]++
+		}
+	}
+
+	idx := make([]int, len(containers))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return depth[idx[a]] < depth[idx[b]] })
+
+	ordered := make([]container.Summary, len(containers))
+	for i, j := range idx {
+		ordered[i] = containers[j]
 	}
+	return ordered
 }
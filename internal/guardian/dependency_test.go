@@ -2,7 +2,10 @@ package guardian
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -44,13 +47,13 @@ func TestCheckDependencyOrphans_StartsOrphan(t *testing.T) {
 	dock.statusResults["orphan01234567890abcdef"] = "exited"
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkDependencyOrphans(context.Background())
 
@@ -90,13 +93,13 @@ func TestCheckDependencyOrphans_SkipsNonDependents(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkDependencyOrphans(context.Background())
 
@@ -133,13 +136,13 @@ func TestCheckDependencyOrphans_SkipsWhenParentNotRunning(t *testing.T) {
 	dock.statusResults[parentID] = "exited" // Parent not running
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkDependencyOrphans(context.Background())
 
@@ -157,13 +160,13 @@ func TestCheckDependencyOrphans_Disabled(t *testing.T) {
 	clk := newMockClock(time.Now())
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkDependencyOrphans(context.Background())
 
@@ -206,13 +209,13 @@ func TestCheckDependencyOrphans_SkipsAutoRecovered(t *testing.T) {
 	dock.statusResults["orphan01234567890abcdef"] = "running"
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkDependencyOrphans(context.Background())
 
@@ -220,3 +223,287 @@ func TestCheckDependencyOrphans_SkipsAutoRecovered(t *testing.T) {
 		t.Error("should not start auto-recovered container")
 	}
 }
+
+func TestCheckDependencyOrphans_LabelOptOut(t *testing.T) {
+	cfg := &config.Config{MonitorDependencies: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	parentID := "parent1234567890abcdef"
+	orphan := func(name string, labels map[string]string) container.InspectResponse {
+		return container.InspectResponse{
+			Name:       name,
+			HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + parentID)},
+			Config:     &container.Config{Labels: labels},
+			State:      &container.State{ExitCode: 0},
+		}
+	}
+
+	dock.exitedContainers = []container.Summary{
+		{ID: "sidecar1234567890abcdef"},
+		{ID: "orphan01234567890abcdef"},
+	}
+	dock.inspectResults["sidecar1234567890abcdef"] = orphan("/short-lived", map[string]string{"autoheal.dependency": "false"})
+	dock.inspectResults["orphan01234567890abcdef"] = orphan("/orphan-app", map[string]string{})
+	dock.statusResults[parentID] = "running"
+	dock.statusResults["sidecar1234567890abcdef"] = "exited"
+	dock.statusResults["orphan01234567890abcdef"] = "exited"
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkDependencyOrphans(context.Background())
+
+	if len(dock.startCalls) != 1 || dock.startCalls[0] != "orphan01234567890abcdef" {
+		t.Errorf("expected only the normal orphan started, got %v", dock.startCalls)
+	}
+}
+
+func TestCheckDependencyOrphans_InspectsOnlyCandidates(t *testing.T) {
+	cfg := &config.Config{MonitorDependencies: true}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+
+	for i := range 50 {
+		c := container.Summary{ID: fmt.Sprintf("cijob%018d", i)}
+		c.HostConfig.NetworkMode = "bridge"
+		dock.exitedContainers = append(dock.exitedContainers, c)
+	}
+	dep := container.Summary{ID: "sidecar1234567890abcdef"}
+	dep.HostConfig.NetworkMode = "container:parent1234567890abcdef"
+	dock.exitedContainers = append(dock.exitedContainers, dep)
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.checkDependencyOrphans(context.Background())
+
+	if dock.inspectCalls != 1 {
+		t.Errorf("expected only the container:X dependent inspected, got %d inspects", dock.inspectCalls)
+	}
+}
+
+func TestCheckDependencyOrphans_ScanLimit(t *testing.T) {
+	cfg := &config.Config{MonitorDependencies: true, DependencyScanLimit: 3}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+
+	for i := range 5 {
+		dock.exitedContainers = append(dock.exitedContainers, container.Summary{ID: fmt.Sprintf("unknown%016d", i)})
+	}
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.checkDependencyOrphans(context.Background())
+	if dock.inspectCalls != 3 {
+		t.Fatalf("expected 3 inspects with DEPENDENCY_SCAN_LIMIT=3, got %d", dock.inspectCalls)
+	}
+
+	// Next cycle continues where the last left off
+	batch := g.dependencyCandidates(dock.exitedContainers)
+	if len(batch) != 3 || batch[0].ID != dock.exitedContainers[3].ID || batch[2].ID != dock.exitedContainers[0].ID {
+		t.Errorf("expected rotation to continue from the deferred containers, got %v", batch)
+	}
+}
+
+func TestDependencyCandidates_ConcurrentCallersShareTheRotation(t *testing.T) {
+	cfg := &config.Config{MonitorDependencies: true, DependencyScanLimit: 2}
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+
+	var exited []container.Summary
+	for i := range 8 {
+		exited = append(exited, container.Summary{ID: fmt.Sprintf("unknown%016d", i)})
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = map[string]int{}
+		wg   sync.WaitGroup
+	)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batch := g.dependencyCandidates(exited)
+			mu.Lock()
+			defer mu.Unlock()
+			for _, c := range batch {
+				seen[c.ID]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Four batches of two cover all eight containers exactly once
+	if len(seen) != len(exited) {
+		t.Errorf("expected every container in exactly one batch, got %v", seen)
+	}
+}
+
+func TestCheckUnhealthy_RestartsParentFirst(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+
+	parentID := "vpn000001234567890abcdef"
+	dependent := func(id, name string) container.Summary {
+		c := container.Summary{ID: id, Names: []string{name}, State: "running"}
+		c.HostConfig.NetworkMode = "container:" + parentID
+		return c
+	}
+	parent := container.Summary{ID: parentID, Names: []string{"/vpn"}, State: "running"}
+	parent.HostConfig.NetworkMode = "bridge"
+
+	dock.unhealthyContainers = []container.Summary{
+		dependent("torrent1234567890abcdef", "/torrent"),
+		parent,
+		dependent("indexer1234567890abcdef", "/indexer"),
+	}
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.checkUnhealthy(context.Background())
+
+	want := []string{parentID, "torrent1234567890abcdef", "indexer1234567890abcdef"}
+	if len(dock.restartCalls) != len(want) {
+		t.Fatalf("expected %d restarts, got %v", len(want), dock.restartCalls)
+	}
+	for i, id := range want {
+		if dock.restartCalls[i] != id {
+			t.Errorf("restart %d = %s, want %s (parent first, dependents in original order)", i, dock.restartCalls[i], id)
+		}
+	}
+}
+
+// hookClock runs onAfter when a wait starts, to change the world mid-wait.
+type hookClock struct {
+	*mockClock
+	onAfter func(time.Duration)
+}
+
+func (c *hookClock) After(d time.Duration) <-chan time.Time {
+	if c.onAfter != nil {
+		c.onAfter(d)
+	}
+	return c.mockClock.After(d)
+}
+
+func dieGraceGuardian(parentBefore, parentAfter string) (*Guardian, *mockDocker, *[]time.Duration) {
+	cfg := &config.Config{MonitorDependencies: true, DependencyDieGrace: 3}
+	dock := newMockDocker()
+	parentID := "parent1234567890abcdef"
+	childID := "child01234567890abcdef"
+
+	dock.exitedContainers = []container.Summary{{ID: childID}}
+	dock.inspectResults[childID] = container.InspectResponse{
+		Name:       "/vpn-client",
+		HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + parentID)},
+		Config:     &container.Config{Labels: map[string]string{}},
+		State:      &container.State{ExitCode: 128},
+	}
+	dock.statusResults[parentID] = parentBefore
+	dock.statusResults[childID] = "exited"
+
+	var waits []time.Duration
+	clk := &hookClock{mockClock: newMockClock(time.Now())}
+	clk.onAfter = func(d time.Duration) {
+		waits = append(waits, d)
+		dock.statusResults[parentID] = parentAfter
+	}
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk.mockClock)
+	g.clock = clk
+	return g, dock, &waits
+}
+
+func TestDieGrace_WaitsForParentToSettle(t *testing.T) {
+	// Parent is mid-restart when the child dies, and back up once the grace ends
+	g, dock, waits := dieGraceGuardian("restarting", "running")
+
+	g.checkOrphanedDependents(context.Background(), "child01234567890abcdef")
+
+	if len(*waits) != 1 || (*waits)[0] != 3*time.Second {
+		t.Errorf("expected a single 3s grace wait, got %v", *waits)
+	}
+	if len(dock.startCalls) != 1 {
+		t.Errorf("expected orphan started once parent confirmed running, got %d starts", len(dock.startCalls))
+	}
+}
+
+func TestDieGrace_ParentDownAfterGrace(t *testing.T) {
+	// Parent looked fine at the die event but went down during the grace
+	g, dock, _ := dieGraceGuardian("running", "restarting")
+
+	g.checkOrphanedDependents(context.Background(), "child01234567890abcdef")
+
+	if len(dock.startCalls) != 0 {
+		t.Errorf("orphan must not start while parent is not running, got %d starts", len(dock.startCalls))
+	}
+}
+
+// composeDependent registers an exited Compose container that depends_on db.
+func composeDependent(dock *mockDocker, id string, exitCode int) {
+	labels := map[string]string{
+		composeProjectLabel:   "shop",
+		composeServiceLabel:   "web",
+		composeDependsOnLabel: "db:service_healthy:false",
+	}
+	c := container.Summary{ID: id, Labels: labels}
+	c.HostConfig.NetworkMode = "shop_default"
+	dock.exitedContainers = append(dock.exitedContainers, c)
+	dock.inspectResults[id] = container.InspectResponse{
+		Name:       "/shop-web-1",
+		HostConfig: &container.HostConfig{NetworkMode: "shop_default"},
+		Config:     &container.Config{Labels: labels},
+		State:      &container.State{ExitCode: exitCode},
+	}
+	dock.statusResults[id] = "exited"
+}
+
+func TestCheckDependencyOrphans_ComposeDependsOn(t *testing.T) {
+	id := "web0001234567890abcdef"
+	db := container.Summary{ID: "db00001234567890abcdef", Labels: map[string]string{composeProjectLabel: "shop", composeServiceLabel: "db"}}
+	otherProjectDB := container.Summary{ID: "db00021234567890abcdef", Labels: map[string]string{composeProjectLabel: "blog", composeServiceLabel: "db"}}
+
+	tests := []struct {
+		name      string
+		mode      string
+		exitCode  int
+		running   []container.Summary
+		wantStart bool
+	}{
+		{"dependency running", "compose", 1, []container.Summary{db}, true},
+		{"both modes", "both", 1, []container.Summary{db}, true},
+		{"dependency down", "compose", 1, nil, false},
+		{"dependency in another project", "compose", 1, []container.Summary{otherProjectDB}, false},
+		{"clean exit left alone", "compose", 0, []container.Summary{db}, false},
+		{"netns mode ignores compose links", "netns", 1, []container.Summary{db}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MonitorDependencies: true, DependencyMode: tt.mode}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+			composeDependent(dock, id, tt.exitCode)
+			dock.runningContainers = tt.running
+
+			g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+			g.checkDependencyOrphans(context.Background())
+
+			if got := len(dock.startCalls) == 1; got != tt.wantStart {
+				t.Fatalf("started = %v, want %v (starts %v)", got, tt.wantStart, dock.startCalls)
+			}
+			if tt.wantStart && (len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "compose dependencies running")) {
+				t.Errorf("unexpected notifications: %v", notif.actions)
+			}
+		})
+	}
+}
+
+func TestComposeDependencies(t *testing.T) {
+	got := composeDependencies(map[string]string{
+		composeProjectLabel:   "shop",
+		composeDependsOnLabel: "db:service_healthy:false, redis:service_started:true,db:service_started:false",
+	})
+	if fmt.Sprint(got) != "[db redis]" {
+		t.Errorf("composeDependencies = %v, want [db redis]", got)
+	}
+	if got := composeDependencies(map[string]string{composeDependsOnLabel: "db:service_started:false"}); got != nil {
+		t.Errorf("outside a project = %v, want nil", got)
+	}
+}
@@ -0,0 +1,37 @@
+package guardian
+
+import (
+	"fmt"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+)
+
+// Disabled reports whether all guardian actions are halted. Observation
+// (scans, counters, logs) continues while disabled.
+func (g *Guardian) Disabled() bool {
+	return g.disabled.Load()
+}
+
+// SetDisabled halts or resumes all guardian actions, logging and notifying
+// once per state change. source describes who flipped the switch.
+func (g *Guardian) SetDisabled(disabled bool, source string) {
+	if g.disabled.Swap(disabled) == disabled {
+		return
+	}
+	g.announceDisabled(source)
+}
+
+// announceDisabled reports the current disabled state.
+func (g *Guardian) announceDisabled(source string) {
+	state := "ENABLED"
+	gauge := 0.0
+	if g.disabled.Load() {
+		state = "DISABLED"
+		gauge = 1
+	}
+	metrics.Disabled.Set(gauge)
+
+	g.log.Infof("Docker-Guardian actions %s (%s)", state, source)
+	g.notifier.Action(notify.Event{Text: fmt.Sprintf("Docker-Guardian actions %s (%s)", state, source)})
+}
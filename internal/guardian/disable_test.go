@@ -0,0 +1,56 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestDisabled_NoActionsUntilEnabled(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, MonitorDependencies: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	parentID := "parent1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: "abcdef1234567890abcdef", Names: []string{"/web"}, State: "running"}}
+	dock.exitedContainers = []container.Summary{{ID: "orphan01234567890abcdef"}}
+	dock.inspectResults["orphan01234567890abcdef"] = container.InspectResponse{
+		Name:       "/orphan-app",
+		HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + parentID)},
+		Config:     &container.Config{Labels: map[string]string{}},
+		State:      &container.State{ExitCode: 1},
+	}
+	dock.statusResults[parentID] = "running"
+	dock.statusResults["orphan01234567890abcdef"] = "exited"
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.SetDisabled(true, "test")
+	g.fullScan(context.Background())
+
+	if len(dock.restartCalls) != 0 || len(dock.startCalls) != 0 || len(dock.stopCalls) != 0 {
+		t.Fatalf("expected no actions while disabled, got restarts=%v starts=%v stops=%v",
+			dock.restartCalls, dock.startCalls, dock.stopCalls)
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "actions DISABLED") {
+		t.Fatalf("expected a single DISABLED notification, got %v", notif.actions)
+	}
+	if !g.Status().Disabled {
+		t.Error("expected /status to report disabled")
+	}
+
+	g.SetDisabled(true, "test") // no-op, no second notification
+	g.SetDisabled(false, "test")
+	g.fullScan(context.Background())
+
+	if len(dock.restartCalls) != 1 || len(dock.startCalls) != 1 {
+		t.Errorf("expected actions to resume after enable, got restarts=%v starts=%v", dock.restartCalls, dock.startCalls)
+	}
+	if len(notif.actions) < 2 || !strings.Contains(notif.actions[1], "actions ENABLED") {
+		t.Errorf("expected ENABLED notification, got %v", notif.actions)
+	}
+}
@@ -0,0 +1,52 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+)
+
+// checkDocker pings the daemon before a full scan. While it is unreachable
+// the scan is skipped rather than logging a list failure for every check.
+// After NOTIFY_DOCKER_DOWN_THRESHOLD consecutive failures a single
+// [CRITICAL] alert is sent on the actions category, like the other critical
+// alerts, and a recovery notice follows once the daemon answers again.
+func (g *Guardian) checkDocker(ctx context.Context) error {
+	err := g.docker.Ping(ctx)
+
+	g.dockerMu.Lock()
+	failures := g.dockerFailures
+	var alert, recovered bool
+	if err != nil {
+		g.dockerFailures++
+		failures = g.dockerFailures
+		alert = !g.dockerDownSent && g.cfg().NotifyDockerDownThreshold > 0 && failures >= g.cfg().NotifyDockerDownThreshold
+		if alert {
+			g.dockerDownSent = true
+		}
+	} else {
+		recovered = g.dockerDownSent
+		g.dockerFailures = 0
+		g.dockerDownSent = false
+	}
+	g.dockerMu.Unlock()
+
+	if err != nil {
+		metrics.DockerUp.Set(0)
+		g.logFor(ctx).Error("docker daemon unreachable", "consecutive_failures", failures, "error", err)
+		if alert {
+			g.notifier.Action(notify.Event{Text: fmt.Sprintf("[CRITICAL] Docker daemon unreachable for %d consecutive checks (%v) - containers are not being monitored", failures, err)})
+		}
+		return err
+	}
+
+	metrics.DockerUp.Set(1)
+	if recovered {
+		text := fmt.Sprintf("Docker daemon reachable again after %d failed checks - monitoring resumed", failures)
+		g.logFor(ctx).Info(text)
+		g.notifier.Action(notify.Event{Text: text})
+	}
+	return nil
+}
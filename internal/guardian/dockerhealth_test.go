@@ -0,0 +1,102 @@
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCheckDocker_AlertsOnceAfterThresholdAndRecovers(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyDockerDownThreshold: 3}
+	dock := newMockDocker()
+	dock.pingErr = errors.New("Cannot connect to the Docker daemon")
+	// Restart notices are off so only the daemon alerts reach the notifier
+	dock.unhealthyContainers = []container.Summary{{ID: "abcdef1234567890abcdef", Names: []string{"/app"}, State: "running",
+		Labels: map[string]string{"autoheal.notify": "false"}}}
+	notif := &mockNotifier{}
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	for range 2 {
+		if summary := g.fullScan(context.Background()); summary.ListErr == nil {
+			t.Fatal("scan should report the unreachable daemon")
+		}
+	}
+	if len(notif.actions) != 0 {
+		t.Fatalf("expected no alert below the threshold, got %v", notif.actions)
+	}
+	if got := testutil.ToFloat64(metrics.DockerUp); got != 0 {
+		t.Errorf("docker_up = %v, want 0", got)
+	}
+
+	for range 3 {
+		g.fullScan(context.Background())
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "[CRITICAL] Docker daemon unreachable for 3 consecutive checks") {
+		t.Fatalf("expected one unreachable alert, got %v", notif.actions)
+	}
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("no container checks should run while the daemon is down, got %v", dock.restartCalls)
+	}
+
+	dock.pingErr = nil
+	g.fullScan(context.Background())
+	if len(notif.actions) != 2 || !strings.Contains(notif.actions[1], "reachable again after 5 failed checks") {
+		t.Fatalf("expected a recovery notice, got %v", notif.actions)
+	}
+	if got := testutil.ToFloat64(metrics.DockerUp); got != 1 {
+		t.Errorf("docker_up = %v, want 1", got)
+	}
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected the scan to resume once the daemon is back, got %v", dock.restartCalls)
+	}
+
+	// A healthy daemon does not repeat the recovery notice
+	g.fullScan(context.Background())
+	if len(notif.actions) != 2 {
+		t.Errorf("expected no further notifications, got %v", notif.actions)
+	}
+}
+
+func TestCheckDocker_ShortOutageDoesNotNotify(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyDockerDownThreshold: 3}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+
+	dock.pingErr = errors.New("connection refused")
+	g.fullScan(context.Background())
+	g.fullScan(context.Background())
+	dock.pingErr = nil
+	g.fullScan(context.Background())
+	dock.pingErr = errors.New("connection refused")
+	g.fullScan(context.Background())
+
+	if len(notif.actions) != 0 {
+		t.Errorf("failures were never consecutive beyond the threshold, got %v", notif.actions)
+	}
+}
+
+func TestCheckDocker_AlertLogsOneRecord(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyDockerDownThreshold: 1}
+	dock := newMockDocker()
+	dock.pingErr = errors.New("Cannot connect to the Docker daemon")
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	var buf bytes.Buffer
+	g.log = logging.NewWriter(&buf, false, slog.LevelDebug)
+
+	g.fullScan(context.Background())
+
+	if n := strings.Count(buf.String(), "unreachable"); n != 1 {
+		t.Errorf("expected one log record for the alerting check, got %d:\n%s", n, buf.String())
+	}
+}
@@ -0,0 +1,53 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
+)
+
+// nodeStateTTL bounds how often the node's Swarm availability is queried.
+const nodeStateTTL = 10 * time.Second
+
+// nodeDraining reports whether AUTOHEAL_PAUSE_ON_NODE_DRAIN applies: this
+// Swarm node is set to drain or pause, so containers stopping is expected
+// and the guardian should not fight it. A single notification is sent when
+// the node enters and leaves that state. If the state can't be read the
+// guard stays open.
+func (g *Guardian) nodeDraining(ctx context.Context) (string, bool) {
+	if !g.cfg().PauseOnNodeDrain {
+		return "", false
+	}
+
+	g.drainMu.Lock()
+	defer g.drainMu.Unlock()
+	if !g.drainCheckedAt.IsZero() && g.clock.Since(g.drainCheckedAt) < nodeStateTTL {
+		return g.drainState, g.drainState != ""
+	}
+	g.drainCheckedAt = g.clock.Now()
+
+	info, err := g.docker.Info(ctx)
+	if err != nil {
+		g.logFor(ctx).Debug("failed to read node state", "error", err)
+		return g.drainState, g.drainState != ""
+	}
+
+	state := ""
+	if info.Availability == "drain" || info.Availability == "pause" {
+		state = info.Availability
+	}
+	if state != g.drainState {
+		var msg string
+		if state != "" {
+			msg = fmt.Sprintf("Swarm node availability is %s - all actions paused until it is active again", state)
+		} else {
+			msg = "Swarm node is active again - actions resumed"
+		}
+		g.logFor(ctx).Info(msg)
+		g.notifier.Action(notify.Event{Text: msg})
+		g.drainState = state
+	}
+	return state, state != ""
+}
@@ -0,0 +1,85 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/moby/moby/api/types/container"
+)
+
+func drainGuardian(availability string) (*Guardian, *mockDocker, *mockNotifier, *mockClock) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, PauseOnNodeDrain: true}
+	dock := newMockDocker()
+	dock.nodeInfo = docker.NodeInfo{SwarmState: "active", Availability: availability}
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/web"}, State: "running"},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/api"}, State: "running"},
+	}
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	return g, dock, notif, clk
+}
+
+func TestNodeDrain_ActsNormallyWhenActive(t *testing.T) {
+	g, dock, notif, _ := drainGuardian("active")
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("expected both containers restarted on an active node, got %v", dock.restartCalls)
+	}
+	for _, a := range notif.actions {
+		if strings.Contains(a, "Swarm node") {
+			t.Errorf("unexpected node notification: %q", a)
+		}
+	}
+}
+
+func TestNodeDrain_PausesWhileDraining(t *testing.T) {
+	g, dock, notif, clk := drainGuardian("drain")
+
+	g.checkUnhealthy(context.Background())
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Fatalf("expected no restarts while draining, got %v", dock.restartCalls)
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "availability is drain") {
+		t.Fatalf("expected a single drain notification, got %v", notif.actions)
+	}
+	if dock.infoCalls != 1 {
+		t.Errorf("node state should be cached within the TTL, got %d Info calls", dock.infoCalls)
+	}
+	if !g.history.contains("web", "skip", "node-draining") {
+		t.Error("expected a node-draining skip decision")
+	}
+
+	// Drain ends: actions resume with one notification
+	dock.nodeInfo.Availability = "active"
+	clk.Advance(nodeStateTTL)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("expected restarts once the node is active, got %v", dock.restartCalls)
+	}
+	if len(notif.actions) < 2 || !strings.Contains(notif.actions[1], "active again") {
+		t.Errorf("expected a resume notification, got %v", notif.actions)
+	}
+}
+
+func TestNodeDrain_DisabledByDefault(t *testing.T) {
+	g, dock, _, _ := drainGuardian("drain")
+	g.cfg().PauseOnNodeDrain = false
+
+	g.checkUnhealthy(context.Background())
+
+	if dock.infoCalls != 0 || len(dock.restartCalls) != 2 {
+		t.Errorf("expected no Info calls and normal restarts, got %d calls, restarts %v", dock.infoCalls, dock.restartCalls)
+	}
+}
@@ -0,0 +1,67 @@
+package guardian
+
+import (
+	"context"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+)
+
+// dryRunDocker passes reads through to the daemon but logs and swallows every
+// call that would change a container (AUTOHEAL_DRY_RUN). Each skipped call
+// reports success, so the tracker, circuit breaker and metrics behave as they
+// would for a real action.
+type dryRunDocker struct {
+	docker.API
+	log *logging.Logger
+}
+
+func (d dryRunDocker) would(verb, id string) {
+	d.log.Infof("[DRY RUN] would %s container %s", verb, shortContainerID(id))
+}
+
+func (d dryRunDocker) RestartContainer(_ context.Context, id string, _ int) error {
+	d.would("restart", id)
+	return nil
+}
+
+func (d dryRunDocker) StartContainer(_ context.Context, id string) error {
+	d.would("start", id)
+	return nil
+}
+
+func (d dryRunDocker) StopContainer(_ context.Context, id string, _ int) error {
+	d.would("stop", id)
+	return nil
+}
+
+func (d dryRunDocker) StopContainerSignal(_ context.Context, id, _ string, _ int) error {
+	d.would("stop", id)
+	return nil
+}
+
+func (d dryRunDocker) RemoveContainer(_ context.Context, id string) error {
+	d.would("remove", id)
+	return nil
+}
+
+func (d dryRunDocker) RecreateContainer(_ context.Context, id string, _ int) (string, error) {
+	d.would("recreate", id)
+	return id, nil
+}
+
+func (d dryRunDocker) ForceServiceUpdate(_ context.Context, serviceID string) error {
+	d.log.Infof("[DRY RUN] would force-update service %s", serviceID)
+	return nil
+}
+
+// dockerClient returns the real Docker client behind g.docker, if any, looking
+// through the dry-run wrapper.
+func (g *Guardian) dockerClient() (*docker.Client, bool) {
+	api := g.docker
+	if d, ok := api.(dryRunDocker); ok {
+		api = d.API
+	}
+	client, ok := api.(*docker.Client)
+	return client, ok
+}
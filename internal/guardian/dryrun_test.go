@@ -0,0 +1,64 @@
+package guardian
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestDryRun_CheckUnhealthyDoesNotRestart(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, DryRun: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+
+	g := NewWithClock(cfg, dock, notif, logging.New(false, slog.LevelDebug), clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("dry run must not restart, got %v", dock.restartCalls)
+	}
+	if len(notif.actions) != 1 || !strings.HasPrefix(notif.actions[0], "[DRY RUN] ") {
+		t.Errorf("expected a [DRY RUN] notification, got %v", notif.actions)
+	}
+	if stats, _ := g.tracker.Stats(id); stats.RecentRestarts != 1 {
+		t.Errorf("the would-be restart should still be tracked, got %d", stats.RecentRestarts)
+	}
+}
+
+func TestDryRun_CheckDependencyOrphansDoesNotStart(t *testing.T) {
+	cfg := &config.Config{MonitorDependencies: true, DryRun: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+
+	parentID := "parent1234567890abcdef"
+	orphanID := "orphan01234567890abcdef"
+	dock.exitedContainers = []container.Summary{{ID: orphanID}}
+	dock.inspectResults[orphanID] = container.InspectResponse{
+		Name:       "/orphan-app",
+		HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + parentID)},
+		Config:     &container.Config{Labels: map[string]string{}},
+		State:      &container.State{ExitCode: 1},
+	}
+	dock.statusResults[parentID] = "running"
+	dock.statusResults[orphanID] = "exited"
+
+	g := NewWithClock(cfg, dock, notif, logging.New(false, slog.LevelDebug), newMockClock(time.Now()))
+	g.checkDependencyOrphans(context.Background())
+
+	if len(dock.startCalls) != 0 || len(dock.stopCalls) != 0 {
+		t.Errorf("dry run must not start or stop, got starts %v stops %v", dock.startCalls, dock.stopCalls)
+	}
+	if len(notif.actions) != 1 || !strings.HasPrefix(notif.actions[0], "[DRY RUN] ") {
+		t.Errorf("expected a [DRY RUN] notification, got %v", notif.actions)
+	}
+}
@@ -0,0 +1,153 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+)
+
+// stoppedBySignal reports whether an exit code means the container was
+// stopped from outside (docker stop/kill, Ctrl-C) rather than crashing.
+// 137 counts as a crash when the kernel OOM killer sent it.
+func stoppedBySignal(exitCode int, oomKilled bool) bool {
+	switch exitCode {
+	case 130, 143:
+		return true
+	case 137:
+		return !oomKilled
+	}
+	return false
+}
+
+// checkExitedLabeled starts monitored containers that exited with a non-zero
+// code (AUTOHEAL_RESTART_EXITED). Clean exits and containers stopped by a
+// signal are left alone, as are container:X dependents and, in compose
+// dependency mode, depends_on dependents, which checkDependencyOrphans owns. Only the default restart action applies; grace
+// period, backoff and the circuit breaker are honoured as for unhealthy
+// restarts.
+func (g *Guardian) checkExitedLabeled(ctx context.Context) {
+	if !g.cfg().RestartExited {
+		return
+	}
+
+	exited, err := g.docker.ExitedContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list exited containers", err)
+		return
+	}
+	for _, c := range exited {
+		g.startExited(ctx, c)
+	}
+}
+
+// checkExitedByID applies checkExitedLabeled to a single container, for
+// events that report it exiting. It does nothing unless the container is
+// still exited when the debounced handler runs.
+func (g *Guardian) checkExitedByID(ctx context.Context, containerID string) {
+	if !g.cfg().RestartExited {
+		return
+	}
+	g.logFor(ctx).Debug("processing debounced exit event", "id", shortContainerID(containerID))
+
+	exited, err := g.docker.ExitedContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list exited containers", err)
+		return
+	}
+	for _, c := range exited {
+		if c.ID == containerID {
+			g.startExited(ctx, c)
+			return
+		}
+	}
+}
+
+// startExited starts one exited container if checkExitedLabeled's rules
+// allow it.
+func (g *Guardian) startExited(ctx context.Context, c container.Summary) {
+	if len(c.Names) == 0 || !g.isMonitored(c.Labels) || containerAction(c.Labels) != "restart" {
+		return
+	}
+	if strings.HasPrefix(c.HostConfig.NetworkMode, "container:") {
+		return
+	}
+	if _, compose := g.dependencyLinks(); compose && g.cfg().MonitorDependencies && len(composeDependencies(c.Labels)) > 0 {
+		return
+	}
+	name := displayName(c.Names)
+	if !g.inScope(c.ID, name) || g.excluded(ctx, name, c.Labels) {
+		return
+	}
+
+	info, err := g.docker.InspectContainer(ctx, c.ID)
+	if err != nil {
+		if docker.ClassifyError(err) != docker.ErrorNotFound {
+			g.internalError(ctx, "failed to inspect container", err, "id", shortContainerID(c.ID))
+		}
+		return
+	}
+	if info.State == nil {
+		return
+	}
+	exitCode := info.State.ExitCode
+	if exitCode == 0 || stoppedBySignal(exitCode, info.State.OOMKilled) {
+		g.logFor(ctx).Debug("exited container left alone", "container", name, "id", shortContainerID(c.ID), "exit_code", exitCode)
+		return
+	}
+
+	ctx = withNotifyContext(ctx, c.Labels)
+	if g.inMaintenance(ctx, c.ID, name, c.Labels) {
+		return
+	}
+	shortID := c.ID[:12]
+	if g.Disabled() {
+		g.logFor(ctx).Infof("Container %s (%s) exited (code %d) - actions disabled, skipping", name, shortID, exitCode)
+		metrics.SkipsTotal.WithLabelValues(name, "disabled").Inc()
+		return
+	}
+	if g.shouldSkip(ctx, c.ID, name, c.Labels) {
+		return
+	}
+	if allowed, reason := g.tracker.ShouldRestart(c.ID); !allowed {
+		msg := g.tracker.FormatSkipReason(c.ID, name, reason)
+		g.logFor(ctx).Info(msg)
+		metrics.SkipsTotal.WithLabelValues(name, string(reason)).Inc()
+		g.recordDecision(ctx, name, c.ID, "skip", string(reason))
+		if reason == SkipCircuit {
+			g.notifier.Action(g.decisionEvent(ctx, name, c.ID, "skip", string(reason), fmt.Sprintf("[CRITICAL] %s", msg)))
+		}
+		return
+	}
+
+	g.logFor(ctx).Infof("Container %s (%s) exited with code %d - Starting container", name, shortID, exitCode)
+	notify := shouldNotify(c.Labels)
+	if err := g.docker.StartContainer(ctx, c.ID); err != nil {
+		if g.containerGone(ctx, c.ID, name, err) {
+			return
+		}
+		class, advance := g.actionFailed(name, "start", err)
+		g.logFor(ctx).Error("failed to start container", "container", name, "id", shortID, "class", class, "error", err)
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, c.ID, "start", "failure", fmt.Sprintf("Container %s (%s) exited with code %d. Failed to start: %s!", name, shortID, exitCode, class.Describe())))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+		g.recordDecision(ctx, name, c.ID, "start", "failure")
+		if advance {
+			g.recordRestart(c.ID, name)
+		}
+		return
+	}
+
+	g.logFor(ctx).Infof("Successfully started %s (%s)", name, shortID)
+	if notify {
+		g.notifier.Action(g.decisionEvent(ctx, name, c.ID, "start", "success", fmt.Sprintf("Container %s (%s) exited with code %d. Successfully started!", name, shortID, exitCode)))
+	}
+	metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+	g.recordDecision(ctx, name, c.ID, "start", "success")
+	g.recordRestart(c.ID, name)
+	g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: c.ID, Action: "start", Result: "success", State: "exited", ExitCode: exitCode})
+}
@@ -0,0 +1,86 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func exitedGuardian(exitCode int, oomKilled bool) (*Guardian, *mockDocker, *mockNotifier) {
+	cfg := &config.Config{ContainerLabel: "autoheal", RestartExited: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "crash01234567890abcdef"
+	dock.exitedContainers = []container.Summary{
+		{ID: id, Names: []string{"/worker"}, State: "exited", Labels: map[string]string{"autoheal": "true"}},
+		{ID: "unlabeled1234567890abc", Names: []string{"/other"}, State: "exited"},
+	}
+	dock.inspectResults[id] = container.InspectResponse{State: &container.State{ExitCode: exitCode, OOMKilled: oomKilled}}
+	dock.inspectResults["unlabeled1234567890abc"] = container.InspectResponse{State: &container.State{ExitCode: 1}}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	return g, dock, notif
+}
+
+func TestCheckExitedLabeled_StartsCrashedContainer(t *testing.T) {
+	g, dock, notif := exitedGuardian(1, false)
+	g.checkExitedLabeled(context.Background())
+
+	if len(dock.startCalls) != 1 || dock.startCalls[0] != "crash01234567890abcdef" {
+		t.Fatalf("expected only the labelled crashed container started, got %v", dock.startCalls)
+	}
+	if !g.history.contains("worker", "start", "success") {
+		t.Error("expected start decision")
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "exited with code 1") {
+		t.Errorf("unexpected notifications: %v", notif.actions)
+	}
+
+	// Backoff applies to the next crash
+	g.checkExitedLabeled(context.Background())
+	if len(dock.startCalls) != 1 {
+		t.Errorf("second start should wait for backoff, got %v", dock.startCalls)
+	}
+	if !g.history.contains("worker", "skip", "backoff") {
+		t.Error("expected backoff skip")
+	}
+}
+
+func TestCheckExitedLabeled_SkipsCleanAndStoppedExits(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		exitCode  int
+		oomKilled bool
+		started   bool
+	}{
+		{"clean exit", 0, false, false},
+		{"docker stop", 143, false, false},
+		{"docker kill", 137, false, false},
+		{"oom killed", 137, true, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dock, _ := exitedGuardian(tt.exitCode, tt.oomKilled)
+			g.checkExitedLabeled(context.Background())
+			if started := len(dock.startCalls) > 0; started != tt.started {
+				t.Errorf("exit code %d: started = %v, want %v", tt.exitCode, started, tt.started)
+			}
+		})
+	}
+}
+
+func TestCheckExitedLabeled_DisabledByDefault(t *testing.T) {
+	g, dock, _ := exitedGuardian(1, false)
+	g.cfg().RestartExited = false
+	g.checkExitedLabeled(context.Background())
+
+	if len(dock.startCalls) != 0 {
+		t.Errorf("AUTOHEAL_RESTART_EXITED off should start nothing, got %v", dock.startCalls)
+	}
+}
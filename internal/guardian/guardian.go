@@ -2,20 +2,23 @@ package guardian
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Will-Luck/Docker-Guardian/internal/clock"
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
 	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
 	"github.com/Will-Luck/Docker-Guardian/internal/notify"
-	"github.com/moby/moby/api/types/events"
+	"golang.org/x/time/rate"
 )
 
 // Guardian orchestrates container health monitoring.
 type Guardian struct {
-	cfg      *config.Config
+	conf     atomic.Pointer[config.Config] // swapped by Reload; read through cfg()
 	docker   docker.API
 	notifier notify.Notifier
 	log      *logging.Logger
@@ -27,13 +30,118 @@ type Guardian struct {
 	// Event debouncing
 	debounceMu     sync.Mutex
 	debounceTimers map[string]*time.Timer
+	debounceWindow time.Duration
 
-	// Orchestration tracking (event-driven replacement for per-cycle cache)
-	orchestrationMu     sync.Mutex
-	orchestrationEvents map[string]time.Time // container name → latest event time
+	// EVENT_PROCESS_RATE token bucket (nil = unlimited)
+	eventLimiter *rate.Limiter
 
-	// Per-cycle caches (used during full scans)
-	orchestratorEvents []events.Message
+	// Orchestration tracking: create/destroy events seen on the stream, which
+	// the per-cycle cache reads instead of querying the daemon
+	orchestrationMu       sync.Mutex
+	orchestrationEvents   map[string]time.Time // container name → latest event time
+	orchestrationPrunedAt time.Time            // last sweep of expired entries
+
+	// oom events awaiting the die that follows: container ID → event time
+	oomMu    sync.Mutex
+	oomKills map[string]time.Time
+
+	// AUTOHEAL_WATCHTOWER_EVENTS=digest: container name → last image seen
+	imageMu      sync.Mutex
+	imageDigests map[string]imageSeen
+
+	// Event stream liveness: degraded = event stream unresponsive, relying on periodic scans;
+	// connectedAt = when the current subscription was established
+	streamMu         sync.Mutex
+	livenessFailures int
+	degraded         bool
+	connectedAt      time.Time
+
+	// Health check tracking: container name → had a health check when last seen
+	healthcheckMu sync.Mutex
+	healthchecked map[string]bool
+
+	// Misconfiguration audit findings already notified, by finding key
+	auditMu       sync.Mutex
+	auditReported map[string]bool
+
+	// Host pressure source for the overload guard
+	load loadSource
+
+	// Host-level alarm: when the unhealthy count first exceeded the threshold
+	alarmMu    sync.Mutex
+	alarmSince time.Time // zero = at or below threshold
+	alarmFired bool
+
+	// Swarm node drain/pause (AUTOHEAL_PAUSE_ON_NODE_DRAIN), cached for nodeStateTTL
+	drainMu        sync.Mutex
+	drainCheckedAt time.Time
+	drainState     string // "drain" or "pause" while paused, empty otherwise
+
+	// Panic button: all actions halted, observation continues
+	disabled atomic.Bool
+
+	// Post-restart checks (crash-loop, recovery loop) in flight
+	verifying sync.WaitGroup
+
+	// Slots for POST_RESTART_SCRIPT runs (POST_RESTART_SCRIPT_MAX_CONCURRENT)
+	scriptSem chan struct{}
+
+	// Two-stage shutdown: stopCh closes on Stop; working counts debounced
+	// event handlers in flight (guarded by debounceMu against Stop)
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	working  sync.WaitGroup
+
+	// Full scans requested by TriggerScan, run by the monitoring loop
+	rescanCh chan struct{}
+
+	// Containers already announced as under maintenance
+	maintenanceMu   sync.Mutex
+	maintenanceSeen map[string]bool
+
+	// Containers with a recovery loop running; further detections are ignored
+	inflightMu sync.Mutex
+	inflight   map[string]bool
+
+	// Containers handleUnhealthy is acting on right now (scan and event paths)
+	handlingMu sync.Mutex
+	handling   map[string]bool
+
+	// Recent decisions (nil = disabled)
+	history *decisionLog
+
+	// Persisted lifetime counts and quarantines (nil = no AUTOHEAL_STATE_FILE)
+	state *stateStore
+
+	// Containers the guardian stopped (action=stop) → when, for QUARANTINE_REMOVE_AFTER
+	quarantineMu sync.Mutex
+	quarantined  map[string]time.Time
+
+	// Recent internal failures (list/inspect errors) for the self-error alert
+	selfErrMu      sync.Mutex
+	selfErrors     []time.Time
+	selfErrFiredAt time.Time
+
+	// Consecutive failed daemon pings, and whether the unreachable alert fired
+	dockerMu       sync.Mutex
+	dockerFailures int
+	dockerDownSent bool
+
+	// Readiness
+	scanned   atomic.Bool // last full scan reached the daemon
+	streaming atomic.Bool // event-driven mode: watcher established
+
+	startedAt    time.Time    // for uptime on /status
+	unhealthyNow atomic.Int64 // unhealthy count from the latest check
+
+	// Rotating offset into exited containers when DEPENDENCY_SCAN_LIMIT applies;
+	// full scans and debounced die handlers advance it concurrently
+	dependencyCursor atomic.Int64
+
+	// Per-cycle caches (used during full scans); orchestratorMu guards the
+	// orchestrator cache, which debounced event handlers also reset
+	orchestratorMu     sync.Mutex
+	orchestratorEvents map[string]time.Time // container name → latest event time in the cooldown
 	orchestratorCached bool
 	cycle              int
 }
@@ -41,23 +149,80 @@ type Guardian struct {
 // New creates a Guardian instance.
 func New(cfg *config.Config, client docker.API, notifier notify.Notifier, log *logging.Logger) *Guardian {
 	clk := clock.Real{}
-	tcfg := TrackerConfig{
-		BackoffMultiplier: cfg.BackoffMultiplier,
-		BackoffMax:        time.Duration(cfg.BackoffMax) * time.Second,
-		BackoffResetAfter: time.Duration(cfg.BackoffResetAfter) * time.Second,
-		RestartBudget:     cfg.RestartBudget,
-		RestartWindow:     time.Duration(cfg.RestartWindow) * time.Second,
+	tcfg := trackerConfig(cfg)
+	debounceWindow := time.Duration(cfg.Interval) * time.Second
+	if debounceWindow <= 0 {
+		debounceWindow = 5 * time.Second
 	}
-	return &Guardian{
-		cfg:                 cfg,
+	g := &Guardian{
 		docker:              client,
 		notifier:            notifier,
 		log:                 log,
 		clock:               clk,
 		tracker:             NewRestartTracker(tcfg, clk),
 		debounceTimers:      make(map[string]*time.Timer),
+		debounceWindow:      debounceWindow,
+		stopCh:              make(chan struct{}),
+		rescanCh:            make(chan struct{}, 1),
 		orchestrationEvents: make(map[string]time.Time),
+		scriptSem:           make(chan struct{}, max(cfg.PostRestartScriptMaxConcurrent, 1)),
+		history:             newDecisionLog(cfg.DecisionHistory),
+		load:                procLoad{root: "/proc"},
+		startedAt:           clk.Now(),
 	}
+	g.conf.Store(cfg)
+	if cfg.StateFile != "" {
+		state, err := loadState(cfg.StateFile)
+		if err != nil {
+			log.Warn("failed to load state file, starting fresh", "path", cfg.StateFile, "error", err)
+		}
+		g.state = state
+		g.quarantined = state.quarantined()
+		if err := g.tracker.restore(state.trackerHistory()); err != nil {
+			log.Warn("failed to load tracker history from state file, starting fresh", "path", cfg.StateFile, "error", err)
+		}
+		g.tracker.saveTo = state.setTrackerHistory
+		g.tracker.onSaveError = func(err error) {
+			log.Warn("failed to save state file", "path", cfg.StateFile, "error", err)
+		}
+	}
+	if cfg.DryRun {
+		g.docker = dryRunDocker{API: client, log: log}
+	}
+	if cfg.EventProcessRate > 0 {
+		burst := int(cfg.EventProcessRate)
+		if burst < 1 {
+			burst = 1
+		}
+		g.eventLimiter = rate.NewLimiter(rate.Limit(cfg.EventProcessRate), burst)
+	}
+	return g
+}
+
+// trackerConfig converts the backoff and budget settings for the tracker.
+func trackerConfig(cfg *config.Config) TrackerConfig {
+	return TrackerConfig{
+		BackoffMultiplier: cfg.BackoffMultiplier,
+		BackoffMax:        time.Duration(cfg.BackoffMax) * time.Second,
+		BackoffResetAfter: time.Duration(cfg.BackoffResetAfter) * time.Second,
+		BackoffJitter:     cfg.BackoffJitter,
+		RestartCooldown:   time.Duration(cfg.PostRestartCooldown) * time.Second,
+		RestartBudget:     cfg.RestartBudget,
+		RestartWindow:     time.Duration(cfg.RestartWindow) * time.Second,
+	}
+}
+
+// cfg returns the config currently in effect.
+func (g *Guardian) cfg() *config.Config {
+	return g.conf.Load()
+}
+
+// Reload swaps in a new config, normally one built by config.Reload so only
+// the hot-reloadable settings differ. The tracker picks up the new backoff
+// and budget settings and keeps each container's restart history.
+func (g *Guardian) Reload(cfg *config.Config) {
+	g.conf.Store(cfg)
+	g.tracker.SetConfig(trackerConfig(cfg))
 }
 
 // NewWithClock creates a Guardian with a custom clock (for testing).
@@ -65,6 +230,7 @@ func NewWithClock(cfg *config.Config, client docker.API, notifier notify.Notifie
 	g := New(cfg, client, notifier, log)
 	g.clock = clk
 	g.tracker.clock = clk
+	g.startedAt = clk.Now()
 	return g
 }
 
@@ -72,8 +238,16 @@ func NewWithClock(cfg *config.Config, client docker.API, notifier notify.Notifie
 // If a Watcher is available (via docker.Client), it uses the event stream.
 // Otherwise, it falls back to the polling loop for compatibility.
 func (g *Guardian) Run(ctx context.Context) error {
+	if g.cfg().Disabled {
+		g.disabled.Store(true)
+		g.announceDisabled("GUARDIAN_DISABLED")
+	}
+
+	g.startHeartbeat(ctx)
+	g.startAudits(ctx)
+
 	// Check if we can get a watcher
-	if client, ok := g.docker.(*docker.Client); ok {
+	if client, ok := g.dockerClient(); ok {
 		return g.runEventDriven(ctx, client)
 	}
 	// Fallback to polling (for tests with mock docker)
@@ -82,10 +256,26 @@ func (g *Guardian) Run(ctx context.Context) error {
 
 func (g *Guardian) runEventDriven(ctx context.Context, client *docker.Client) error {
 	watcher := docker.NewWatcher(client)
-	eventCh := watcher.Watch(ctx)
+	if g.cfg().EventLivenessFailures > 0 {
+		watcher.SetLivenessHandler(g.recordLivenessTimeout)
+	}
+	watcher.SetConnectionHandlers(g.recordStreamConnected, g.recordStreamDisconnected)
+	// The stream ends with the loop, so Stop also closes the watcher
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+	eventCh := watcher.Watch(watchCtx)
 
+	return g.eventLoop(ctx, eventCh)
+}
+
+// eventLoop runs the startup scan, then handles events alongside periodic
+// full scans until the stream closes. With AUTOHEAL_STARTUP_SCAN_ASYNC the
+// startup scan runs in the background so events are handled immediately;
+// periodic scans wait for it, and the per-container claim in handleUnhealthy
+// keeps the scan and a concurrent event from acting on the same container.
+func (g *Guardian) eventLoop(ctx context.Context, eventCh <-chan docker.ContainerEvent) error {
 	// Periodic full scan as safety net (catches grace period expiry, missed events, etc.)
-	scanInterval := time.Duration(g.cfg.Interval) * time.Second
+	scanInterval := time.Duration(g.cfg().Interval) * time.Second
 	if scanInterval <= 0 {
 		scanInterval = 5 * time.Second
 	}
@@ -93,7 +283,17 @@ func (g *Guardian) runEventDriven(ctx context.Context, client *docker.Client) er
 	defer ticker.Stop()
 
 	// Initial full scan on startup
-	g.fullScan(ctx)
+	startupDone := make(chan struct{})
+	if g.cfg().StartupScanAsync {
+		go func() {
+			defer close(startupDone)
+			g.startupScan(ctx)
+		}()
+		defer func() { <-startupDone }()
+	} else {
+		g.startupScan(ctx)
+		close(startupDone)
+	}
 
 	for {
 		select {
@@ -101,9 +301,24 @@ func (g *Guardian) runEventDriven(ctx context.Context, client *docker.Client) er
 			if !ok {
 				return nil // watcher closed (context cancelled)
 			}
+			g.recordStreamActivity()
 			g.handleEvent(ctx, evt)
 		case <-ticker.C:
-			g.fullScan(ctx)
+			select {
+			case <-startupDone:
+				g.fullScan(ctx)
+			default:
+				g.logFor(ctx).Debug("startup scan still running, skipping periodic scan")
+			}
+		case <-g.rescanCh:
+			select {
+			case <-startupDone:
+				g.fullScan(ctx)
+			default:
+				g.logFor(ctx).Debug("startup scan still running, skipping requested scan")
+			}
+		case <-g.stopCh:
+			return nil
 		case <-ctx.Done():
 			return nil
 		}
@@ -111,70 +326,250 @@ func (g *Guardian) runEventDriven(ctx context.Context, client *docker.Client) er
 }
 
 func (g *Guardian) runPolling(ctx context.Context) error {
-	for {
-		g.cycle++
-		g.orchestratorCached = false
-
-		g.checkUnhealthy(ctx)
-		g.checkDependencyOrphans(ctx)
+	g.startupScan(ctx)
 
+	for {
 		select {
-		case <-time.After(time.Duration(g.cfg.Interval) * time.Second):
+		case <-time.After(time.Duration(g.cfg().Interval) * time.Second):
+		case <-g.rescanCh:
+		case <-g.stopCh:
+			return nil
 		case <-ctx.Done():
 			return nil
 		}
+
+		g.fullScan(ctx)
+	}
+}
+
+// recordStreamConnected marks the event stream as established. The watcher
+// calls it once per subscription, after the daemon has confirmed it.
+func (g *Guardian) recordStreamConnected() {
+	g.streamMu.Lock()
+	g.connectedAt = g.clock.Now()
+	g.streamMu.Unlock()
+	g.streaming.Store(true)
+	if !g.Degraded() {
+		metrics.EventStreamConnected.Set(1)
+	}
+}
+
+// recordStreamDisconnected marks the event stream as down until the watcher
+// reconnects, so readiness and the orchestration cache stop relying on it.
+func (g *Guardian) recordStreamDisconnected() {
+	g.streaming.Store(false)
+	metrics.EventStreamConnected.Set(0)
+}
+
+// recordLivenessTimeout counts a reconnect caused by the watcher's liveness
+// window expiring with the daemon down or the stream stalled (the daemon
+// replays events the stream never delivered). After
+// AUTOHEAL_EVENT_LIVENESS_FAILURES consecutive timeouts the guardian degrades
+// to polling: a catch-up full scan is queued at once, since events may have
+// been missed while the stream was dead, and periodic scans carry detection
+// while the stream is retried. It notifies once.
+func (g *Guardian) recordLivenessTimeout() {
+	g.streamMu.Lock()
+	g.livenessFailures++
+	failures := g.livenessFailures
+	enter := g.cfg().EventLivenessFailures > 0 && failures >= g.cfg().EventLivenessFailures && !g.degraded
+	if enter {
+		g.degraded = true
+	}
+	g.streamMu.Unlock()
+
+	if !enter {
+		return
 	}
+	metrics.EventStreamConnected.Set(0)
+	g.TriggerScan()
+	g.log.Warnf("Event stream unresponsive after %d liveness timeout(s) - scanning now, then polling every %ds", failures, g.cfg().Interval)
+	g.notifier.Action(notify.Event{Text: fmt.Sprintf("[CRITICAL] Docker event stream unresponsive after %d liveness timeout(s). Scanning now, then polling every %ds.",
+		failures, g.cfg().Interval)})
+}
+
+// recordStreamActivity clears the liveness failure count on any received
+// event, leaving degraded mode if it was active.
+func (g *Guardian) recordStreamActivity() {
+	g.streamMu.Lock()
+	g.livenessFailures = 0
+	recovered := g.degraded
+	g.degraded = false
+	g.streamMu.Unlock()
+
+	if !recovered {
+		return
+	}
+	metrics.EventStreamConnected.Set(1)
+	g.log.Infof("Event stream recovered - resuming event-driven mode")
+	g.notifier.Action(notify.Event{Text: "Docker event stream recovered. Resuming event-driven mode."})
+}
+
+// Degraded returns true while the event stream is considered unresponsive
+// and the guardian is relying on polling.
+func (g *Guardian) Degraded() bool {
+	g.streamMu.Lock()
+	defer g.streamMu.Unlock()
+	return g.degraded
 }
 
 // fullScan does a complete check of all containers.
 // Called on startup and after event stream reconnection.
-func (g *Guardian) fullScan(ctx context.Context) {
+func (g *Guardian) fullScan(ctx context.Context) scanSummary {
 	g.cycle++
-	g.orchestratorCached = false
+	g.invalidateOrchestratorCache()
 
-	g.checkUnhealthy(ctx)
+	if err := g.checkDocker(ctx); err != nil {
+		g.scanned.Store(false)
+		return scanSummary{ListErr: err}
+	}
+	g.recordImageDigests(ctx)
+	summary := g.checkUnhealthy(ctx)
 	g.checkDependencyOrphans(ctx)
+	g.checkExitedLabeled(ctx)
+	g.removeExpiredQuarantines(ctx)
+	g.checkHealthcheckLoss(ctx)
+	g.scanned.Store(summary.ListErr == nil)
+	return summary
+}
+
+// startupScan runs the initial full scan and sends a startup notification
+// summarising what it found, so operators get immediate confirmation.
+func (g *Guardian) startupScan(ctx context.Context) {
+	summary := g.fullScan(ctx)
+	g.replayStartupEvents(ctx, &summary)
+	g.runAudits(ctx)
+
+	msg := fmt.Sprintf("Startup scan: %d monitored, %d unhealthy, %d restarted",
+		g.monitoredCount(ctx), summary.Unhealthy, summary.Restarted)
+	if summary.Stopped > 0 {
+		msg += fmt.Sprintf(", %d stopped", summary.Stopped)
+	}
+	g.notifier.Startup(msg)
+}
+
+// monitoredCount returns the number of running containers covered by
+// AUTOHEAL_CONTAINER_LABEL (excluding autoheal=False opt-outs).
+func (g *Guardian) monitoredCount(ctx context.Context) int {
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list running containers", err)
+		return 0
+	}
+	count := 0
+	for _, c := range running {
+		if g.isMonitored(c.Labels) {
+			count++
+		}
+	}
+	return count
+}
+
+// isMonitored returns true if the labels match AUTOHEAL_CONTAINER_LABEL
+// and the container has not opted out.
+func (g *Guardian) isMonitored(labels map[string]string) bool {
+	if labels["autoheal"] == "False" {
+		return false
+	}
+	if g.cfg().ContainerLabel == "all" {
+		return true
+	}
+	return labels[g.cfg().ContainerLabel] == "true"
 }
 
 // handleEvent processes a single Docker event with debouncing.
+// Each event gets a correlation ID that is carried through to the log
+// records and notifications produced while handling it. AUTOHEAL_EVENT_ACTIONS
+// decides which behaviours events may trigger; periodic scans are unaffected.
 func (g *Guardian) handleEvent(ctx context.Context, evt docker.ContainerEvent) {
+	if !g.admitEvent(evt) {
+		return
+	}
+	metrics.EventsProcessedTotal.WithLabelValues(evt.Action).Inc()
+
+	ctx = withCorrelationID(ctx, newCorrelationID())
+	g.logFor(ctx).Debug("docker event received",
+		"action", evt.Action, "health_status", evt.HealthStatus,
+		"container", evt.ContainerName, "id", evt.ContainerID)
+
 	switch evt.Action {
 	case "health_status":
-		if evt.HealthStatus == "unhealthy" {
+		if evt.HealthStatus == "unhealthy" && g.cfg().EventActionEnabled("health") {
 			g.debounce(ctx, evt.ContainerID, func() {
 				g.checkContainerByID(ctx, evt.ContainerID)
 			})
 		} else if evt.HealthStatus == "healthy" {
-			g.tracker.Reset(evt.ContainerID)
+			if g.resetTracking(evt.ContainerID, evt.ContainerName) {
+				g.reportRecovered(ctx, evt.ContainerID, evt.ContainerName)
+			}
+		}
+
+	case "oom":
+		// An OOM-killed container often exits without ever reporting
+		// unhealthy, so check it as an exited container once the die that
+		// follows has landed, rather than at the next full scan.
+		g.recordOOM(evt)
+		if g.cfg().EventActionEnabled("oom") {
+			g.debounce(ctx, "exit:"+evt.ContainerID, func() {
+				g.checkExitedByID(ctx, evt.ContainerID)
+			})
 		}
 
 	case "die":
-		g.debounce(ctx, "dep:"+evt.ContainerID, func() {
-			g.checkOrphanedDependents(ctx, evt.ContainerID)
-		})
+		if g.takeOOM(evt.ContainerID, evt.Timestamp) {
+			g.reportOOMKill(ctx, evt)
+		}
+		// Shares the oom key, so an OOM kill is checked once
+		if evt.ExitCode != 0 && g.cfg().EventActionEnabled("crash") {
+			g.debounce(ctx, "exit:"+evt.ContainerID, func() {
+				g.checkExitedByID(ctx, evt.ContainerID)
+			})
+		}
+		if g.cfg().EventActionEnabled("orphan") {
+			g.debounce(ctx, "dep:"+evt.ContainerID, func() {
+				g.checkOrphanedDependents(ctx, evt.ContainerID)
+			})
+		}
 
 	case "create", "destroy":
 		g.recordOrchestrationActivity(evt)
 
-	case "start":
-		// No action needed — tracked for potential future use
+	case "start", "kill":
+		// No action needed: every docker stop sends a kill, so it is no
+		// failure signal on its own; an OOM kill is handled on "oom"
 	}
 }
 
-// debounce ensures only one action per container within the debounce window.
-func (g *Guardian) debounce(ctx context.Context, key string, fn func()) {
-	debounceWindow := time.Duration(g.cfg.Interval) * time.Second
-	if debounceWindow <= 0 {
-		debounceWindow = 5 * time.Second
+// admitEvent applies EVENT_PROCESS_RATE. die and oom events always pass
+// without spending a token, so orphaned dependents are still started and OOM
+// kills still reported while a flapping health check floods the stream;
+// health changes that are dropped are picked up by the next full scan.
+func (g *Guardian) admitEvent(evt docker.ContainerEvent) bool {
+	if g.eventLimiter == nil || evt.Action == "die" || evt.Action == "oom" || g.eventLimiter.Allow() {
+		return true
 	}
+	metrics.EventsDroppedTotal.WithLabelValues(evt.Action).Inc()
+	g.log.Debug("event dropped by EVENT_PROCESS_RATE", "action", evt.Action, "id", evt.ContainerID)
+	return false
+}
 
+// debounce ensures only one action per container within the debounce window.
+func (g *Guardian) debounce(ctx context.Context, key string, fn func()) {
 	g.debounceMu.Lock()
-	if timer, ok := g.debounceTimers[key]; ok {
-		timer.Stop()
+	if timer, ok := g.debounceTimers[key]; ok && timer.Stop() {
+		metrics.EventsDebouncedTotal.Inc()
+		g.logFor(ctx).Debug("event coalesced by debounce", "key", key, "window", g.debounceWindow)
 	}
-	g.debounceTimers[key] = time.AfterFunc(debounceWindow, func() {
-		if ctx.Err() == nil {
+	g.debounceTimers[key] = time.AfterFunc(g.debounceWindow, func() {
+		g.debounceMu.Lock()
+		run := ctx.Err() == nil && !g.stopping()
+		if run {
+			g.working.Add(1)
+		}
+		g.debounceMu.Unlock()
+		if run {
 			fn()
+			g.working.Done()
 		}
 		g.debounceMu.Lock()
 		delete(g.debounceTimers, key)
@@ -185,42 +580,105 @@ func (g *Guardian) debounce(ctx context.Context, key string, fn func()) {
 
 // checkContainerByID inspects and potentially restarts a single container.
 func (g *Guardian) checkContainerByID(ctx context.Context, containerID string) {
-	// Use the regular unhealthy check — it re-queries and filters
-	g.orchestratorCached = false
-	g.checkUnhealthy(ctx)
+	g.logFor(ctx).Debug("processing debounced event", "id", containerID)
+	g.invalidateOrchestratorCache()
+
+	// Re-query so the container is only acted on if it is still unhealthy
+	containers, err := g.docker.UnhealthyContainers(ctx, g.cfg().ContainerLabel, g.cfg().OnlyMonitorRunning)
+	if err != nil {
+		g.internalError(ctx, "failed to list unhealthy containers", err)
+		return
+	}
+	metrics.UnhealthyContainers.Set(float64(len(containers)))
+	g.observeUnhealthyCount(len(containers))
+
+	var summary scanSummary
+	for _, c := range containers {
+		if c.ID == containerID {
+			if scanOnly(c.Labels) {
+				g.logFor(ctx).Debug("autoheal.reaction=scan, leaving to the next full scan", "id", shortContainerID(containerID))
+				return
+			}
+			g.handleUnhealthy(ctx, c, &summary)
+			return
+		}
+	}
 }
 
 // checkOrphanedDependents checks if any dependents of the given container need starting.
-func (g *Guardian) checkOrphanedDependents(ctx context.Context, _ string) {
-	g.orchestratorCached = false
+// With AUTOHEAL_DEPENDENCY_DIE_GRACE set, it first waits so that a parent
+// restarting alongside its child settles; the scan then re-verifies each
+// parent is running before starting anything.
+func (g *Guardian) checkOrphanedDependents(ctx context.Context, containerID string) {
+	if g.cfg().DependencyDieGrace > 0 {
+		g.logFor(ctx).Debug("waiting for die grace before orphan scan",
+			"id", containerID, "grace_seconds", g.cfg().DependencyDieGrace)
+		select {
+		case <-g.clock.After(time.Duration(g.cfg().DependencyDieGrace) * time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+	g.invalidateOrchestratorCache()
 	g.checkDependencyOrphans(ctx)
 }
 
-// recordOrchestrationActivity records a create/destroy event for orchestration tracking.
+// orchestrationPruneInterval is the minimum time between sweeps of expired
+// orchestration events.
+const orchestrationPruneInterval = 10 * time.Second
+
+// recordOrchestrationActivity records a create/destroy event for orchestration
+// tracking. Expired entries are swept inline at most once per
+// orchestrationPruneInterval, so a burst of events (a large docker compose up)
+// costs a single sweep rather than a goroutine per event.
 func (g *Guardian) recordOrchestrationActivity(evt docker.ContainerEvent) {
 	g.orchestrationMu.Lock()
+	defer g.orchestrationMu.Unlock()
 	g.orchestrationEvents[evt.ContainerName] = evt.Timestamp
-	g.orchestrationMu.Unlock()
 
-	// Clean up old entries
-	go g.pruneOrchestrationEvents()
+	now := g.clock.Now()
+	if now.Sub(g.orchestrationPrunedAt) < orchestrationPruneInterval {
+		return
+	}
+	g.orchestrationPrunedAt = now
+	cutoff := now.Add(-time.Duration(g.cfg().WatchtowerCooldown) * time.Second)
+	for name, ts := range g.orchestrationEvents {
+		if ts.Before(cutoff) {
+			delete(g.orchestrationEvents, name)
+		}
+	}
 }
 
-func (g *Guardian) pruneOrchestrationEvents() {
-	cutoff := g.clock.Now().Add(-time.Duration(g.cfg.WatchtowerCooldown) * time.Second)
+// recentOrchestration returns the containers with create/destroy events on
+// the stream at or after since.
+func (g *Guardian) recentOrchestration(since time.Time) map[string]time.Time {
 	g.orchestrationMu.Lock()
+	defer g.orchestrationMu.Unlock()
+	recent := make(map[string]time.Time)
 	for name, ts := range g.orchestrationEvents {
-		if ts.Before(cutoff) {
-			delete(g.orchestrationEvents, name)
+		if !ts.Before(since) {
+			recent[name] = ts
 		}
 	}
-	g.orchestrationMu.Unlock()
+	return recent
+}
+
+// Ready returns true once a full scan has completed against a reachable
+// daemon and, in event-driven mode, the event stream is established.
+func (g *Guardian) Ready() bool {
+	if !g.scanned.Load() {
+		return false
+	}
+	if g.EventStreamConnected() && (!g.streaming.Load() || g.Degraded()) {
+		return false
+	}
+	return true
 }
 
 // EventStreamConnected returns whether we're using event-driven mode.
 // Used by metrics.
 func (g *Guardian) EventStreamConnected() bool {
-	_, ok := g.docker.(*docker.Client)
+	_, ok := g.dockerClient()
 	return ok
 }
 
@@ -232,9 +690,61 @@ func (g *Guardian) Tracker() *RestartTracker {
 // UnhealthyCount returns the count from the last check (for metrics).
 // This is a simple accessor — the real metric instrumentation happens in Phase 5.
 func (g *Guardian) UnhealthyCount(ctx context.Context) int {
-	containers, err := g.docker.UnhealthyContainers(ctx, g.cfg.ContainerLabel, g.cfg.OnlyMonitorRunning)
+	containers, err := g.docker.UnhealthyContainers(ctx, g.cfg().ContainerLabel, g.cfg().OnlyMonitorRunning)
 	if err != nil {
 		return 0
 	}
 	return len(containers)
 }
+
+// Status is a point-in-time snapshot of guardian state, served on /status.
+type Status struct {
+	Ready                bool               `json:"ready"`
+	Mode                 string             `json:"mode"` // "events", "degraded" or "polling"
+	EventStreamConnected bool               `json:"event_stream_connected"`
+	Disabled             bool               `json:"disabled"`
+	UptimeSeconds        float64            `json:"uptime_seconds"`
+	Unhealthy            int                `json:"unhealthy"` // as of the latest check
+	OpenCircuits         []TrackedContainer `json:"open_circuits"`
+	Decisions            []Decision         `json:"decisions"`
+
+	// Cumulative restarts per container name (only with AUTOHEAL_STATE_FILE)
+	LifetimeRestarts map[string]int `json:"lifetime_restarts,omitempty"`
+}
+
+// Status returns the current guardian status including recent decisions.
+func (g *Guardian) Status() Status {
+	mode := "polling"
+	if g.EventStreamConnected() {
+		mode = "events"
+		if g.Degraded() {
+			mode = "degraded"
+		}
+	}
+	decisions := g.Decisions()
+	if decisions == nil {
+		decisions = []Decision{}
+	}
+	circuits := []TrackedContainer{}
+	for _, c := range g.tracker.Snapshot() {
+		if c.CircuitOpen {
+			circuits = append(circuits, c)
+		}
+	}
+	st := Status{
+		Ready:                g.Ready(),
+		Mode:                 mode,
+		EventStreamConnected: mode == "events" && g.streaming.Load(),
+		Disabled:             g.Disabled(),
+		Unhealthy:            int(g.unhealthyNow.Load()),
+		OpenCircuits:         circuits,
+		Decisions:            decisions,
+	}
+	if !g.startedAt.IsZero() {
+		st.UptimeSeconds = g.clock.Since(g.startedAt).Round(time.Second).Seconds()
+	}
+	if g.state != nil {
+		st.LifetimeRestarts = g.state.lifetimeRestarts()
+	}
+	return st
+}
@@ -1,24 +1,46 @@
 package guardian
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/events"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func newTestGuardian(cfg *config.Config, dock *mockDocker, notif *mockNotifier, clk *mockClock) *Guardian {
-	return &Guardian{
-		cfg:      cfg,
+	g := &Guardian{
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
+
+		debounceTimers:      make(map[string]*time.Timer),
+		debounceWindow:      10 * time.Millisecond,
+		stopCh:              make(chan struct{}),
+		rescanCh:            make(chan struct{}, 1),
+		orchestrationEvents: make(map[string]time.Time),
+		scriptSem:           make(chan struct{}, max(cfg.PostRestartScriptMaxConcurrent, 1)),
 	}
+	g.conf.Store(cfg)
+	return g
 }
 
 func TestShouldSkip_NoGuards(t *testing.T) {
@@ -135,6 +157,125 @@ func TestShouldSkip_OrchestrationAffected(t *testing.T) {
 	}
 }
 
+func TestShouldSkip_EventDrivenUsesStreamedOrchestration(t *testing.T) {
+	cfg := &config.Config{
+		WatchtowerCooldown: 300,
+		WatchtowerScope:    "affected",
+		WatchtowerEvents:   "orchestration",
+	}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.recordStreamConnected()
+	g.connectedAt = clk.Now().Add(-time.Hour) // connected well over a cooldown ago
+	ctx := context.Background()
+
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerName: "stale", Action: "create", Timestamp: clk.Now().Add(-10 * time.Minute)})
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerName: "web", Action: "create", Timestamp: clk.Now().Add(-time.Minute)})
+
+	if !g.shouldSkip(ctx, "abcdef123456", "/web", nil) {
+		t.Error("container with a recent create event should be skipped")
+	}
+	if g.shouldSkip(ctx, "bbbbbb123456", "/stale", nil) {
+		t.Error("create event outside the cooldown should not cause a skip")
+	}
+	if g.shouldSkip(ctx, "cccccc123456", "/db", nil) {
+		t.Error("container without events should not be skipped with scope=affected")
+	}
+	if dock.containerEventsCalls != 0 {
+		t.Errorf("event-driven mode should not query ContainerEvents, got %d calls", dock.containerEventsCalls)
+	}
+
+	// A degraded stream may have missed events, so Docker is asked again
+	g.streamMu.Lock()
+	g.degraded = true
+	g.streamMu.Unlock()
+	g.invalidateOrchestratorCache()
+	g.shouldSkip(ctx, "abcdef123456", "/web", nil)
+	if dock.containerEventsCalls != 1 {
+		t.Errorf("degraded stream should fall back to ContainerEvents, got %d calls", dock.containerEventsCalls)
+	}
+
+	// So may a stream that has dropped and not yet reconnected
+	g.streamMu.Lock()
+	g.degraded = false
+	g.streamMu.Unlock()
+	g.recordStreamDisconnected()
+	g.invalidateOrchestratorCache()
+	g.shouldSkip(ctx, "abcdef123456", "/web", nil)
+	if dock.containerEventsCalls != 2 {
+		t.Errorf("disconnected stream should fall back to ContainerEvents, got %d calls", dock.containerEventsCalls)
+	}
+}
+
+func TestShouldSkip_QueriesDockerForOneCooldownAfterReconnect(t *testing.T) {
+	cfg := &config.Config{
+		WatchtowerCooldown: 300,
+		WatchtowerScope:    "affected",
+		WatchtowerEvents:   "orchestration",
+	}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	ctx := context.Background()
+
+	// The stream drops and comes back; a create during the gap is only
+	// known to Docker's event history
+	g.recordStreamConnected()
+	g.recordStreamDisconnected()
+	clk.Advance(30 * time.Second)
+	dock.containerEvents = []events.Message{{
+		Action: events.ActionCreate,
+		Actor:  events.Actor{Attributes: map[string]string{"name": "web"}},
+		Time:   clk.Now().Add(-10 * time.Second).Unix(),
+	}}
+	g.recordStreamConnected()
+
+	if !g.shouldSkip(ctx, "abcdef123456", "/web", nil) {
+		t.Error("create during the reconnect gap should still be honoured")
+	}
+	if dock.containerEventsCalls != 1 {
+		t.Errorf("expected ContainerEvents to be queried right after a reconnect, got %d calls", dock.containerEventsCalls)
+	}
+
+	// A full cooldown after the reconnect the stream covers the window
+	clk.Advance(5 * time.Minute)
+	g.invalidateOrchestratorCache()
+	g.shouldSkip(ctx, "abcdef123456", "/web", nil)
+	if dock.containerEventsCalls != 1 {
+		t.Errorf("expected the stream to be trusted a cooldown after reconnecting, got %d calls", dock.containerEventsCalls)
+	}
+}
+
+func TestShouldSkip_OrchestrationNotify(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		cfg := &config.Config{
+			WatchtowerCooldown:  300,
+			WatchtowerScope:     "all",
+			WatchtowerEvents:    "orchestration",
+			OrchestrationNotify: enabled,
+		}
+		dock := newMockDocker()
+		notif := &mockNotifier{}
+		dock.containerEvents = []events.Message{{Action: "create"}}
+
+		g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+		if !g.shouldSkip(context.Background(), "abcdef123456", "test-container", nil) {
+			t.Fatalf("enabled=%v: should still skip the action during orchestration", enabled)
+		}
+		if enabled {
+			if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "deferred") {
+				t.Errorf("expected a deferral notification, got %v", notif.actions)
+			}
+		} else if len(notif.actions) != 0 {
+			t.Errorf("expected no action notification when disabled, got %v", notif.actions)
+		}
+		if len(dock.restartCalls) != 0 {
+			t.Errorf("enabled=%v: restart must stay suppressed, got %v", enabled, dock.restartCalls)
+		}
+	}
+}
+
 func TestShouldSkip_BackupWithinTimeout(t *testing.T) {
 	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
 	clk := newMockClock(now)
@@ -186,3 +327,685 @@ func TestShouldSkip_BackupTimeoutExpired(t *testing.T) {
 		t.Error("should not skip when backup timeout has expired")
 	}
 }
+
+func TestStartupScan_Summary(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+		WatchtowerCooldown: 0,
+		GracePeriod:        0,
+		BackupLabel:        "",
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "aaaaaa1234567890abcdef", Names: []string{"/app-a"}, State: "running", Labels: map[string]string{}},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/app-b"}, State: "running", Labels: map[string]string{}},
+	}
+	dock.runningContainers = []container.Summary{
+		{ID: "aaaaaa1234567890abcdef", Labels: map[string]string{}},
+		{ID: "bbbbbb1234567890abcdef", Labels: map[string]string{}},
+		{ID: "cccccc1234567890abcdef", Labels: map[string]string{}},
+		{ID: "dddddd1234567890abcdef", Labels: map[string]string{"autoheal": "False"}},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.startupScan(context.Background())
+
+	if len(notif.startups) != 1 {
+		t.Fatalf("expected 1 startup notification, got %d", len(notif.startups))
+	}
+	want := "Startup scan: 3 monitored, 2 unhealthy, 2 restarted"
+	if notif.startups[0] != want {
+		t.Errorf("got %q, want %q", notif.startups[0], want)
+	}
+}
+
+func TestLivenessTimeouts_DegradeToPolling(t *testing.T) {
+	cfg := &config.Config{Interval: 5, EventLivenessFailures: 3}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	g.recordLivenessTimeout()
+	g.recordLivenessTimeout()
+	if g.Degraded() {
+		t.Fatal("should not degrade before threshold")
+	}
+	if len(g.rescanCh) != 0 {
+		t.Fatal("no scan should be queued before the threshold")
+	}
+
+	g.recordLivenessTimeout()
+	if !g.Degraded() {
+		t.Fatal("should degrade to polling after 3 liveness timeouts")
+	}
+	// Degrading queues a catch-up scan instead of waiting for the next tick
+	if len(g.rescanCh) != 1 {
+		t.Fatal("expected degrading to queue an immediate full scan")
+	}
+	<-g.rescanCh
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "[CRITICAL]") {
+		t.Errorf("expected one critical degraded notification, got %v", notif.actions)
+	}
+
+	// Further timeouts don't re-notify
+	g.recordLivenessTimeout()
+	if len(notif.actions) != 1 {
+		t.Errorf("expected no repeat notification, got %v", notif.actions)
+	}
+
+	// An event arriving restores event-driven mode
+	g.recordStreamActivity()
+	if g.Degraded() {
+		t.Error("should leave degraded mode once events arrive")
+	}
+	if len(notif.actions) != 2 || !strings.Contains(notif.actions[1], "recovered") {
+		t.Errorf("expected recovery notification, got %v", notif.actions)
+	}
+}
+
+func TestLivenessTimeouts_ActivityResetsCount(t *testing.T) {
+	cfg := &config.Config{Interval: 5, EventLivenessFailures: 2}
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+
+	g.recordLivenessTimeout()
+	g.recordStreamActivity()
+	g.recordLivenessTimeout()
+	if g.Degraded() {
+		t.Error("non-consecutive timeouts should not degrade")
+	}
+}
+
+func TestReady_AfterFirstScan(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all"}
+	dock := newMockDocker()
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+	if g.Ready() {
+		t.Fatal("should not be ready before the first scan")
+	}
+
+	dock.unhealthyErr = errors.New("daemon unreachable")
+	g.fullScan(context.Background())
+	if g.Ready() {
+		t.Fatal("should not be ready when the scan could not reach the daemon")
+	}
+
+	dock.unhealthyErr = nil
+	g.fullScan(context.Background())
+	if !g.Ready() {
+		t.Error("should be ready after a successful scan")
+	}
+}
+
+func TestHandleEvent_CorrelationID(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{
+		{ID: id, Names: []string{"/test-app"}, State: "running"},
+	}
+	dock.restartErr[id] = errors.New("restart failed")
+
+	var buf bytes.Buffer
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.log = logging.NewWriter(&buf, true, slog.LevelDebug)
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{
+		ContainerID:   id,
+		ContainerName: "test-app",
+		Action:        "health_status",
+		HealthStatus:  "unhealthy",
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		notif.mu.Lock()
+		n := len(notif.actionEvents)
+		notif.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for debounced restart")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ids := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid JSON log record %q: %v", line, err)
+		}
+		msg, _ := rec["msg"].(string)
+		cid, _ := rec["correlation_id"].(string)
+		ids[msg] = cid
+	}
+
+	want := ids["docker event received"]
+	if want == "" {
+		t.Fatalf("event record missing correlation_id, records: %v", ids)
+	}
+	for _, msg := range []string{"processing debounced event", "failed to restart container"} {
+		if ids[msg] != want {
+			t.Errorf("record %q correlation_id = %q, want %q", msg, ids[msg], want)
+		}
+	}
+	if got := notif.actionEvents[0].CorrelationID; got != want {
+		t.Errorf("notification correlation_id = %q, want %q", got, want)
+	}
+	if got := notif.actionEvents[0].Container; got != "test-app" {
+		t.Errorf("notification container = %q, want test-app", got)
+	}
+}
+
+func TestCheckBackupRunning_MultipleBackupContainers(t *testing.T) {
+	cfg := &config.Config{BackupActiveLabel: "backup.active"}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.runningContainers = []container.Summary{
+		{ID: "aaaaaa123456", Names: []string{"/app"}},
+		{ID: "bbbbbb123456", Names: []string{"/backup-media"}, Labels: map[string]string{"backup.active": "true"}},
+		{ID: "cccccc123456", Names: []string{"/backup-db"}, Labels: map[string]string{"backup.active": "true"}},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	if !g.checkBackupRunning(context.Background()) {
+		t.Error("expected backup running with labelled containers present")
+	}
+	if !g.shouldSkip(context.Background(), "dddddd123456", "unrelated", nil) {
+		t.Error("should skip every container while a backup is running")
+	}
+	if len(notif.skips) != 1 || !strings.Contains(notif.skips[0], "backup running") {
+		t.Errorf("expected backup running skip notification, got %v", notif.skips)
+	}
+}
+
+func TestCheckBackupRunning_None(t *testing.T) {
+	cfg := &config.Config{BackupActiveLabel: "backup.active=true"}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.runningContainers = []container.Summary{
+		{ID: "aaaaaa123456", Names: []string{"/app"}},
+		{ID: "bbbbbb123456", Names: []string{"/backup-idle"}, Labels: map[string]string{"backup.active": "false"}},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	if g.checkBackupRunning(context.Background()) {
+		t.Error("expected no backup running when no container matches the label value")
+	}
+	if g.shouldSkip(context.Background(), "dddddd123456", "unrelated", nil) {
+		t.Error("should not skip when no backup is running")
+	}
+}
+
+func TestDebounce_CountsCoalescedEvents(t *testing.T) {
+	g := newTestGuardian(&config.Config{}, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+	g.debounceWindow = time.Hour
+
+	before := testutil.ToFloat64(metrics.EventsDebouncedTotal)
+	for range 5 {
+		g.debounce(context.Background(), "abcdef123456", func() {})
+	}
+	after := testutil.ToFloat64(metrics.EventsDebouncedTotal)
+
+	g.debounceMu.Lock()
+	for _, timer := range g.debounceTimers {
+		timer.Stop()
+	}
+	g.debounceMu.Unlock()
+
+	if got := after - before; got != 4 {
+		t.Errorf("debounced = %v, want 4 (5 events coalesced into 1)", got)
+	}
+}
+
+func TestHandleEvent_EventActionsAllowlist(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, EventActions: []string{"health"}}
+	dock := newMockDocker()
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	g.debounceWindow = time.Hour // keep scheduled handlers pending for inspection
+
+	pending := func() []string {
+		g.debounceMu.Lock()
+		defer g.debounceMu.Unlock()
+		var keys []string
+		for k, timer := range g.debounceTimers {
+			timer.Stop()
+			keys = append(keys, k)
+		}
+		return keys
+	}
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: "parent1234567890abcdef", Action: "die"})
+	if keys := pending(); len(keys) != 0 {
+		t.Errorf("die event with only health enabled should schedule no orphan check, got %v", keys)
+	}
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{
+		ContainerID: "abcdef1234567890abcdef", Action: "health_status", HealthStatus: "unhealthy",
+	})
+	if keys := pending(); len(keys) != 1 || keys[0] != "abcdef1234567890abcdef" {
+		t.Errorf("health event should still schedule a check, got %v", keys)
+	}
+}
+
+func TestHandleEvent_OOMBeforeDieSendsOOMNotification(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, EventActions: []string{"oom"}}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	at := time.Now()
+	g := newTestGuardian(cfg, dock, notif, newMockClock(at))
+	g.debounceWindow = time.Hour // keep the scheduled check pending
+
+	const id = "abcdef1234567890abcdef"
+	dock.inspectResults[id] = container.InspectResponse{Name: "/web", Config: &container.Config{}}
+	ctx := context.Background()
+
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerID: "other1234567890abcdef", ContainerName: "other", Action: "die", Timestamp: at})
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerID: id, ContainerName: "web", Action: "oom", Timestamp: at})
+	g.debounceMu.Lock()
+	_, scheduled := g.debounceTimers["exit:"+id]
+	g.debounceMu.Unlock()
+	if !scheduled {
+		t.Error("oom event should schedule an exited check of the container")
+	}
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerID: id, ContainerName: "web", Action: "die", Timestamp: at.Add(time.Millisecond)})
+
+	notif.mu.Lock()
+	actions := append([]string(nil), notif.actions...)
+	notif.mu.Unlock()
+	if len(actions) != 1 || !strings.Contains(actions[0], "Container web (abcdef123456) was killed by the OOM killer") {
+		t.Errorf("expected one OOM notification for web only, got %v", actions)
+	}
+
+	// The oom record is consumed by the die; a second die is a plain exit
+	g.handleEvent(ctx, docker.ContainerEvent{ContainerID: id, ContainerName: "web", Action: "die", Timestamp: at.Add(time.Second)})
+	notif.mu.Lock()
+	n := len(notif.actions)
+	notif.mu.Unlock()
+	if n != 1 {
+		t.Errorf("die without a preceding oom should not notify, got %d notifications", n)
+	}
+}
+
+func TestHandleEvent_OOMStartsExitedContainer(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		actions   []string
+		wantStart bool
+	}{
+		{"oom enabled", []string{"oom"}, true},
+		{"oom disabled", []string{"health", "orphan"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dock, _ := exitedGuardian(137, true)
+			g.cfg().EventActions = tt.actions
+			const id = "crash01234567890abcdef"
+
+			g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, ContainerName: "worker", Action: "oom", Timestamp: time.Now()})
+			time.Sleep(50 * time.Millisecond)
+
+			dock.mu.Lock()
+			started := slices.Contains(dock.startCalls, id)
+			dock.mu.Unlock()
+			if started != tt.wantStart {
+				t.Errorf("exited container started = %v, want %v", started, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestHandleEvent_CrashStartsExitedContainer(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		actions   []string
+		exitCode  int
+		wantStart bool
+	}{
+		{"non-zero exit", []string{"crash"}, 1, true},
+		{"clean exit", []string{"crash"}, 0, false},
+		{"crash disabled", []string{"health", "orphan"}, 1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g, dock, _ := exitedGuardian(tt.exitCode, false)
+			g.cfg().EventActions = tt.actions
+			const id = "crash01234567890abcdef"
+
+			g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, ContainerName: "worker", Action: "die", ExitCode: tt.exitCode, Timestamp: time.Now()})
+			time.Sleep(50 * time.Millisecond)
+
+			dock.mu.Lock()
+			started := slices.Contains(dock.startCalls, id)
+			dock.mu.Unlock()
+			if started != tt.wantStart {
+				t.Errorf("exited container started = %v, want %v", started, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestHandleEvent_KillIsNotAFailureSignal(t *testing.T) {
+	g, _, _ := exitedGuardian(137, false)
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: "crash01234567890abcdef", ContainerName: "worker", Action: "kill", Timestamp: time.Now()})
+
+	g.debounceMu.Lock()
+	n := len(g.debounceTimers)
+	g.debounceMu.Unlock()
+	if n != 0 {
+		t.Errorf("a kill event should not schedule any check, got %d", n)
+	}
+}
+
+func TestHandleEvent_ScanReactionLeavesContainerToFullScan(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{
+		{ID: id, Names: []string{"/noisy"}, State: "running", Labels: map[string]string{"autoheal.reaction": "scan"}},
+	}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, Action: "health_status", HealthStatus: "unhealthy"})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		g.debounceMu.Lock()
+		n := len(g.debounceTimers)
+		g.debounceMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the debounced check")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	dock.mu.Lock()
+	restarts := len(dock.restartCalls)
+	dock.mu.Unlock()
+	if restarts != 0 {
+		t.Fatalf("scan-mode container restarted from an event: %v", dock.restartCalls)
+	}
+
+	g.fullScan(context.Background())
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != id {
+		t.Errorf("expected the full scan to restart the container, got %v", dock.restartCalls)
+	}
+}
+
+func TestRecordOrchestrationActivity_PrunesWithoutGoroutines(t *testing.T) {
+	cfg := &config.Config{WatchtowerCooldown: 60}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, clk)
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 500; i++ {
+		g.recordOrchestrationActivity(docker.ContainerEvent{
+			ContainerName: fmt.Sprintf("svc-%d", i), Action: "create", Timestamp: clk.Now(),
+		})
+	}
+	// Slack for unrelated runtime goroutines; a goroutine per event would add hundreds
+	if after := runtime.NumGoroutine(); after > before+10 {
+		t.Errorf("recording events spawned goroutines: %d → %d", before, after)
+	}
+	if n := len(g.orchestrationEvents); n != 500 {
+		t.Fatalf("expected 500 tracked containers, got %d", n)
+	}
+
+	// Once the burst is older than the cooldown, the next event sweeps it away
+	clk.Advance(61 * time.Second)
+	g.recordOrchestrationActivity(docker.ContainerEvent{ContainerName: "late", Action: "create", Timestamp: clk.Now()})
+	if n := len(g.orchestrationEvents); n != 1 {
+		t.Errorf("expected only the fresh event after pruning, got %d entries", n)
+	}
+}
+
+func TestEventLoop_AsyncStartupScanHandlesEvents(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", Interval: 60, StartupScanAsync: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	slow, app := "slow01234567890abcdef", "app001234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{
+		{ID: slow, Names: []string{"/slow"}, State: "running"},
+		{ID: app, Names: []string{"/app"}, State: "running"},
+	}
+	gate := make(chan struct{})
+	dock.restartGate[slow] = gate // the startup scan stalls on the first container
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	restarts := func(id string) int {
+		dock.mu.Lock()
+		defer dock.mu.Unlock()
+		n := 0
+		for _, c := range dock.restartCalls {
+			if c == id {
+				n++
+			}
+		}
+		return n
+	}
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	eventCh := make(chan docker.ContainerEvent)
+	done := make(chan error, 1)
+	go func() { done <- g.eventLoop(ctx, eventCh) }()
+
+	waitFor("startup scan to reach the slow container", func() bool { return restarts(slow) == 1 })
+	for _, id := range []string{slow, app} {
+		eventCh <- docker.ContainerEvent{ContainerID: id, Action: "health_status", HealthStatus: "unhealthy"}
+	}
+	waitFor("event to restart app during the scan", func() bool { return restarts(app) == 1 })
+	waitFor("debounced events to be handled", func() bool {
+		g.debounceMu.Lock()
+		defer g.debounceMu.Unlock()
+		return len(g.debounceTimers) == 0
+	})
+
+	close(gate)
+	waitFor("startup scan to finish", func() bool {
+		notif.mu.Lock()
+		defer notif.mu.Unlock()
+		return len(notif.startups) == 1
+	})
+	cancel()
+	<-done
+
+	if n := restarts(slow); n != 1 {
+		t.Errorf("slow restarted %d times, want 1 (the event must not act while the scan owns it)", n)
+	}
+	if n := restarts(app); n != 1 {
+		t.Errorf("app restarted %d times, want 1 (the scan must not repeat the event's restart)", n)
+	}
+}
+
+func TestHandleEvent_ProcessRateDropsFloodButNotDie(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", Interval: 60, EventProcessRate: 10, MonitorDependencies: true}
+	g := New(cfg, newMockDocker(), &mockNotifier{}, logging.New(false, slog.LevelDebug))
+	defer func() {
+		g.debounceMu.Lock()
+		for _, timer := range g.debounceTimers {
+			timer.Stop()
+		}
+		g.debounceMu.Unlock()
+	}()
+
+	processed := func(action string) float64 {
+		return testutil.ToFloat64(metrics.EventsProcessedTotal.WithLabelValues(action))
+	}
+	dropped := func(action string) float64 {
+		return testutil.ToFloat64(metrics.EventsDroppedTotal.WithLabelValues(action))
+	}
+	healthBefore, droppedBefore, dieBefore := processed("health_status"), dropped("health_status"), processed("die")
+
+	for range 100 {
+		g.handleEvent(context.Background(), docker.ContainerEvent{
+			ContainerID: "abcdef1234567890abcdef", Action: "health_status", HealthStatus: "unhealthy",
+		})
+	}
+	// The burst (one second's worth) passes; the rest of the flood is dropped
+	if n := processed("health_status") - healthBefore; n < 10 || n > 11 {
+		t.Errorf("expected about 10 health events processed, got %v", n)
+	}
+	if n := (processed("health_status") - healthBefore) + (dropped("health_status") - droppedBefore); n != 100 {
+		t.Errorf("processed + dropped = %v, want 100", n)
+	}
+
+	for range 5 {
+		g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: "parent1234567890abcdef", Action: "die"})
+	}
+	if n := processed("die") - dieBefore; n != 5 {
+		t.Errorf("die events must never be dropped, processed %v of 5", n)
+	}
+
+	// Tokens refill at the configured rate
+	time.Sleep(250 * time.Millisecond)
+	mid := processed("health_status")
+	for range 10 {
+		g.handleEvent(context.Background(), docker.ContainerEvent{
+			ContainerID: "abcdef1234567890abcdef", Action: "health_status", HealthStatus: "unhealthy",
+		})
+	}
+	if n := processed("health_status") - mid; n < 1 || n > 4 {
+		t.Errorf("expected a few events admitted after refilling, got %v", n)
+	}
+}
+
+func TestReload_SwapsConfigAndKeepsTrackerHistory(t *testing.T) {
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(&config.Config{ContainerLabel: "all", GracePeriod: 300}, newMockDocker(), &mockNotifier{}, clk)
+	g.tracker.RecordRestart("abc123")
+
+	next := &config.Config{ContainerLabel: "all", GracePeriod: 60, BackoffMultiplier: 3, BackoffMax: 120, RestartBudget: 1, RestartWindow: 600}
+	g.Reload(next)
+
+	if g.cfg() != next {
+		t.Fatal("Reload did not swap the config")
+	}
+	g.tracker.mu.Lock()
+	_, kept := g.tracker.history["abc123"]
+	tcfg := g.tracker.cfg
+	g.tracker.mu.Unlock()
+	if !kept {
+		t.Error("Reload dropped the tracker history")
+	}
+	if tcfg.RestartBudget != 1 || tcfg.BackoffMultiplier != 3 || tcfg.BackoffMax != 120*time.Second {
+		t.Errorf("tracker config not updated: %+v", tcfg)
+	}
+}
+
+func TestPostRestartScript_JSONOnStdin(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "stdin.json")
+	script := filepath.Join(dir, "hook.sh")
+	// Write via a temp name so the test never reads a half-written file
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > "+out+".tmp && mv "+out+".tmp "+out+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, PostRestartScript: script, PostRestartScriptJSON: true}
+	dock := newMockDocker()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(now))
+	g.checkUnhealthy(context.Background())
+
+	var data []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if b, err := os.ReadFile(out); err == nil {
+			data = b
+			break
+		}
+	}
+	if data == nil {
+		t.Fatal("post-restart script did not run")
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("stdin is not JSON: %v (%s)", err, data)
+	}
+	want := map[string]any{
+		"container": "app",
+		"id":        "abcdef123456",
+		"full_id":   id,
+		"action":    "restart",
+		"result":    "success",
+		"state":     "running",
+		"timeout":   float64(10),
+		"exit_code": float64(0),
+		"timestamp": "2025-01-01T12:00:00Z",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestPostRestartScript_KilledAtTimeout(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "child.pid")
+	script := filepath.Join(dir, "hang.sh")
+	// The background sleep shares the script's process group
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nsleep 30 &\necho $! > "+pidFile+"\nwait\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{PostRestartScript: script, PostRestartScriptTimeout: 1}
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+
+	start := time.Now()
+	if err := g.execPostRestartScript(cfg, postRestart{Container: "app", ShortID: "abcdef123456"}); err != nil {
+		t.Fatalf("timeout should be logged, not returned: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("script ran for %v, want it killed at the 1s deadline", elapsed)
+	}
+
+	pid, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("script did not record its child: %v", err)
+	}
+	if _, err := os.Stat("/proc"); err != nil {
+		t.Skip("no /proc to check the child process")
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		// Gone, or a zombie nobody has reaped yet: either way it was killed
+		stat, err := os.ReadFile("/proc/" + strings.TrimSpace(string(pid)) + "/stat")
+		if err != nil || strings.Contains(string(stat), ") Z ") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("child process still running: %s", stat)
+		}
+	}
+}
@@ -3,82 +3,229 @@ package guardian
 import (
 	"context"
 	"fmt"
-	"os/exec"
+	"path"
 	"strings"
 	"time"
 
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
 )
 
+// inScope applies CONTAINER_ID_DENYLIST and CONTAINER_ID_ALLOWLIST. The
+// denylist always wins; a non-empty allowlist restricts management to the
+// containers it lists.
+func (g *Guardian) inScope(containerID, containerName string) bool {
+	if matchesContainer(g.cfg().ContainerIDDenylist, containerID, containerName) {
+		return false
+	}
+	if len(g.cfg().ContainerIDAllowlist) > 0 {
+		return matchesContainer(g.cfg().ContainerIDAllowlist, containerID, containerName)
+	}
+	return true
+}
+
+// isExcluded reports whether AUTOHEAL_EXCLUDE_LABEL or AUTOHEAL_EXCLUDE_NAMES
+// keeps the guardian away from a container. The label excludes when present
+// with any value other than "false"; names are matched as globs without the
+// leading slash.
+func (g *Guardian) isExcluded(name string, labels map[string]string) bool {
+	if g.cfg().ExcludeLabel != "" {
+		if v, ok := labels[g.cfg().ExcludeLabel]; ok && !strings.EqualFold(v, "false") {
+			return true
+		}
+	}
+	name = strings.TrimPrefix(name, "/")
+	for _, p := range g.cfg().ExcludeNames {
+		if p == "" {
+			continue
+		}
+		if ok, _ := path.Match(strings.TrimPrefix(p, "/"), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// excluded applies isExcluded, logging and counting exclusions.
+func (g *Guardian) excluded(ctx context.Context, name string, labels map[string]string) bool {
+	if !g.isExcluded(name, labels) {
+		return false
+	}
+	g.logFor(ctx).Debug("container excluded", "container", name)
+	metrics.ExcludedTotal.WithLabelValues(name).Inc()
+	return true
+}
+
+// matchesContainer reports whether any entry is a prefix of the container ID
+// (so short IDs work) or a glob pattern matching the container name.
+func matchesContainer(entries []string, containerID, containerName string) bool {
+	name := strings.TrimPrefix(containerName, "/")
+	for _, e := range entries {
+		if strings.HasPrefix(containerID, e) {
+			return true
+		}
+		if ok, _ := path.Match(e, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyOrchestrationSkip reports an action deferred for orchestration
+// activity. Normally that is a skip notification; with
+// AUTOHEAL_ORCHESTRATION_NOTIFY it goes out as an action notification, subject
+// to the per-container NOTIFY_RATE_LIMIT, so users hear about the deferral
+// without opting into every skip.
+func (g *Guardian) notifyOrchestrationSkip(ctx context.Context, name, containerID string) {
+	if !g.cfg().OrchestrationNotify {
+		g.notifier.Skip(g.decisionEvent(ctx, name, containerID, "skip", "orchestration", fmt.Sprintf("Container %s (%s) skipped - orchestration activity", name, containerID[:12])))
+		return
+	}
+	g.notifier.Action(g.decisionEvent(ctx, name, containerID, "skip", "orchestration", fmt.Sprintf("Container %s (%s) needs attention - action deferred until orchestration activity settles (%ds cooldown)",
+		name, containerID[:12], g.cfg().WatchtowerCooldown)))
+}
+
 // shouldSkip returns true if this container should be skipped due to
 // orchestration activity, grace period, or backup awareness.
 func (g *Guardian) shouldSkip(ctx context.Context, containerID, containerName string, labels map[string]string) bool {
 	shortID := containerID[:12]
 	cleanName := strings.TrimPrefix(containerName, "/")
 
+	// Scheduled maintenance window (backups, batch jobs)
+	if g.inMaintenanceWindow(g.clock.Now()) {
+		g.logFor(ctx).Infof("Container %s (%s) inside a maintenance window - skipping", cleanName, shortID)
+		g.notifier.Skip(g.decisionEvent(ctx, cleanName, containerID, "skip", "maintenance", fmt.Sprintf("Container %s (%s) skipped - maintenance window", cleanName, shortID)))
+		metrics.SkipsTotal.WithLabelValues(cleanName, "maintenance").Inc()
+		g.recordDecision(ctx, cleanName, containerID, "skip", "maintenance")
+		return true
+	}
+
 	// Orchestrator/Watchtower cooldown
-	if g.cfg.WatchtowerCooldown > 0 {
+	if g.cfg().WatchtowerCooldown > 0 {
 		g.fetchOrchestrationEvents(ctx)
 
-		if g.cfg.WatchtowerScope == "affected" {
+		if g.cfg().WatchtowerScope == "affected" {
 			if g.isContainerInOrchestration(cleanName) {
-				now := g.clock.Now().Format("02-01-2006 15:04:05")
-				fmt.Printf("%s Container %s (%s) affected by orchestration activity within %ds - skipping\n",
-					now, cleanName, shortID, g.cfg.WatchtowerCooldown)
-				g.notifier.Skip(fmt.Sprintf("Container %s (%s) skipped - orchestration activity", cleanName, shortID))
+				g.logFor(ctx).Infof("Container %s (%s) affected by orchestration activity within %ds - skipping", cleanName, shortID, g.cfg().WatchtowerCooldown)
+				g.notifyOrchestrationSkip(ctx, cleanName, containerID)
 				metrics.SkipsTotal.WithLabelValues(cleanName, "orchestration").Inc()
+				g.recordDecision(ctx, cleanName, containerID, "skip", "orchestration")
 				return true
 			}
 		} else {
 			if g.isOrchestratorActive() {
-				now := g.clock.Now().Format("02-01-2006 15:04:05")
-				fmt.Printf("%s Container %s (%s) skipped - orchestration activity detected within %ds\n",
-					now, cleanName, shortID, g.cfg.WatchtowerCooldown)
-				g.notifier.Skip(fmt.Sprintf("Container %s (%s) skipped - orchestration activity", cleanName, shortID))
+				g.logFor(ctx).Infof("Container %s (%s) skipped - orchestration activity detected within %ds", cleanName, shortID, g.cfg().WatchtowerCooldown)
+				g.notifyOrchestrationSkip(ctx, cleanName, containerID)
 				metrics.SkipsTotal.WithLabelValues(cleanName, "orchestration").Inc()
+				g.recordDecision(ctx, cleanName, containerID, "skip", "orchestration")
 				return true
 			}
 		}
+
+		if g.cfg().WatchtowerEvents == "digest" && g.imageRecentlyChanged(ctx, containerID, cleanName) {
+			g.logFor(ctx).Infof("Container %s (%s) image changed within %ds - skipping", cleanName, shortID, g.cfg().WatchtowerCooldown)
+			g.notifyOrchestrationSkip(ctx, cleanName, containerID)
+			metrics.SkipsTotal.WithLabelValues(cleanName, "orchestration").Inc()
+			g.recordDecision(ctx, cleanName, containerID, "skip", "orchestration")
+			return true
+		}
 	}
 
 	// Grace period
-	if g.cfg.GracePeriod > 0 {
+	if g.cfg().GracePeriod > 0 {
 		finishedAt, err := g.docker.ContainerFinishedAt(ctx, containerID)
 		if err == nil {
 			age := g.clock.Since(finishedAt)
-			if age < time.Duration(g.cfg.GracePeriod)*time.Second {
-				now := g.clock.Now().Format("02-01-2006 15:04:05")
-				fmt.Printf("%s Container %s (%s) stopped within grace period (%ds) - skipping\n",
-					now, cleanName, shortID, g.cfg.GracePeriod)
-				g.notifier.Skip(fmt.Sprintf("Container %s (%s) skipped - grace period", cleanName, shortID))
+			if age < time.Duration(g.cfg().GracePeriod)*time.Second {
+				g.logFor(ctx).Infof("Container %s (%s) stopped within grace period (%ds) - skipping", cleanName, shortID, g.cfg().GracePeriod)
+				g.notifier.Skip(g.decisionEvent(ctx, cleanName, containerID, "skip", "grace", fmt.Sprintf("Container %s (%s) skipped - grace period", cleanName, shortID)))
 				metrics.SkipsTotal.WithLabelValues(cleanName, "grace").Inc()
+				g.recordDecision(ctx, cleanName, containerID, "skip", "grace")
 				return true
 			}
 		}
 	}
 
 	// Backup awareness — skip containers stopped within backup timeout
-	if g.isBackupManaged(labels) && g.cfg.BackupTimeout > 0 {
+	if g.isBackupManaged(labels) && g.cfg().BackupTimeout > 0 {
 		finishedAt, err := g.docker.ContainerFinishedAt(ctx, containerID)
 		if err == nil {
 			age := g.clock.Since(finishedAt)
-			if age < time.Duration(g.cfg.BackupTimeout)*time.Second {
-				now := g.clock.Now().Format("02-01-2006 15:04:05")
-				fmt.Printf("%s Container %s (%s) managed by backup (stopped %s ago, timeout %ds) - skipping\n",
-					now, cleanName, shortID, age.Round(time.Second), g.cfg.BackupTimeout)
-				g.notifier.Skip(fmt.Sprintf("Container %s (%s) skipped - backup timeout", cleanName, shortID))
+			if age < time.Duration(g.cfg().BackupTimeout)*time.Second {
+				g.logFor(ctx).Infof("Container %s (%s) managed by backup (stopped %s ago, timeout %ds) - skipping", cleanName, shortID, age.Round(time.Second), g.cfg().BackupTimeout)
+				g.notifier.Skip(g.decisionEvent(ctx, cleanName, containerID, "skip", "backup", fmt.Sprintf("Container %s (%s) skipped - backup timeout", cleanName, shortID)))
 				metrics.SkipsTotal.WithLabelValues(cleanName, "backup").Inc()
+				g.recordDecision(ctx, cleanName, containerID, "skip", "backup")
 				return true
 			}
 		}
 	}
 
+	// Swarm node drain — containers stop on purpose; notified once in nodeDraining
+	if state, draining := g.nodeDraining(ctx); draining {
+		g.logFor(ctx).Infof("Container %s (%s) skipped - node availability %s", cleanName, shortID, state)
+		metrics.SkipsTotal.WithLabelValues(cleanName, "node-draining").Inc()
+		g.recordDecision(ctx, cleanName, containerID, "skip", "node-draining")
+		return true
+	}
+
+	// Host overload — restarting into a thrashing host makes things worse
+	if reason, overloaded := g.hostOverloaded(ctx); overloaded {
+		g.logFor(ctx).Infof("Container %s (%s) skipped - host overloaded (%s)", cleanName, shortID, reason)
+		g.notifier.Skip(g.decisionEvent(ctx, cleanName, containerID, "skip", "host-overloaded", fmt.Sprintf("Container %s (%s) skipped - host overloaded", cleanName, shortID)))
+		metrics.SkipsTotal.WithLabelValues(cleanName, "host-overloaded").Inc()
+		g.recordDecision(ctx, cleanName, containerID, "skip", "host-overloaded")
+		return true
+	}
+
+	// Host-wide backup pause — any running container carrying BACKUP_ACTIVE_LABEL
+	if g.checkBackupRunning(ctx) {
+		g.logFor(ctx).Infof("Container %s (%s) skipped - backup running (%s)", cleanName, shortID, g.cfg().BackupActiveLabel)
+		g.notifier.Skip(g.decisionEvent(ctx, cleanName, containerID, "skip", "backup", fmt.Sprintf("Container %s (%s) skipped - backup running", cleanName, shortID)))
+		metrics.SkipsTotal.WithLabelValues(cleanName, "backup").Inc()
+		g.recordDecision(ctx, cleanName, containerID, "skip", "backup")
+		return true
+	}
+
 	return false
 }
 
-// fetchOrchestrationEvents queries Docker events once per cycle and caches the result.
-// Also logs a summary line when events are detected.
+// containerGone handles an action that failed because the container no longer
+// exists, typically removed by a deploy between detection and action. That is
+// a benign skip: no failure notification and no restart budget consumed.
+// Scan and event paths both land here; docker_guardian_action_races_total
+// shows how often, to help tune AUTOHEAL_INTERVAL and debouncing.
+// Returns false for any other error.
+func (g *Guardian) containerGone(ctx context.Context, containerID, containerName string, err error) bool {
+	if !docker.IsNotFound(err) {
+		return false
+	}
+	g.logFor(ctx).Infof("Container %s (%s) no longer exists - skipping", containerName, containerID[:12])
+	metrics.SkipsTotal.WithLabelValues(containerName, "container-gone").Inc()
+	metrics.ActionRacesTotal.WithLabelValues(containerName).Inc()
+	g.recordDecision(ctx, containerName, containerID, "skip", "container-gone")
+	g.resetTracking(containerID, containerName)
+	return true
+}
+
+// actionFailed classifies a failed action on a container and counts it in
+// docker_guardian_action_errors_total. It returns the error class and whether
+// the failure should advance the container's circuit breaker: transient
+// classes (permission, conflict) are not the container's fault.
+func (g *Guardian) actionFailed(name, action string, err error) (docker.ErrorClass, bool) {
+	class := docker.ClassifyError(err)
+	metrics.ActionErrorsTotal.WithLabelValues(name, action, string(class)).Inc()
+	return class, !class.Transient()
+}
+
+// fetchOrchestrationEvents collects the containers with orchestration events
+// in the cooldown once per cycle and caches the result. In event-driven mode
+// they come from the create/destroy events already seen on the stream;
+// otherwise, or while the stream is degraded, Docker is queried. Also logs a
+// summary line when events are detected.
 func (g *Guardian) fetchOrchestrationEvents(ctx context.Context) {
+	g.orchestratorMu.Lock()
+	defer g.orchestratorMu.Unlock()
 	if g.orchestratorCached {
 		return
 	}
@@ -86,55 +233,96 @@ func (g *Guardian) fetchOrchestrationEvents(ctx context.Context) {
 	g.orchestratorEvents = nil
 
 	now := g.clock.Now()
-	since := now.Add(-time.Duration(g.cfg.WatchtowerCooldown) * time.Second)
-	orchestrationOnly := g.cfg.WatchtowerEvents != "all"
+	since := now.Add(-time.Duration(g.cfg().WatchtowerCooldown) * time.Second)
 
-	events, err := g.docker.ContainerEvents(ctx, since, now, orchestrationOnly)
-	if err != nil {
-		return
+	var count int
+	if g.orchestrationFromStream() {
+		g.orchestratorEvents = g.recentOrchestration(since)
+		count = len(g.orchestratorEvents)
+	} else {
+		orchestrationOnly := g.cfg().WatchtowerEvents != "all"
+		events, err := g.docker.ContainerEvents(ctx, since, now, orchestrationOnly)
+		if err != nil {
+			return
+		}
+		g.orchestratorEvents = make(map[string]time.Time, len(events))
+		for _, e := range events {
+			g.orchestratorEvents[e.Actor.Attributes["name"]] = time.Unix(e.Time, e.TimeNano%1e9)
+		}
+		count = len(events)
+	}
+
+	if count > 0 {
+		g.logFor(ctx).Infof("Orchestration activity detected: %d container event(s) within %ds cooldown", count, g.cfg().WatchtowerCooldown)
 	}
-	g.orchestratorEvents = events
+}
 
-	if len(events) > 0 {
-		dateStr := g.clock.Now().Format("02-01-2006 15:04:05")
-		fmt.Printf("%s Orchestration activity detected: %d container event(s) within %ds cooldown\n",
-			dateStr, len(events), g.cfg.WatchtowerCooldown)
+// orchestrationFromStream reports whether the event stream is healthy and
+// records every event the orchestration guard counts. AUTOHEAL_WATCHTOWER_EVENTS=all
+// includes event types the watcher does not subscribe to, so it still
+// queries Docker. The watcher resubscribes without a since, so until one
+// cooldown has passed since the latest (re)connect the stream may be missing
+// events from before it and Docker is queried instead.
+func (g *Guardian) orchestrationFromStream() bool {
+	if !g.streaming.Load() || g.cfg().WatchtowerEvents == "all" {
+		return false
 	}
+	g.streamMu.Lock()
+	defer g.streamMu.Unlock()
+	cooldown := time.Duration(g.cfg().WatchtowerCooldown) * time.Second
+	return !g.degraded && g.clock.Now().Sub(g.connectedAt) >= cooldown
 }
 
 // isOrchestratorActive returns true if any orchestration events were found this cycle.
 func (g *Guardian) isOrchestratorActive() bool {
+	g.orchestratorMu.Lock()
+	defer g.orchestratorMu.Unlock()
 	return len(g.orchestratorEvents) > 0
 }
 
+// invalidateOrchestratorCache makes the next guard check re-query events.
+func (g *Guardian) invalidateOrchestratorCache() {
+	g.orchestratorMu.Lock()
+	g.orchestratorCached = false
+	g.orchestratorMu.Unlock()
+}
+
 // isContainerInOrchestration checks if this specific container had events this cycle.
 func (g *Guardian) isContainerInOrchestration(containerName string) bool {
-	for _, e := range g.orchestratorEvents {
-		if e.Actor.Attributes["name"] == containerName {
-			return true
-		}
-	}
-	return false
+	g.orchestratorMu.Lock()
+	defer g.orchestratorMu.Unlock()
+	_, ok := g.orchestratorEvents[containerName]
+	return ok
 }
 
 // isBackupManaged returns true if the container has the backup label.
 func (g *Guardian) isBackupManaged(labels map[string]string) bool {
-	if g.cfg.BackupLabel == "" {
+	if g.cfg().BackupLabel == "" {
 		return false
 	}
-	_, ok := labels[g.cfg.BackupLabel]
+	_, ok := labels[g.cfg().BackupLabel]
 	return ok
 }
 
-// runPostRestartScript executes the POST_RESTART_SCRIPT if configured.
-func (g *Guardian) runPostRestartScript(containerName, shortID, state string, timeout int) {
-	if g.cfg.PostRestartScript == "" {
-		return
+// checkBackupRunning returns true if any running container carries
+// BACKUP_ACTIVE_LABEL. The label may be given as "key" (any value) or
+// "key=value" (exact match).
+func (g *Guardian) checkBackupRunning(ctx context.Context) bool {
+	if g.cfg().BackupActiveLabel == "" {
+		return false
 	}
-	go func() {
-		cmd := exec.Command(g.cfg.PostRestartScript, containerName, shortID, state, fmt.Sprintf("%d", timeout)) //nolint:gosec // User-configured script path from POST_RESTART_SCRIPT env var
-		if err := cmd.Run(); err != nil {
-			g.log.Error("post-restart script failed", "error", err)
+	key, want, hasValue := strings.Cut(g.cfg().BackupActiveLabel, "=")
+
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		g.internalError(ctx, "failed to list running containers", err)
+		return false
+	}
+	for _, c := range running {
+		v, ok := c.Labels[key]
+		if ok && (!hasValue || v == want) {
+			return true
 		}
-	}()
+	}
+	return false
 }
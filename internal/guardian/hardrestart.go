@@ -0,0 +1,99 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+)
+
+// hardRestartStartTimeout bounds the start that follows a hard restart's
+// stop, which runs even after shutdown has cancelled the action.
+const hardRestartStartTimeout = 30 * time.Second
+
+// hardRestartUnhealthy handles autoheal.action=hardrestart: an explicit stop,
+// a pause of AUTOHEAL_HARD_RESTART_PAUSE, then a start. Unlike docker restart
+// this gives the container's processes time to release file locks and other
+// resources before it comes back. A container that stops but will not start
+// again is left down, which is reported as [CRITICAL].
+func (g *Guardian) hardRestartUnhealthy(ctx context.Context, c container.Summary, name string, t actionTrigger, summary *scanSummary) string {
+	id := c.ID
+	shortID := id[:12]
+	timeout := g.stopTimeout(c.Labels)
+	pause := time.Duration(g.cfg().HardRestartPause) * time.Second
+	notify, healthSuffix := t.notify, t.healthSuffix
+
+	g.logFor(ctx).Infof("Container %s (%s) %s - Hard restarting container now (stop with %ds timeout, start after %s)", name, shortID, t.reason, timeout, pause)
+
+	start := time.Now()
+	defer func() {
+		metrics.RestartDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	}()
+	if err := g.docker.StopContainer(ctx, id, timeout); err != nil {
+		if g.containerGone(ctx, id, name, err) {
+			return "container-gone"
+		}
+		class, advance := g.actionFailed(name, "hardrestart", err)
+		g.logFor(ctx).Error("failed to stop container for hard restart", "container", name, "id", shortID, "class", class, "error", err)
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "hardrestart", "failure", fmt.Sprintf("Container %s (%s) %s. Failed to stop the container for a hard restart: %s!%s", name, shortID, t.reason, class.Describe(), healthSuffix)))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+		g.recordDecision(ctx, name, id, "hardrestart", "failure")
+		if advance {
+			g.tracker.RecordRestartFailure(id)
+			g.recordRestart(id, name)
+		}
+		return "failure"
+	}
+
+	if pause > 0 {
+		select {
+		case <-g.clock.After(pause):
+		case <-ctx.Done():
+			// Cut the pause short, but don't leave a container we stopped down
+			g.logFor(ctx).Infof("Container %s (%s) hard restart interrupted - starting it now", name, shortID)
+		}
+	}
+
+	// The start must go through even on shutdown
+	startCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), hardRestartStartTimeout)
+	defer cancel()
+	if err := g.docker.StartContainer(startCtx, id); err != nil {
+		if g.containerGone(startCtx, id, name, err) {
+			return "container-gone"
+		}
+		class, _ := g.actionFailed(name, "hardrestart", err)
+		g.logFor(ctx).Error("failed to start container after hard restart stop", "container", name, "id", shortID, "class", class, "error", err)
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "hardrestart", "failure", fmt.Sprintf("[CRITICAL] Container %s (%s) was stopped for a hard restart but failed to start: %s! The container is down.%s", name, shortID, class.Describe(), healthSuffix)))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+		g.recordDecision(ctx, name, id, "hardrestart", "failure")
+		// Counted whatever the error class: the container is down either way
+		g.tracker.RecordRestartFailure(id)
+		g.recordRestart(id, name)
+		return "failure"
+	}
+
+	if notify {
+		g.notifier.Action(g.decisionEvent(ctx, name, id, "hardrestart", "success", fmt.Sprintf("Container %s (%s) %s. Successfully hard-restarted the container (stop + start)!%s", name, shortID, t.reason, healthSuffix)))
+	}
+	metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+	g.logFor(ctx).Info("hard-restarted container", "container", name, "id", shortID)
+	g.recordDecision(ctx, name, id, "hardrestart", "success")
+	g.tracker.ResetRestartFailures(id)
+	g.resetUnhealthy(id, name)
+	g.watchCrashloop(ctx, id, name, notify)
+	summary.Restarted++
+
+	g.recordRestart(id, name)
+	g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: id, Action: "hardrestart", Result: "success", State: string(c.State), Timeout: timeout})
+	if t.siblings {
+		g.restartComposeSiblings(ctx, id, name, c.Labels)
+	}
+	g.startRecovery(ctx, c, name, notify)
+	return "success"
+}
@@ -0,0 +1,105 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func hardRestartContainer(id string) container.Summary {
+	return container.Summary{ID: id, Names: []string{"/locky"}, State: "running",
+		Labels: map[string]string{"autoheal.action": "hardrestart", "autoheal.stop.timeout": "20"}}
+}
+
+func TestHardRestart_StopsThenStarts(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, HardRestartPause: 5}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{hardRestartContainer(id)}
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	summary := g.checkUnhealthy(context.Background())
+
+	if want := []string{"stop " + id, "start " + id}; !slices.Equal(dock.lifecycleCalls, want) {
+		t.Fatalf("calls = %v, want %v", dock.lifecycleCalls, want)
+	}
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("hard restart should not use docker restart, got %v", dock.restartCalls)
+	}
+	if summary.Restarted != 1 {
+		t.Errorf("Restarted = %d, want 1", summary.Restarted)
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Successfully hard-restarted") {
+		t.Errorf("unexpected notifications: %v", notif.actions)
+	}
+}
+
+func TestHardRestart_StartsEvenWhenCancelledDuringPause(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, HardRestartPause: 5}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	id := "abcdef1234567890abcdef"
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	// A forced shutdown cancels the action once the container is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := g.hardRestartUnhealthy(ctx, hardRestartContainer(id), "locky", actionTrigger{reason: "found to be unhealthy", notify: true}, &scanSummary{})
+
+	if want := []string{"stop " + id, "start " + id}; result != "success" || !slices.Equal(dock.lifecycleCalls, want) {
+		t.Fatalf("result %q, calls = %v, want success after %v", result, dock.lifecycleCalls, want)
+	}
+	for _, a := range notif.actions {
+		if strings.Contains(a, "[CRITICAL]") {
+			t.Errorf("the container was started again, got %q", a)
+		}
+	}
+}
+
+func TestHardRestart_StartFailureIsCritical(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{hardRestartContainer(id)}
+	dock.startErr[id] = errors.New("port is already allocated")
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	g.checkUnhealthy(context.Background())
+
+	if want := []string{"stop " + id, "start " + id}; !slices.Equal(dock.lifecycleCalls, want) {
+		t.Fatalf("calls = %v, want %v", dock.lifecycleCalls, want)
+	}
+	if len(notif.actions) != 1 || !strings.HasPrefix(notif.actions[0], "[CRITICAL]") || !strings.Contains(notif.actions[0], "failed to start") {
+		t.Errorf("expected a critical start failure notification, got %v", notif.actions)
+	}
+	if g.tracker.RestartFailures(id) != 1 {
+		t.Errorf("RestartFailures = %d, want 1", g.tracker.RestartFailures(id))
+	}
+}
+
+func TestHardRestart_StopFailureSkipsStart(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{hardRestartContainer(id)}
+	dock.stopErr[id] = errors.New("cannot kill container")
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	g.checkUnhealthy(context.Background())
+
+	if want := []string{"stop " + id}; !slices.Equal(dock.lifecycleCalls, want) {
+		t.Fatalf("calls = %v, want %v", dock.lifecycleCalls, want)
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Failed to stop the container for a hard restart") {
+		t.Errorf("unexpected notifications: %v", notif.actions)
+	}
+}
@@ -0,0 +1,60 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// startHeartbeat sends a summary every HEARTBEAT_INTERVAL seconds so that
+// silence during quiet periods is not ambiguous.
+func (g *Guardian) startHeartbeat(ctx context.Context) {
+	if g.cfg().HeartbeatInterval <= 0 {
+		return
+	}
+	interval := time.Duration(g.cfg().HeartbeatInterval) * time.Second
+	go func() {
+		for {
+			select {
+			case <-g.clock.After(interval):
+				g.notifier.Heartbeat(g.heartbeatSummary(ctx))
+			case <-g.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// heartbeatSummary describes current state: "all good" only when nothing is
+// unhealthy, no circuit is open and actions are enabled.
+func (g *Guardian) heartbeatSummary(ctx context.Context) string {
+	monitored := g.monitoredCount(ctx)
+	unhealthy, err := g.docker.UnhealthyContainers(ctx, g.cfg().ContainerLabel, g.cfg().OnlyMonitorRunning)
+	if err != nil {
+		return fmt.Sprintf("Heartbeat: Docker daemon unreachable (%v)", err)
+	}
+
+	var notes []string
+	if n := len(unhealthy); n > 0 {
+		names := make([]string, 0, n)
+		for _, c := range unhealthy {
+			names = append(names, displayName(c.Names))
+		}
+		notes = append(notes, fmt.Sprintf("unhealthy: %s", strings.Join(names, ", ")))
+	}
+	if n := g.tracker.CircuitOpenCount(); n > 0 {
+		notes = append(notes, fmt.Sprintf("%d circuit(s) open", n))
+	}
+	if g.Disabled() {
+		notes = append(notes, "actions DISABLED")
+	}
+
+	msg := fmt.Sprintf("Heartbeat: %d monitored, %d unhealthy", monitored, len(unhealthy))
+	if len(notes) == 0 {
+		return msg + " - all good"
+	}
+	return msg + " - attention: " + strings.Join(notes, "; ")
+}
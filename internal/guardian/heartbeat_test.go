@@ -0,0 +1,95 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+// tickClock hands out a test-controlled channel from After.
+type tickClock struct {
+	*mockClock
+	mu    sync.Mutex
+	ticks chan time.Time
+	waits []time.Duration
+}
+
+func (c *tickClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	return c.ticks
+}
+
+func heartbeatGuardian() (*Guardian, *mockDocker, *mockNotifier) {
+	cfg := &config.Config{ContainerLabel: "all", HeartbeatInterval: 3600}
+	dock := newMockDocker()
+	dock.runningContainers = []container.Summary{
+		{ID: "aaaaaa1234567890abcdef", Names: []string{"/web"}},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/db"}},
+		{ID: "cccccc1234567890abcdef", Names: []string{"/ignored"}, Labels: map[string]string{"autoheal": "False"}},
+	}
+	notif := &mockNotifier{}
+	return newTestGuardian(cfg, dock, notif, newMockClock(time.Now())), dock, notif
+}
+
+func TestHeartbeat_FiresOnSchedule(t *testing.T) {
+	g, _, notif := heartbeatGuardian()
+	clk := &tickClock{mockClock: newMockClock(time.Now()), ticks: make(chan time.Time)}
+	g.clock = clk
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	g.startHeartbeat(ctx)
+
+	clk.ticks <- time.Now()
+	clk.ticks <- time.Now()
+	clk.ticks <- time.Now() // blocks until the second heartbeat has been sent
+
+	notif.mu.Lock()
+	defer notif.mu.Unlock()
+	if len(notif.heartbeats) < 2 {
+		t.Fatalf("expected at least 2 heartbeats, got %v", notif.heartbeats)
+	}
+	if want := "Heartbeat: 2 monitored, 0 unhealthy - all good"; notif.heartbeats[0] != want {
+		t.Errorf("got %q, want %q", notif.heartbeats[0], want)
+	}
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+	if clk.waits[0] != time.Hour {
+		t.Errorf("expected hourly schedule, got %v", clk.waits[0])
+	}
+}
+
+func TestHeartbeat_AnnotatesProblems(t *testing.T) {
+	g, dock, _ := heartbeatGuardian()
+	dock.unhealthyContainers = []container.Summary{{ID: "aaaaaa1234567890abcdef", Names: []string{"/web"}}}
+	g.SetDisabled(true, "test")
+
+	got := g.heartbeatSummary(context.Background())
+
+	if !strings.HasPrefix(got, "Heartbeat: 2 monitored, 1 unhealthy - attention:") {
+		t.Errorf("unexpected summary: %q", got)
+	}
+	if !strings.Contains(got, "unhealthy: web") || !strings.Contains(got, "actions DISABLED") {
+		t.Errorf("summary should name unhealthy containers and disabled state: %q", got)
+	}
+}
+
+func TestHeartbeat_DisabledByDefault(t *testing.T) {
+	g, _, _ := heartbeatGuardian()
+	g.cfg().HeartbeatInterval = 0
+	clk := &tickClock{mockClock: newMockClock(time.Now()), ticks: make(chan time.Time)}
+	g.clock = clk
+
+	g.startHeartbeat(context.Background())
+
+	if len(clk.waits) != 0 {
+		t.Error("heartbeat should not be scheduled without HEARTBEAT_INTERVAL")
+	}
+}
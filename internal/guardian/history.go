@@ -0,0 +1,156 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+)
+
+// Decision records a single action or skip taken for a container.
+type Decision struct {
+	Time          time.Time `json:"time"`
+	Container     string    `json:"container"`
+	ID            string    `json:"id"`
+	Action        string    `json:"action"` // restart, stop, start, service-update, notify, skip
+	Result        string    `json:"result"` // success, failure, or the skip reason
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// decisionLog is a fixed-size ring buffer of recent decisions.
+// A nil *decisionLog discards everything.
+type decisionLog struct {
+	mu   sync.Mutex
+	buf  []Decision
+	next int
+	full bool
+}
+
+// newDecisionLog returns a ring buffer holding the last size decisions,
+// or nil if size is 0.
+func newDecisionLog(size int) *decisionLog {
+	if size <= 0 {
+		return nil
+	}
+	return &decisionLog{buf: make([]Decision, size)}
+}
+
+// add stores d, returning the decision it evicted (if any).
+func (l *decisionLog) add(d Decision) (Decision, bool) {
+	if l == nil {
+		return Decision{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	evicted, ok := l.buf[l.next], l.full
+	l.buf[l.next] = d
+	l.next = (l.next + 1) % len(l.buf)
+	if l.next == 0 {
+		l.full = true
+	}
+	return evicted, ok
+}
+
+// recent returns the retained decisions, oldest first.
+func (l *decisionLog) recent() []Decision {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.full {
+		return append([]Decision(nil), l.buf[:l.next]...)
+	}
+	out := make([]Decision, 0, len(l.buf))
+	out = append(out, l.buf[l.next:]...)
+	return append(out, l.buf[:l.next]...)
+}
+
+// contains reports whether a retained decision has the given metric labels.
+func (l *decisionLog) contains(container, action, result string) bool {
+	for _, d := range l.recent() {
+		if d.Container == container && d.Action == action && d.Result == result {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDecision appends a decision to the history and mirrors it in the
+// docker_guardian_recent_decision info metric, dropping evicted label sets.
+// Successful restarts also advance the persisted lifetime count.
+func (g *Guardian) recordDecision(ctx context.Context, name, id, action, result string) {
+	if result == "success" && (action == "restart" || action == "hardrestart" || action == "service-update" || action == "recreate") {
+		g.countLifetimeRestart(name)
+	}
+	if g.history == nil {
+		return
+	}
+	d := Decision{
+		Time:          g.clock.Now(),
+		Container:     name,
+		ID:            shortContainerID(id),
+		Action:        action,
+		Result:        result,
+		CorrelationID: correlationID(ctx),
+	}
+	evicted, ok := g.history.add(d)
+	metrics.RecentDecision.WithLabelValues(d.Container, d.Action, d.Result).Set(float64(d.Time.Unix()))
+	if ok && !g.history.contains(evicted.Container, evicted.Action, evicted.Result) {
+		metrics.RecentDecision.DeleteLabelValues(evicted.Container, evicted.Action, evicted.Result)
+	}
+}
+
+// Decisions returns the recent decision history, oldest first.
+func (g *Guardian) Decisions() []Decision {
+	return g.history.recent()
+}
+
+// ContainerTimeline is the recent history of a single container.
+type ContainerTimeline struct {
+	Container string       `json:"container"`
+	ID        string       `json:"id"`
+	Decisions []Decision   `json:"decisions"`
+	Tracker   TrackerStats `json:"tracker"`
+}
+
+// ContainerTimeline returns the retained decisions for a container, matched by
+// name or ID prefix, oldest first, alongside its tracker state. Returns false
+// if the guardian knows nothing about the container.
+func (g *Guardian) ContainerTimeline(nameOrID string) (ContainerTimeline, bool) {
+	if nameOrID == "" {
+		return ContainerTimeline{}, false
+	}
+	idPrefix := shortContainerID(nameOrID)
+	timeline := ContainerTimeline{Decisions: []Decision{}}
+	for _, d := range g.Decisions() {
+		if d.Container == nameOrID || strings.HasPrefix(d.ID, idPrefix) {
+			timeline.Decisions = append(timeline.Decisions, d)
+			timeline.Container, timeline.ID = d.Container, d.ID
+		}
+	}
+	if timeline.ID != "" {
+		idPrefix = timeline.ID
+	}
+	stats, tracked := g.tracker.Stats(idPrefix)
+	if len(timeline.Decisions) == 0 && !tracked {
+		return ContainerTimeline{}, false
+	}
+	if timeline.ID == "" {
+		timeline.ID = idPrefix
+	}
+	timeline.Tracker = stats
+	return timeline, true
+}
+
+// shortContainerID truncates a container ID to the 12-character form.
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
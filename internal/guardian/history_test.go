@@ -0,0 +1,77 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+)
+
+func TestDecisionLog_RetainsMostRecent(t *testing.T) {
+	cfg := &config.Config{}
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+	g.history = newDecisionLog(3)
+
+	for i := range 5 {
+		g.recordDecision(context.Background(), fmt.Sprintf("app-%d", i), fmt.Sprintf("%022d", i), "restart", "success")
+	}
+
+	got := g.Decisions()
+	if len(got) != 3 {
+		t.Fatalf("expected 3 retained decisions, got %d", len(got))
+	}
+	for i, want := range []string{"app-2", "app-3", "app-4"} {
+		if got[i].Container != want {
+			t.Errorf("decision %d = %s, want %s (oldest first)", i, got[i].Container, want)
+		}
+	}
+}
+
+func TestDecisionLog_Disabled(t *testing.T) {
+	g := newTestGuardian(&config.Config{}, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+	g.recordDecision(context.Background(), "app", "abcdef1234567890", "restart", "success")
+	if got := g.Decisions(); len(got) != 0 {
+		t.Errorf("expected no history when disabled, got %v", got)
+	}
+}
+
+func TestContainerTimeline_FiltersInOrder(t *testing.T) {
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(&config.Config{}, newMockDocker(), &mockNotifier{}, clk)
+	g.history = newDecisionLog(10)
+	ctx := context.Background()
+
+	flappy := "abcdef1234567890abcdef"
+	g.recordDecision(ctx, "flappy", flappy, "skip", "grace")
+	g.recordDecision(ctx, "other", "bbbbbb1234567890abcdef", "restart", "success")
+	g.recordDecision(ctx, "flappy", flappy, "restart", "success")
+	g.tracker.RecordRestart(flappy)
+	g.recordDecision(ctx, "flappy", flappy, "verify", "recovered")
+
+	for _, key := range []string{"flappy", "abcdef123456", flappy} {
+		timeline, ok := g.ContainerTimeline(key)
+		if !ok {
+			t.Fatalf("%s: expected timeline", key)
+		}
+		var got []string
+		for _, d := range timeline.Decisions {
+			got = append(got, d.Action+"/"+d.Result)
+		}
+		want := []string{"skip/grace", "restart/success", "verify/recovered"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: decisions = %v, want %v", key, got, want)
+		}
+		if timeline.Container != "flappy" || timeline.ID != "abcdef123456" {
+			t.Errorf("%s: got container %s/%s", key, timeline.Container, timeline.ID)
+		}
+		if timeline.Tracker.RecentRestarts != 1 || timeline.Tracker.BackoffRemaining != 10 {
+			t.Errorf("%s: unexpected tracker stats %+v", key, timeline.Tracker)
+		}
+	}
+
+	if _, ok := g.ContainerTimeline("missing"); ok {
+		t.Error("expected no timeline for unknown container")
+	}
+}
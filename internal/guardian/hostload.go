@@ -0,0 +1,89 @@
+package guardian
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// hostLoad is a snapshot of host pressure.
+type hostLoad struct {
+	Load1          float64 // 1-minute load average
+	MemAvailableMB int     // MemAvailable from /proc/meminfo
+}
+
+// loadSource reads current host pressure. Implemented by procLoad in
+// production and replaced in tests.
+type loadSource interface {
+	Load() (hostLoad, error)
+}
+
+// procLoad reads load and memory from a procfs mount. /proc/loadavg and
+// /proc/meminfo are not namespaced, so inside a container they report the host.
+type procLoad struct {
+	root string
+}
+
+func (p procLoad) Load() (hostLoad, error) {
+	var l hostLoad
+
+	raw, err := os.ReadFile(filepath.Join(p.root, "loadavg"))
+	if err != nil {
+		return l, err
+	}
+	fields := strings.Fields(string(raw))
+	if len(fields) == 0 {
+		return l, fmt.Errorf("empty loadavg")
+	}
+	if l.Load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return l, fmt.Errorf("parse loadavg: %w", err)
+	}
+
+	f, err := os.Open(filepath.Join(p.root, "meminfo"))
+	if err != nil {
+		return l, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemAvailable:   12345678 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return l, fmt.Errorf("parse meminfo: %w", err)
+			}
+			l.MemAvailableMB = kb / 1024
+			return l, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return l, err
+	}
+	return l, fmt.Errorf("MemAvailable not found in meminfo")
+}
+
+// hostOverloaded reports whether the host is above AUTOHEAL_MAX_HOST_LOAD or
+// below AUTOHEAL_MIN_HOST_MEMORY_MB, with a description of which limit was
+// crossed. If host pressure can't be read the guard stays open.
+func (g *Guardian) hostOverloaded(ctx context.Context) (string, bool) {
+	if g.load == nil || (g.cfg().MaxHostLoad <= 0 && g.cfg().MinHostMemoryMB <= 0) {
+		return "", false
+	}
+	l, err := g.load.Load()
+	if err != nil {
+		g.logFor(ctx).Debug("failed to read host load", "error", err)
+		return "", false
+	}
+	if g.cfg().MaxHostLoad > 0 && l.Load1 > g.cfg().MaxHostLoad {
+		return fmt.Sprintf("load %.2f > %g", l.Load1, g.cfg().MaxHostLoad), true
+	}
+	if g.cfg().MinHostMemoryMB > 0 && l.MemAvailableMB < g.cfg().MinHostMemoryMB {
+		return fmt.Sprintf("%dMB available < %dMB", l.MemAvailableMB, g.cfg().MinHostMemoryMB), true
+	}
+	return "", false
+}
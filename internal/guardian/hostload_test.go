@@ -0,0 +1,81 @@
+package guardian
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+type fakeLoad struct {
+	load hostLoad
+	err  error
+}
+
+func (f fakeLoad) Load() (hostLoad, error) { return f.load, f.err }
+
+func overloadGuardian(load hostLoad) (*Guardian, *mockDocker) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, MaxHostLoad: 8, MinHostMemoryMB: 256}
+	dock := newMockDocker()
+	dock.unhealthyContainers = []container.Summary{{ID: "abcdef1234567890abcdef", Names: []string{"/app"}, State: "running"}}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	g.load = fakeLoad{load: load}
+	g.history = newDecisionLog(10)
+	return g, dock
+}
+
+func TestHostOverload_DefersAboveLoadThreshold(t *testing.T) {
+	g, dock := overloadGuardian(hostLoad{Load1: 12.5, MemAvailableMB: 4096})
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("expected restart deferred under high load, got %d restarts", len(dock.restartCalls))
+	}
+	if !g.history.contains("app", "skip", "host-overloaded") {
+		t.Error("expected host-overloaded skip decision")
+	}
+}
+
+func TestHostOverload_DefersBelowMemoryThreshold(t *testing.T) {
+	g, dock := overloadGuardian(hostLoad{Load1: 0.5, MemAvailableMB: 100})
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("expected restart deferred under memory pressure, got %d restarts", len(dock.restartCalls))
+	}
+}
+
+func TestHostOverload_ActsBelowThresholds(t *testing.T) {
+	g, dock := overloadGuardian(hostLoad{Load1: 2, MemAvailableMB: 4096})
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected restart on a healthy host, got %d restarts", len(dock.restartCalls))
+	}
+}
+
+func TestProcLoad_ReadsLoadAndMemory(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "loadavg"), []byte("3.42 2.10 1.05 2/345 6789\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meminfo := "MemTotal:       16384000 kB\nMemFree:          512000 kB\nMemAvailable:    2048000 kB\n"
+	if err := os.WriteFile(filepath.Join(root, "meminfo"), []byte(meminfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := procLoad{root: root}.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Load1 != 3.42 || l.MemAvailableMB != 2000 {
+		t.Errorf("got %+v, want load 3.42 and 2000MB", l)
+	}
+}
@@ -0,0 +1,60 @@
+package guardian
+
+import (
+	"context"
+	"time"
+)
+
+// imageSeen is the image a container was last seen running and when that
+// image last changed (zero until a change is observed).
+type imageSeen struct {
+	digest    string
+	changedAt time.Time
+}
+
+// recordImageDigests notes the image of every running container each full
+// scan, so an in-place update is noticed even if the container was healthy
+// until just after it. Only active with AUTOHEAL_WATCHTOWER_EVENTS=digest.
+func (g *Guardian) recordImageDigests(ctx context.Context) {
+	if g.cfg().WatchtowerCooldown <= 0 || g.cfg().WatchtowerEvents != "digest" {
+		return
+	}
+	running, err := g.docker.RunningContainers(ctx)
+	if err != nil {
+		return // checkDocker has already reported the daemon's state
+	}
+	for _, c := range running {
+		if len(c.Names) > 0 && c.ImageID != "" {
+			g.observeImageDigest(displayName(c.Names), c.ImageID)
+		}
+	}
+}
+
+// imageRecentlyChanged reports whether the container's image changed within
+// AUTOHEAL_WATCHTOWER_COOLDOWN. Containers are matched by name, which
+// survives the recreate an image update usually involves.
+func (g *Guardian) imageRecentlyChanged(ctx context.Context, id, name string) bool {
+	digest, err := g.docker.ContainerImageDigest(ctx, id)
+	if err != nil || digest == "" {
+		return false
+	}
+	changedAt := g.observeImageDigest(name, digest)
+	return !changedAt.IsZero() && g.clock.Since(changedAt) < time.Duration(g.cfg().WatchtowerCooldown)*time.Second
+}
+
+// observeImageDigest records the image a container runs and returns when it
+// last changed. The first sighting of a container is not a change.
+func (g *Guardian) observeImageDigest(name, digest string) time.Time {
+	g.imageMu.Lock()
+	defer g.imageMu.Unlock()
+	if g.imageDigests == nil {
+		g.imageDigests = make(map[string]imageSeen)
+	}
+	seen, ok := g.imageDigests[name]
+	if ok && seen.digest != digest {
+		seen.changedAt = g.clock.Now()
+	}
+	seen.digest = digest
+	g.imageDigests[name] = seen
+	return seen.changedAt
+}
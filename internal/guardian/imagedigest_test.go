@@ -0,0 +1,63 @@
+package guardian
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestShouldSkip_ImageDigestChange(t *testing.T) {
+	id := "abcdef1234567890abcdef"
+	for _, tc := range []struct {
+		mode string
+		want bool
+	}{
+		{"digest", true},
+		{"orchestration", false},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			cfg := &config.Config{WatchtowerCooldown: 300, WatchtowerScope: "affected", WatchtowerEvents: tc.mode}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+			clk := newMockClock(time.Now())
+			g := newTestGuardian(cfg, dock, notif, clk)
+
+			// Seen on the old image by a scan, then updated in place
+			dock.runningContainers = []container.Summary{{ID: id, Names: []string{"/app"}, ImageID: "sha256:old"}}
+			g.recordImageDigests(context.Background())
+			dock.imageDigests[id] = "sha256:new"
+
+			if got := g.shouldSkip(context.Background(), id, "/app", nil); got != tc.want {
+				t.Fatalf("shouldSkip after an image change = %v, want %v", got, tc.want)
+			}
+			if !tc.want {
+				return
+			}
+			if len(notif.skips) != 1 {
+				t.Errorf("expected 1 skip notification, got %d", len(notif.skips))
+			}
+
+			// Once the cooldown has passed the new image is simply current
+			clk.Advance(301 * time.Second)
+			g.invalidateOrchestratorCache()
+			if g.shouldSkip(context.Background(), id, "/app", nil) {
+				t.Error("should not skip once the cooldown has passed")
+			}
+		})
+	}
+}
+
+func TestShouldSkip_ImageDigestFirstSightingIsNotAChange(t *testing.T) {
+	cfg := &config.Config{WatchtowerCooldown: 300, WatchtowerScope: "affected", WatchtowerEvents: "digest"}
+	dock := newMockDocker()
+	id := "abcdef1234567890abcdef"
+	dock.imageDigests[id] = "sha256:new"
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+	if g.shouldSkip(context.Background(), id, "/app", nil) {
+		t.Error("a container never seen before should not count as updated")
+	}
+}
@@ -0,0 +1,52 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+)
+
+// maintenanceLabel marks a container as temporarily under maintenance. Unlike
+// autoheal.action=none (permanent opt-out) it expresses intent to come back.
+const maintenanceLabel = "autoheal.maintenance"
+
+// inMaintenance reports whether a container carries autoheal.maintenance=true
+// and, if so, records a "maintenance" skip. A skip notification is sent the
+// first time each container is seen under maintenance.
+func (g *Guardian) inMaintenance(ctx context.Context, id, name string, labels map[string]string) bool {
+	if v := labels[maintenanceLabel]; v != "true" && v != "True" {
+		return false
+	}
+	shortID := shortContainerID(id)
+	g.logFor(ctx).Infof("Container %s (%s) under maintenance (%s) - skipping", name, shortID, maintenanceLabel)
+	metrics.SkipsTotal.WithLabelValues(name, "maintenance").Inc()
+	g.recordDecision(ctx, name, id, "skip", "maintenance")
+
+	g.maintenanceMu.Lock()
+	first := !g.maintenanceSeen[id]
+	if first {
+		if g.maintenanceSeen == nil {
+			g.maintenanceSeen = make(map[string]bool)
+		}
+		g.maintenanceSeen[id] = true
+	}
+	g.maintenanceMu.Unlock()
+	if first {
+		g.notifier.Skip(g.decisionEvent(ctx, name, id, "skip", "maintenance", fmt.Sprintf("Container %s (%s) is under maintenance - the guardian will not act on it until %s is removed", name, shortID, maintenanceLabel)))
+	}
+	return true
+}
+
+// inMaintenanceWindow reports whether now falls inside one of the daily
+// AUTOHEAL_MAINTENANCE_WINDOWS, read in local time (TZ).
+func (g *Guardian) inMaintenanceWindow(now time.Time) bool {
+	local := now.In(time.Local)
+	for _, w := range g.cfg().MaintenanceSchedule() {
+		if w.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMaintenanceLabel_SkipsAndNotifiesOnce(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/db"}, State: "running", Labels: map[string]string{maintenanceLabel: "true"}},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/web"}, State: "running"},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	g.checkUnhealthy(context.Background())
+	g.tracker.Reset("bbbbbb1234567890abcdef")
+	g.checkUnhealthy(context.Background())
+
+	for _, id := range dock.restartCalls {
+		if id == "abcdef1234567890abcdef" {
+			t.Fatal("container under maintenance must not be restarted")
+		}
+	}
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("container without the label should still be restarted, got %v", dock.restartCalls)
+	}
+	if !g.history.contains("db", "skip", "maintenance") {
+		t.Error("expected a maintenance skip decision")
+	}
+	if len(notif.skips) != 1 || !strings.Contains(notif.skips[0], "under maintenance") {
+		t.Errorf("expected one maintenance notification across scans, got %v", notif.skips)
+	}
+}
+
+func TestMaintenanceLabel_ExemptsOrphanStart(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", MonitorDependencies: true}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+
+	parentID := "parent1234567890abcdef"
+	dock.exitedContainers = []container.Summary{{ID: "orphan01234567890abcdef"}}
+	dock.inspectResults["orphan01234567890abcdef"] = container.InspectResponse{
+		Name:       "/orphan-app",
+		HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + parentID)},
+		Config:     &container.Config{Labels: map[string]string{maintenanceLabel: "true"}},
+		State:      &container.State{ExitCode: 0},
+	}
+	dock.statusResults[parentID] = "running"
+	dock.statusResults["orphan01234567890abcdef"] = "exited"
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.checkDependencyOrphans(context.Background())
+
+	if len(dock.startCalls) != 0 {
+		t.Errorf("orphan under maintenance must not be started, got %v", dock.startCalls)
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 14, h, m, 0, 0, time.Local) }
+	tests := []struct {
+		name    string
+		windows []string
+		now     time.Time
+		want    bool
+	}{
+		{"inside", []string{"02:00-04:00"}, at(2, 30), true},
+		{"start is inclusive", []string{"02:00-04:00"}, at(2, 0), true},
+		{"end is exclusive", []string{"02:00-04:00"}, at(4, 0), false},
+		{"outside", []string{"02:00-04:00"}, at(12, 0), false},
+		{"wraps past midnight, before", []string{"23:00-01:30"}, at(23, 45), true},
+		{"wraps past midnight, after", []string{"23:00-01:30"}, at(0, 15), true},
+		{"wraps past midnight, outside", []string{"23:00-01:30"}, at(1, 30), false},
+		{"second of several", []string{"02:00-04:00", "12:00-12:30"}, at(12, 10), true},
+		{"none configured", nil, at(3, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGuardian(&config.Config{MaintenanceWindows: tt.windows}, newMockDocker(), &mockNotifier{}, newMockClock(tt.now))
+			if got := g.inMaintenanceWindow(tt.now); got != tt.want {
+				t.Errorf("inMaintenanceWindow(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_SuppressesRestarts(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, MaintenanceWindows: []string{"02:00-04:00"}}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Date(2026, 3, 14, 3, 0, 0, 0, time.Local))
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/backup-target"}, State: "running"}}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	before := testutil.ToFloat64(metrics.SkipsTotal.WithLabelValues("backup-target", "maintenance"))
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Fatalf("no restart expected inside the window, got %v", dock.restartCalls)
+	}
+	if len(notif.skips) != 1 || !strings.Contains(notif.skips[0], "maintenance window") {
+		t.Errorf("expected a maintenance window skip notification, got %v", notif.skips)
+	}
+	if got := testutil.ToFloat64(metrics.SkipsTotal.WithLabelValues("backup-target", "maintenance")) - before; got != 1 {
+		t.Errorf("maintenance skips +%v, want 1", got)
+	}
+
+	clk.Advance(time.Hour)
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected a restart once the window closed, got %v", dock.restartCalls)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/notify"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/events"
 )
@@ -24,15 +26,27 @@ type mockDocker struct {
 
 	inspectResults map[string]container.InspectResponse
 	inspectErr     map[string]error
+	inspectCalls   int
 
 	restartCalls []string
 	restartErr   map[string]error
+	restartGate  map[string]chan struct{} // restart blocks until the channel is closed
 
 	startCalls []string
 	startErr   map[string]error
 
-	stopCalls []string
-	stopErr   map[string]error
+	stopCalls   []string
+	stopSignals []string // signal passed to each StopContainerSignal call
+	stopErr     map[string]error
+
+	lifecycleCalls []string // "stop <id>" and "start <id>" in call order
+
+	removeCalls []string
+	removeErr   map[string]error
+
+	recreateCalls []string
+	recreateErr   map[string]error
+	recreateIDs   map[string]string // replacement ID returned with recreateErr
 
 	statusResults map[string]string
 	statusErr     map[string]error
@@ -40,11 +54,23 @@ type mockDocker struct {
 	finishedAtResults map[string]time.Time
 	finishedAtErr     map[string]error
 
+	imageDigests map[string]string
+
 	healthLogResults map[string]string
 	healthLogErr     map[string]error
 
-	containerEvents    []events.Message
-	containerEventsErr error
+	containerEvents      []events.Message
+	containerEventsErr   error
+	containerEventsCalls int
+
+	pingErr error
+
+	serviceUpdateCalls []string
+	serviceUpdateErr   map[string]error
+
+	nodeInfo  docker.NodeInfo
+	infoErr   error
+	infoCalls int
 }
 
 func newMockDocker() *mockDocker {
@@ -52,14 +78,19 @@ func newMockDocker() *mockDocker {
 		inspectResults:    make(map[string]container.InspectResponse),
 		inspectErr:        make(map[string]error),
 		restartErr:        make(map[string]error),
+		restartGate:       make(map[string]chan struct{}),
 		startErr:          make(map[string]error),
 		stopErr:           make(map[string]error),
+		removeErr:         make(map[string]error),
+		recreateErr:       make(map[string]error),
 		statusResults:     make(map[string]string),
 		statusErr:         make(map[string]error),
 		finishedAtResults: make(map[string]time.Time),
 		finishedAtErr:     make(map[string]error),
+		imageDigests:      make(map[string]string),
 		healthLogResults:  make(map[string]string),
 		healthLogErr:      make(map[string]error),
+		serviceUpdateErr:  make(map[string]error),
 	}
 }
 
@@ -76,6 +107,9 @@ func (m *mockDocker) RunningContainers(_ context.Context) ([]container.Summary,
 }
 
 func (m *mockDocker) InspectContainer(_ context.Context, id string) (container.InspectResponse, error) {
+	m.mu.Lock()
+	m.inspectCalls++
+	m.mu.Unlock()
 	if err, ok := m.inspectErr[id]; ok && err != nil {
 		return container.InspectResponse{}, err
 	}
@@ -85,17 +119,25 @@ func (m *mockDocker) InspectContainer(_ context.Context, id string) (container.I
 func (m *mockDocker) RestartContainer(_ context.Context, id string, _ int) error {
 	m.mu.Lock()
 	m.restartCalls = append(m.restartCalls, id)
+	gate := m.restartGate[id]
 	m.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
 	if err, ok := m.restartErr[id]; ok {
 		return err
 	}
 	return nil
 }
 
-func (m *mockDocker) StartContainer(_ context.Context, id string) error {
+func (m *mockDocker) StartContainer(ctx context.Context, id string) error {
 	m.mu.Lock()
 	m.startCalls = append(m.startCalls, id)
+	m.lifecycleCalls = append(m.lifecycleCalls, "start "+id)
 	m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if err, ok := m.startErr[id]; ok {
 		return err
 	}
@@ -105,6 +147,7 @@ func (m *mockDocker) StartContainer(_ context.Context, id string) error {
 func (m *mockDocker) StopContainer(_ context.Context, id string, _ int) error {
 	m.mu.Lock()
 	m.stopCalls = append(m.stopCalls, id)
+	m.lifecycleCalls = append(m.lifecycleCalls, "stop "+id)
 	m.mu.Unlock()
 	if err, ok := m.stopErr[id]; ok {
 		return err
@@ -112,6 +155,33 @@ func (m *mockDocker) StopContainer(_ context.Context, id string, _ int) error {
 	return nil
 }
 
+func (m *mockDocker) StopContainerSignal(ctx context.Context, id, signal string, timeout int) error {
+	m.mu.Lock()
+	m.stopSignals = append(m.stopSignals, signal)
+	m.mu.Unlock()
+	return m.StopContainer(ctx, id, timeout)
+}
+
+func (m *mockDocker) RemoveContainer(_ context.Context, id string) error {
+	m.mu.Lock()
+	m.removeCalls = append(m.removeCalls, id)
+	m.mu.Unlock()
+	if err, ok := m.removeErr[id]; ok {
+		return err
+	}
+	return nil
+}
+
+func (m *mockDocker) RecreateContainer(_ context.Context, id string, _ int) (string, error) {
+	m.mu.Lock()
+	m.recreateCalls = append(m.recreateCalls, id)
+	m.mu.Unlock()
+	if err, ok := m.recreateErr[id]; ok {
+		return m.recreateIDs[id], err
+	}
+	return "new-" + id, nil
+}
+
 func (m *mockDocker) ContainerStatus(_ context.Context, id string) (string, error) {
 	if err, ok := m.statusErr[id]; ok && err != nil {
 		return "", err
@@ -126,6 +196,12 @@ func (m *mockDocker) ContainerFinishedAt(_ context.Context, id string) (time.Tim
 	return m.finishedAtResults[id], nil
 }
 
+func (m *mockDocker) ContainerImageDigest(_ context.Context, id string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.imageDigests[id], nil
+}
+
 func (m *mockDocker) ContainerHealthLog(_ context.Context, id string) (string, error) {
 	if err, ok := m.healthLogErr[id]; ok && err != nil {
 		return "", err
@@ -134,18 +210,49 @@ func (m *mockDocker) ContainerHealthLog(_ context.Context, id string) (string, e
 }
 
 func (m *mockDocker) ContainerEvents(_ context.Context, _, _ time.Time, _ bool) ([]events.Message, error) {
+	m.mu.Lock()
+	m.containerEventsCalls++
+	m.mu.Unlock()
 	return m.containerEvents, m.containerEventsErr
 }
 
+func (m *mockDocker) Info(_ context.Context) (docker.NodeInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.infoCalls++
+	return m.nodeInfo, m.infoErr
+}
+
+func (m *mockDocker) Ping(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pingErr
+}
+
+func (m *mockDocker) ForceServiceUpdate(_ context.Context, serviceID string) error {
+	m.mu.Lock()
+	m.serviceUpdateCalls = append(m.serviceUpdateCalls, serviceID)
+	m.mu.Unlock()
+	if err, ok := m.serviceUpdateErr[serviceID]; ok {
+		return err
+	}
+	return nil
+}
+
 func (m *mockDocker) Close() error { return nil }
 
 // mockNotifier implements notify.Notifier for testing.
 type mockNotifier struct {
-	mu       sync.Mutex
-	startups []string
-	actions  []string
-	skips    []string
-	closed   bool
+	mu         sync.Mutex
+	startups   []string
+	heartbeats []string
+	actions    []string
+	skips      []string
+	recoveries []string
+	errors     []string
+	closed     bool
+
+	actionEvents []notify.Event
 }
 
 func (m *mockNotifier) Startup(text string) {
@@ -154,15 +261,34 @@ func (m *mockNotifier) Startup(text string) {
 	m.mu.Unlock()
 }
 
-func (m *mockNotifier) Action(text string) {
+func (m *mockNotifier) Heartbeat(text string) {
+	m.mu.Lock()
+	m.heartbeats = append(m.heartbeats, text)
+	m.mu.Unlock()
+}
+
+func (m *mockNotifier) Action(evt notify.Event) {
+	m.mu.Lock()
+	m.actions = append(m.actions, evt.Text)
+	m.actionEvents = append(m.actionEvents, evt)
+	m.mu.Unlock()
+}
+
+func (m *mockNotifier) Skip(evt notify.Event) {
+	m.mu.Lock()
+	m.skips = append(m.skips, evt.Text)
+	m.mu.Unlock()
+}
+
+func (m *mockNotifier) Recovery(evt notify.Event) {
 	m.mu.Lock()
-	m.actions = append(m.actions, text)
+	m.recoveries = append(m.recoveries, evt.Text)
 	m.mu.Unlock()
 }
 
-func (m *mockNotifier) Skip(text string) {
+func (m *mockNotifier) Error(text string) {
 	m.mu.Lock()
-	m.skips = append(m.skips, text)
+	m.errors = append(m.errors, text)
 	m.mu.Unlock()
 }
 
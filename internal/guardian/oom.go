@@ -0,0 +1,69 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+)
+
+// oomDieWindow is how soon after an oom event a die must follow to be
+// reported as an OOM kill. Docker sends the pair within milliseconds; an oom
+// for a process the container survives goes stale well before any later die.
+const oomDieWindow = 10 * time.Second
+
+// recordOOM notes an oom event so the die that follows can be reported as an
+// OOM kill rather than a plain exit. Stale entries are swept on each call.
+func (g *Guardian) recordOOM(evt docker.ContainerEvent) {
+	g.oomMu.Lock()
+	defer g.oomMu.Unlock()
+	if g.oomKills == nil {
+		g.oomKills = make(map[string]time.Time)
+	}
+	for id, at := range g.oomKills {
+		if evt.Timestamp.Sub(at) > oomDieWindow {
+			delete(g.oomKills, id)
+		}
+	}
+	g.oomKills[evt.ContainerID] = evt.Timestamp
+}
+
+// takeOOM reports whether an oom event for the container preceded a die at
+// the given time, clearing the record either way.
+func (g *Guardian) takeOOM(id string, diedAt time.Time) bool {
+	g.oomMu.Lock()
+	defer g.oomMu.Unlock()
+	at, ok := g.oomKills[id]
+	delete(g.oomKills, id)
+	return ok && diedAt.Sub(at) <= oomDieWindow
+}
+
+// reportOOMKill sends the OOM notification for a container whose die followed
+// an oom event. A container that is not monitored, opts out of
+// notifications, or is already gone is only logged.
+func (g *Guardian) reportOOMKill(ctx context.Context, evt docker.ContainerEvent) {
+	id := evt.ContainerID
+	name := evt.ContainerName
+	var labels map[string]string
+	info, err := g.docker.InspectContainer(ctx, id)
+	if err == nil {
+		if name == "" {
+			name = strings.TrimPrefix(info.Name, "/")
+		}
+		if info.Config != nil {
+			labels = info.Config.Labels
+		}
+	}
+	if name == "" {
+		name = shortContainerID(id)
+	}
+
+	msg := fmt.Sprintf("Container %s (%s) was killed by the OOM killer", name, shortContainerID(id))
+	g.logFor(ctx).Warn(msg)
+	if err != nil || !g.isMonitored(labels) || !shouldNotify(labels) {
+		return
+	}
+	g.notifier.Action(g.notifyEvent(ctx, name, id, msg))
+}
@@ -0,0 +1,78 @@
+package guardian
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+)
+
+// postRestart describes the action a POST_RESTART_SCRIPT run follows. With
+// POST_RESTART_SCRIPT_JSON it is passed as JSON on stdin; otherwise the
+// script gets name, short ID, state and timeout as arguments.
+type postRestart struct {
+	Container string    `json:"container"`
+	ShortID   string    `json:"id"`
+	ID        string    `json:"full_id"`
+	Action    string    `json:"action"` // restart, hardrestart, recreate, service-update, start
+	Result    string    `json:"result"` // success or failure
+	State     string    `json:"state"`
+	Timeout   int       `json:"timeout"`
+	ExitCode  int       `json:"exit_code"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// runPostRestartScript executes the POST_RESTART_SCRIPT if configured, in the
+// background. At most POST_RESTART_SCRIPT_MAX_CONCURRENT scripts run at once;
+// the rest wait their turn.
+func (g *Guardian) runPostRestartScript(run postRestart) {
+	cfg := g.cfg()
+	if cfg.PostRestartScript == "" || cfg.DryRun {
+		return
+	}
+	run.Timestamp = g.clock.Now()
+	go func() {
+		g.scriptSem <- struct{}{}
+		defer func() { <-g.scriptSem }()
+		if err := g.execPostRestartScript(cfg, run); err != nil {
+			g.log.Error("post-restart script failed", "container", run.Container, "error", err)
+		}
+	}()
+}
+
+// execPostRestartScript runs the script and waits for it. Once
+// POST_RESTART_SCRIPT_TIMEOUT passes its whole process group is killed, so
+// anything it started goes too.
+func (g *Guardian) execPostRestartScript(cfg *config.Config, run postRestart) error {
+	ctx := context.Background()
+	if cfg.PostRestartScriptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.PostRestartScriptTimeout)*time.Second)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if cfg.PostRestartScriptJSON {
+		payload, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		cmd = exec.CommandContext(ctx, cfg.PostRestartScript) //nolint:gosec // User-configured script path from POST_RESTART_SCRIPT env var
+		cmd.Stdin = bytes.NewReader(payload)
+	} else {
+		cmd = exec.CommandContext(ctx, cfg.PostRestartScript, run.Container, run.ShortID, run.State, fmt.Sprintf("%d", run.Timeout)) //nolint:gosec // User-configured script path from POST_RESTART_SCRIPT env var
+	}
+	killProcessGroup(cmd)
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		g.log.Warn("post-restart script killed after timeout", "container", run.Container, "timeout_seconds", cfg.PostRestartScriptTimeout)
+		return nil
+	}
+	return err
+}
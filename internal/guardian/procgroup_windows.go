@@ -0,0 +1,7 @@
+package guardian
+
+import "os/exec"
+
+// killProcessGroup leaves cmd as is: Windows has no process groups to
+// signal, so cancellation kills the direct child only.
+func killProcessGroup(_ *exec.Cmd) {}
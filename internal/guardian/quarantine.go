@@ -0,0 +1,109 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+)
+
+// recordQuarantine remembers that the guardian stopped a container, so
+// QUARANTINE_REMOVE_AFTER only ever removes containers it quarantined itself.
+func (g *Guardian) recordQuarantine(id string) {
+	if g.cfg().QuarantineRemoveAfter <= 0 {
+		return
+	}
+	g.quarantineMu.Lock()
+	defer g.quarantineMu.Unlock()
+	if g.quarantined == nil {
+		g.quarantined = make(map[string]time.Time)
+	}
+	g.quarantined[id] = g.clock.Now()
+	g.saveQuarantines()
+}
+
+// saveQuarantines persists the quarantine list. Caller holds quarantineMu.
+func (g *Guardian) saveQuarantines() {
+	if g.state == nil {
+		return
+	}
+	if err := g.state.setQuarantined(maps.Clone(g.quarantined)); err != nil {
+		g.log.Warn("failed to save state file", "path", g.cfg().StateFile, "error", err)
+	}
+}
+
+// removeExpiredQuarantines removes containers quarantined longer than
+// QUARANTINE_REMOVE_AFTER, notifying before each removal. A container that
+// has been started since the guardian stopped it is no longer the guardian's
+// quarantine and is forgotten rather than removed, so containers stopped by
+// anyone else are never touched.
+func (g *Guardian) removeExpiredQuarantines(ctx context.Context) {
+	if g.cfg().QuarantineRemoveAfter <= 0 || g.Disabled() {
+		return
+	}
+	retention := time.Duration(g.cfg().QuarantineRemoveAfter) * time.Second
+
+	g.quarantineMu.Lock()
+	defer g.quarantineMu.Unlock()
+	changed := false
+	for id, stoppedAt := range g.quarantined {
+		if g.clock.Since(stoppedAt) < retention {
+			continue
+		}
+		info, err := g.docker.InspectContainer(ctx, id)
+		if err != nil {
+			if docker.ClassifyError(err) == docker.ErrorNotFound {
+				delete(g.quarantined, id) // already removed by someone else
+				changed = true
+			}
+			continue
+		}
+		if info.State == nil || info.State.Running || startedSince(info.State.StartedAt, stoppedAt) {
+			delete(g.quarantined, id)
+			changed = true
+			continue
+		}
+
+		name := strings.TrimPrefix(info.Name, "/")
+		var labels map[string]string
+		if info.Config != nil {
+			labels = info.Config.Labels
+		}
+		ctx := withNotifyContext(ctx, labels)
+		if g.inMaintenance(ctx, id, name, labels) {
+			continue
+		}
+		shortID := shortContainerID(id)
+		age := g.clock.Since(stoppedAt).Round(time.Second)
+		g.logFor(ctx).Infof("Container %s (%s) quarantined %s ago - Removing container", name, shortID, age)
+		g.notifier.Action(g.notifyEvent(ctx, name, id, fmt.Sprintf("Container %s (%s) quarantined %s ago - removing it (QUARANTINE_REMOVE_AFTER)", name, shortID, age)))
+
+		delete(g.quarantined, id)
+		changed = true
+		if err := g.docker.RemoveContainer(ctx, id); err != nil {
+			class, _ := g.actionFailed(name, "remove", err)
+			g.logFor(ctx).Error("failed to remove container", "container", name, "id", shortID, "class", class, "error", err)
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "remove", "failure", fmt.Sprintf("Container %s (%s) quarantined. Failed to remove: %s!", name, shortID, class.Describe())))
+			g.recordDecision(ctx, name, id, "remove", "failure")
+			continue
+		}
+		g.logFor(ctx).Info("removed quarantined container", "container", name, "id", shortID)
+		g.recordDecision(ctx, name, id, "remove", "success")
+	}
+	if changed {
+		g.saveQuarantines()
+	}
+}
+
+// startedSince reports whether a container's StartedAt timestamp is after t.
+// An unparsable timestamp counts as started, erring towards not removing.
+func startedSince(startedAt string, t time.Time) bool {
+	started, err := time.Parse(time.RFC3339Nano, startedAt)
+	if err != nil {
+		return true
+	}
+	return started.After(t)
+}
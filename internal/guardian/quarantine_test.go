@@ -0,0 +1,120 @@
+package guardian
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+const quarantineID = "quarant1234567890abcdef"
+
+// quarantineGuardian stops (quarantines) one unhealthy container with
+// QUARANTINE_REMOVE_AFTER=3600 and leaves it exited.
+func quarantineGuardian(t *testing.T) (*Guardian, *mockDocker, *mockNotifier, *mockClock) {
+	t.Helper()
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, QuarantineRemoveAfter: 3600}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: quarantineID, Names: []string{"/broken"}, State: "running", Labels: map[string]string{"autoheal.action": "stop"}},
+	}
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	g.checkUnhealthy(context.Background())
+	if len(dock.stopCalls) != 1 {
+		t.Fatalf("expected the container to be quarantined, got stops %v", dock.stopCalls)
+	}
+
+	dock.unhealthyContainers = nil
+	dock.inspectResults[quarantineID] = container.InspectResponse{
+		Name:  "/broken",
+		State: &container.State{Status: "exited", StartedAt: clk.Now().Add(-time.Hour).Format(time.RFC3339Nano)},
+	}
+	return g, dock, notif, clk
+}
+
+func TestRemoveExpiredQuarantines_RemovesAfterRetention(t *testing.T) {
+	g, dock, notif, clk := quarantineGuardian(t)
+
+	clk.Advance(3601 * time.Second)
+	g.removeExpiredQuarantines(context.Background())
+
+	if len(dock.removeCalls) != 1 || dock.removeCalls[0] != quarantineID {
+		t.Fatalf("expected quarantined container removed, got %v", dock.removeCalls)
+	}
+	if last := notif.actions[len(notif.actions)-1]; !strings.Contains(last, "removing it") {
+		t.Errorf("expected a notification before removal, got %q", last)
+	}
+	if !g.history.contains("broken", "remove", "success") {
+		t.Error("expected remove decision")
+	}
+
+	g.removeExpiredQuarantines(context.Background())
+	if len(dock.removeCalls) != 1 {
+		t.Errorf("container should only be removed once, got %v", dock.removeCalls)
+	}
+}
+
+func TestRemoveExpiredQuarantines_KeepsBeforeRetention(t *testing.T) {
+	g, dock, _, clk := quarantineGuardian(t)
+
+	clk.Advance(30 * time.Minute)
+	g.removeExpiredQuarantines(context.Background())
+
+	if len(dock.removeCalls) != 0 {
+		t.Errorf("container inside retention should be kept, got %v", dock.removeCalls)
+	}
+}
+
+func TestRemoveExpiredQuarantines_NeverRemovesExternallyStopped(t *testing.T) {
+	g, dock, _, clk := quarantineGuardian(t)
+
+	// Someone started the quarantined container again and stopped it themselves
+	dock.inspectResults[quarantineID] = container.InspectResponse{
+		Name:  "/broken",
+		State: &container.State{Status: "exited", StartedAt: clk.Now().Add(time.Minute).Format(time.RFC3339Nano)},
+	}
+	// A container the guardian never stopped
+	dock.exitedContainers = []container.Summary{{ID: "manual01234567890abcdef", Names: []string{"/manual"}, State: "exited"}}
+	dock.inspectResults["manual01234567890abcdef"] = container.InspectResponse{
+		Name:  "/manual",
+		State: &container.State{Status: "exited", StartedAt: clk.Now().Add(-time.Hour).Format(time.RFC3339Nano)},
+	}
+
+	clk.Advance(3601 * time.Second)
+	g.removeExpiredQuarantines(context.Background())
+
+	if len(dock.removeCalls) != 0 {
+		t.Errorf("externally stopped containers must never be removed, got %v", dock.removeCalls)
+	}
+	if len(g.quarantined) != 0 {
+		t.Errorf("restarted container should no longer be tracked, got %v", g.quarantined)
+	}
+}
+
+func TestQuarantines_SurviveReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	state, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState on missing file: %v", err)
+	}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(&config.Config{QuarantineRemoveAfter: 3600, StateFile: path}, newMockDocker(), &mockNotifier{}, clk)
+	g.state = state
+	g.recordQuarantine(quarantineID)
+
+	reloaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if at, ok := reloaded.quarantined()[quarantineID]; !ok || !at.Equal(clk.Now()) {
+		t.Errorf("expected quarantine time to persist, got %v", reloaded.quarantined())
+	}
+}
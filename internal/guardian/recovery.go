@@ -0,0 +1,173 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+)
+
+// verifyPollInterval is how often health is re-checked during VERIFY_TIMEOUT.
+const verifyPollInterval = 2 * time.Second
+
+// recovering reports whether a recovery loop is running for the container.
+func (g *Guardian) recovering(id string) bool {
+	g.inflightMu.Lock()
+	defer g.inflightMu.Unlock()
+	return g.inflight[id]
+}
+
+// claimRecovery marks a container as owned by a recovery loop. Returns false
+// if one is already running.
+func (g *Guardian) claimRecovery(id string) bool {
+	g.inflightMu.Lock()
+	defer g.inflightMu.Unlock()
+	if g.inflight[id] {
+		return false
+	}
+	if g.inflight == nil {
+		g.inflight = make(map[string]bool)
+	}
+	g.inflight[id] = true
+	return true
+}
+
+func (g *Guardian) releaseRecovery(id string) {
+	g.inflightMu.Lock()
+	delete(g.inflight, id)
+	g.inflightMu.Unlock()
+}
+
+// startRecovery follows a successful restart with an explicit recovery loop:
+// wait up to VERIFY_TIMEOUT for the container to report healthy and, if it
+// doesn't, restart again up to VERIFY_MAX_RESTARTS times (within the restart
+// budget and backoff) before giving up and escalating. While the loop runs,
+// other detections of the same container are ignored.
+func (g *Guardian) startRecovery(ctx context.Context, c container.Summary, name string, notify bool) {
+	if g.cfg().VerifyTimeout <= 0 || !g.claimRecovery(c.ID) {
+		return
+	}
+	g.verifying.Add(1)
+	go func() {
+		defer g.verifying.Done()
+		defer g.releaseRecovery(c.ID)
+		g.recover(ctx, c, name, notify)
+	}()
+}
+
+func (g *Guardian) recover(ctx context.Context, c container.Summary, name string, notify bool) {
+	id := c.ID
+	shortID := id[:12]
+	timeout := g.stopTimeout(c.Labels)
+
+	for restarts := 1; ; restarts++ {
+		healthy, err := g.waitHealthy(ctx, id)
+		if err != nil {
+			return
+		}
+		if healthy {
+			g.logFor(ctx).Infof("Container %s (%s) healthy again after %d restart(s)", name, shortID, restarts)
+			g.recordDecision(ctx, name, id, "verify", "recovered")
+			g.tracker.ResetBackoff(id)
+			if restarts > 1 && notify {
+				g.notifier.Action(g.decisionEvent(ctx, name, id, "verify", "recovered", fmt.Sprintf("Container %s (%s) recovered after %d restarts", name, shortID, restarts)))
+			}
+			return
+		}
+		if g.recoveryHalted(ctx, id, name, c.Labels) {
+			return
+		}
+
+		if restarts > g.cfg().VerifyMaxRestarts {
+			g.giveUpRecovery(ctx, id, name, fmt.Sprintf("still unhealthy after %d restarts", restarts), notify)
+			return
+		}
+
+		allowed, reason := g.tracker.ShouldRestart(id)
+		if !allowed && reason == SkipBackoff {
+			select {
+			case <-g.clock.After(g.tracker.BackoffRemaining(id)):
+			case <-ctx.Done():
+				return
+			}
+			if g.recoveryHalted(ctx, id, name, c.Labels) {
+				return
+			}
+			allowed, reason = g.tracker.ShouldRestart(id)
+		}
+		if !allowed {
+			g.giveUpRecovery(ctx, id, name, g.tracker.FormatSkipReason(id, name, reason), notify)
+			return
+		}
+
+		g.logFor(ctx).Infof("Container %s (%s) not healthy within %ds - Restarting again (%d/%d)", name, shortID, g.cfg().VerifyTimeout, restarts, g.cfg().VerifyMaxRestarts)
+		err = g.docker.RestartContainer(ctx, id, timeout)
+		if err != nil && g.containerGone(ctx, id, name, err) {
+			return
+		}
+		g.recordRestart(id, name)
+		if err != nil {
+			g.logFor(ctx).Error("failed to restart container", "container", name, "id", shortID, "error", err)
+			g.tracker.RecordRestartFailure(id)
+			g.recordDecision(ctx, name, id, "restart", "failure")
+			g.giveUpRecovery(ctx, id, name, "restart failed: "+err.Error(), notify)
+			return
+		}
+		g.recordDecision(ctx, name, id, "restart", "success")
+	}
+}
+
+// recoveryHalted reports whether the panic button or maintenance now rules out
+// another restart. The recovery loop then ends quietly rather than escalating:
+// the container is being left alone on purpose, not failing to recover.
+func (g *Guardian) recoveryHalted(ctx context.Context, id, name string, labels map[string]string) bool {
+	if g.Disabled() {
+		g.logFor(ctx).Infof("Container %s (%s) not healthy yet - actions disabled, ending recovery", name, id[:12])
+		metrics.SkipsTotal.WithLabelValues(name, "disabled").Inc()
+		return true
+	}
+	if g.inMaintenance(ctx, id, name, labels) {
+		return true
+	}
+	if g.inMaintenanceWindow(g.clock.Now()) {
+		g.logFor(ctx).Infof("Container %s (%s) not healthy yet - inside a maintenance window, ending recovery", name, id[:12])
+		metrics.SkipsTotal.WithLabelValues(name, "maintenance").Inc()
+		g.recordDecision(ctx, name, id, "skip", "maintenance")
+		return true
+	}
+	return false
+}
+
+// waitHealthy polls the container's health for up to VERIFY_TIMEOUT. A
+// non-nil error means the context was cancelled.
+func (g *Guardian) waitHealthy(ctx context.Context, id string) (bool, error) {
+	limit := time.Duration(g.cfg().VerifyTimeout) * time.Second
+	for waited := time.Duration(0); waited < limit; {
+		step := min(verifyPollInterval, limit-waited)
+		select {
+		case <-g.clock.After(step):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+		waited += step
+
+		info, err := g.docker.InspectContainer(ctx, id)
+		if err == nil && info.State != nil && info.State.Health != nil &&
+			info.State.Health.Status == container.Healthy {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// giveUpRecovery ends a recovery loop that could not bring the container back.
+func (g *Guardian) giveUpRecovery(ctx context.Context, id, name, why string, notify bool) {
+	shortID := id[:12]
+	g.logFor(ctx).Warnf("Container %s (%s) did not recover (%s) - giving up", name, shortID, why)
+	g.recordDecision(ctx, name, id, "verify", "gave-up")
+	if notify {
+		g.notifier.Action(g.decisionEvent(ctx, name, id, "verify", "gave-up", fmt.Sprintf("[CRITICAL] Container %s (%s) did not recover: %s", name, shortID, why)))
+	}
+}
@@ -0,0 +1,158 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+)
+
+// recoveryGuardian becomes healthy once it has been restarted healthyAfter
+// times (0 = never). The clock advances on every wait so backoff elapses.
+func recoveryGuardian(healthyAfter int) (*Guardian, *mockDocker, *mockNotifier) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, VerifyTimeout: 10, VerifyMaxRestarts: 2}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/sticky-app"}, State: "running"}}
+	setHealth := func(status container.HealthStatus) {
+		dock.inspectResults[id] = container.InspectResponse{State: &container.State{Health: &container.Health{Status: status}}}
+	}
+	setHealth(container.Unhealthy)
+
+	clk := &hookClock{mockClock: newMockClock(time.Now())}
+	clk.onAfter = func(d time.Duration) {
+		clk.Advance(d)
+		dock.mu.Lock()
+		restarts := len(dock.restartCalls)
+		dock.mu.Unlock()
+		if healthyAfter > 0 && restarts >= healthyAfter {
+			setHealth(container.Healthy)
+		}
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk.mockClock)
+	g.clock = clk
+	g.tracker.clock = clk
+	g.history = newDecisionLog(20)
+	return g, dock, notif
+}
+
+func TestRecovery_RecoversOnFirstTry(t *testing.T) {
+	g, dock, notif := recoveryGuardian(1)
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected a single restart, got %d", len(dock.restartCalls))
+	}
+	if !g.history.contains("sticky-app", "verify", "recovered") {
+		t.Error("expected recovered decision")
+	}
+	if len(notif.actions) != 1 {
+		t.Errorf("expected only the restart notification, got %v", notif.actions)
+	}
+}
+
+func TestRecovery_RecoversOnSecondTry(t *testing.T) {
+	g, dock, notif := recoveryGuardian(2)
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("expected 2 restarts, got %d", len(dock.restartCalls))
+	}
+	if !g.history.contains("sticky-app", "verify", "recovered") {
+		t.Error("expected recovered decision")
+	}
+	last := notif.actions[len(notif.actions)-1]
+	if !strings.Contains(last, "recovered after 2 restarts") {
+		t.Errorf("unexpected final notification: %q", last)
+	}
+}
+
+func TestRecovery_RecoveredClearsBackoffOnly(t *testing.T) {
+	g, _, _ := recoveryGuardian(2)
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	id := "abcdef1234567890abcdef"
+	if remaining := g.tracker.BackoffRemaining(id); remaining != 0 {
+		t.Errorf("expected backoff cleared on verified recovery, got %v", remaining)
+	}
+	stats, ok := g.tracker.Stats(id)
+	if !ok || stats.RecentRestarts != 2 {
+		t.Errorf("expected both restarts kept for the budget, got %+v", stats)
+	}
+}
+
+func TestRecovery_GivesUpAndEscalates(t *testing.T) {
+	g, dock, notif := recoveryGuardian(0)
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	// Initial restart plus VERIFY_MAX_RESTARTS more
+	if len(dock.restartCalls) != 3 {
+		t.Errorf("expected 3 restarts, got %d", len(dock.restartCalls))
+	}
+	if !g.history.contains("sticky-app", "verify", "gave-up") {
+		t.Error("expected gave-up decision")
+	}
+	last := notif.actions[len(notif.actions)-1]
+	if !strings.HasPrefix(last, "[CRITICAL] Container sticky-app") || !strings.Contains(last, "still unhealthy after 3 restarts") {
+		t.Errorf("unexpected escalation: %q", last)
+	}
+}
+
+func TestRecovery_StopsWhenDisabledMidLoop(t *testing.T) {
+	g, dock, notif := recoveryGuardian(0)
+	clk := g.clock.(*hookClock)
+	advance := clk.onAfter
+	clk.onAfter = func(d time.Duration) {
+		advance(d)
+		g.disabled.Store(true) // POST /disable while the loop waits for health
+	}
+
+	g.checkUnhealthy(context.Background())
+	g.verifying.Wait()
+
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected no restarts after disabling, got %d in total", len(dock.restartCalls))
+	}
+	if g.history.contains("sticky-app", "verify", "gave-up") {
+		t.Error("a halted recovery should not be reported as given up")
+	}
+	for _, a := range notif.actions {
+		if strings.HasPrefix(a, "[CRITICAL]") {
+			t.Errorf("unexpected escalation: %q", a)
+		}
+	}
+}
+
+func TestRecovery_DedupsDetectionsWhileInFlight(t *testing.T) {
+	g, dock, _ := recoveryGuardian(1)
+	id := dock.unhealthyContainers[0].ID
+
+	if !g.claimRecovery(id) {
+		t.Fatal("first claim should succeed")
+	}
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("detection during recovery should not restart, got %d", len(dock.restartCalls))
+	}
+	if g.claimRecovery(id) {
+		t.Error("second claim should fail while in flight")
+	}
+	g.releaseRecovery(id)
+	if g.recovering(id) {
+		t.Error("release should clear in-flight state")
+	}
+}
@@ -0,0 +1,91 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+)
+
+// shouldRecreate reports whether a container has failed to restart often
+// enough (AUTOHEAL_RECREATE_AFTER_FAILURES) to escalate to a recreate.
+func (g *Guardian) shouldRecreate(id string) bool {
+	return g.cfg().RecreateAfterFailures > 0 && g.tracker.RestartFailures(id) >= g.cfg().RecreateAfterFailures
+}
+
+// recreateUnhealthy replaces an unhealthy container with a fresh one built
+// from the same configuration, either because autoheal.action=recreate asks
+// for it or, when escalated is set, because its restarts keep failing. A
+// corrupted container state is often only fixed this way.
+func (g *Guardian) recreateUnhealthy(ctx context.Context, c container.Summary, name string, t actionTrigger, escalated bool, summary *scanSummary) string {
+	id := c.ID
+	shortID := id[:12]
+	timeout := g.stopTimeout(c.Labels)
+	failures := g.tracker.RestartFailures(id)
+	notify, healthSuffix := t.notify, t.healthSuffix
+
+	failedText, doneText := "Failed to recreate the container!", "Successfully recreated the container!"
+	if escalated {
+		g.logFor(ctx).Infof("Container %s (%s) failed to restart %d times - Recreating container now", name, shortID, failures)
+		failedText = fmt.Sprintf("Failed to recreate the container after %d failed restarts!", failures)
+		doneText = fmt.Sprintf("Recreated the container after %d failed restarts!", failures)
+	} else {
+		g.logFor(ctx).Infof("Container %s (%s) %s - Recreating container now with %ds timeout (action=recreate)", name, shortID, t.reason, timeout)
+	}
+
+	start := time.Now()
+	newID, err := g.docker.RecreateContainer(ctx, id, timeout)
+	metrics.RestartDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	if err != nil {
+		g.logFor(ctx).Error("failed to recreate container", "container", name, "id", shortID, "new_id", shortContainerID(newID), "error", err)
+		removed := errors.Is(err, docker.ErrOriginalRemoved)
+		if notify {
+			text := fmt.Sprintf("Container %s (%s) %s. %s%s", name, shortID, t.reason, failedText, healthSuffix)
+			if removed {
+				step := "be created"
+				if newID != "" {
+					step = "start"
+				}
+				text = fmt.Sprintf("[CRITICAL] Container %s (%s) was removed for a recreate but its replacement failed to %s! The container is down.%s", name, shortID, step, healthSuffix)
+			}
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "recreate", "failure", text))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+		g.recordDecision(ctx, name, id, "recreate", "failure")
+		switch {
+		case removed && newID != "":
+			// The old ID is gone; later checks see the replacement
+			g.tracker.Move(id, newID)
+			id = newID
+		case removed:
+			// Nothing is left to track until the container is recreated
+			g.resetTracking(id, name)
+			return "failure"
+		}
+		g.tracker.RecordRestartFailure(id)
+		g.recordRestart(id, name)
+		return "failure"
+	}
+
+	if notify {
+		g.notifier.Action(g.decisionEvent(ctx, name, id, "recreate", "success", fmt.Sprintf("Container %s (%s) %s. %s%s", name, shortID, t.reason, doneText, healthSuffix)))
+	}
+	metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+	g.logFor(ctx).Info("recreated container", "container", name, "id", shortID, "new_id", shortContainerID(newID))
+	g.recordDecision(ctx, name, id, "recreate", "success")
+	summary.Restarted++
+
+	// The old ID is gone; carry the backoff and budget over to the
+	// replacement so a container recreated over and over still trips the
+	// circuit breaker. Only the failure streak starts again.
+	g.tracker.Move(id, newID)
+	g.tracker.ResetRestartFailures(newID)
+	g.resetUnhealthy(newID, name)
+	g.recordRestart(newID, name)
+	g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: newID, Action: "recreate", Result: "success", State: string(c.State), Timeout: timeout})
+	return "success"
+}
@@ -0,0 +1,216 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/moby/moby/api/types/container"
+)
+
+func TestRecreate_EscalatesAfterRestartFailures(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, RecreateAfterFailures: 2}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/corrupt-app"}, State: "running"}}
+	dock.restartErr[id] = errors.New("container state corrupted")
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	for i := 0; i < 2; i++ {
+		g.checkUnhealthy(context.Background())
+		clk.Advance(time.Hour) // clear backoff between scans
+	}
+	if len(dock.restartCalls) != 2 || len(dock.recreateCalls) != 0 {
+		t.Fatalf("expected 2 restarts and no recreate yet, got %d/%d", len(dock.restartCalls), len(dock.recreateCalls))
+	}
+
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("should not restart again once escalated, got %d restarts", len(dock.restartCalls))
+	}
+	if len(dock.recreateCalls) != 1 || dock.recreateCalls[0] != id {
+		t.Fatalf("expected recreate of %s, got %v", id, dock.recreateCalls)
+	}
+	last := notif.actions[len(notif.actions)-1]
+	if !strings.Contains(last, "Recreated the container after 2 failed restarts") {
+		t.Errorf("unexpected notification: %q", last)
+	}
+}
+
+func TestRecreate_SuccessfulRestartResetsFailures(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, RecreateAfterFailures: 2}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/flaky-app"}, State: "running"}}
+	dock.restartErr[id] = errors.New("transient")
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	g.checkUnhealthy(context.Background())
+	clk.Advance(time.Hour)
+	delete(dock.restartErr, id)
+	g.checkUnhealthy(context.Background())
+	clk.Advance(time.Hour)
+	dock.restartErr[id] = errors.New("transient")
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.recreateCalls) != 0 {
+		t.Errorf("failures are not consecutive, expected no recreate, got %v", dock.recreateCalls)
+	}
+	if got := g.tracker.RestartFailures(id); got != 1 {
+		t.Errorf("expected failure count 1 after reset, got %d", got)
+	}
+}
+
+func TestRecreate_DisabledByDefault(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/corrupt-app"}, State: "running"}}
+	dock.restartErr[id] = errors.New("container state corrupted")
+
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	for i := 0; i < 4; i++ {
+		g.checkUnhealthy(context.Background())
+		clk.Advance(time.Hour)
+	}
+	if len(dock.recreateCalls) != 0 {
+		t.Errorf("expected no recreate when disabled, got %v", dock.recreateCalls)
+	}
+}
+
+func TestRecreate_ActionLabel(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{
+		ID: id, Names: []string{"/wedged-app"}, State: "running",
+		Labels: map[string]string{"autoheal.action": "recreate"},
+	}}
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("action=recreate should not restart, got %v", dock.restartCalls)
+	}
+	if len(dock.recreateCalls) != 1 || dock.recreateCalls[0] != id {
+		t.Fatalf("expected recreate of %s, got %v", id, dock.recreateCalls)
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Successfully recreated the container") {
+		t.Errorf("unexpected notifications: %v", notif.actions)
+	}
+}
+
+func TestRecreate_ReplacementKeepsRestartBudget(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.tracker = NewRestartTracker(TrackerConfig{BackoffMultiplier: 2, BackoffMax: time.Second, RestartBudget: 2, RestartWindow: time.Hour}, clk)
+
+	id := "abcdef1234567890abcdef"
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			id = "new-" + id // the mock's replacement ID
+		}
+		dock.unhealthyContainers = []container.Summary{{
+			ID: id, Names: []string{"/wedged-app"}, State: "running",
+			Labels: map[string]string{"autoheal.action": "recreate"},
+		}}
+		g.checkUnhealthy(context.Background())
+		clk.Advance(2 * time.Second) // clear backoff between scans
+	}
+
+	if len(dock.recreateCalls) != 2 {
+		t.Errorf("expected the budget of 2 to stop the third recreate, got %d recreates", len(dock.recreateCalls))
+	}
+	if !g.tracker.IsCircuitOpen(id) {
+		t.Error("expected the circuit to open for the repeatedly recreated container")
+	}
+}
+
+func TestRecreate_ActionLabelFailure(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{
+		ID: id, Names: []string{"/wedged-app"}, State: "running",
+		Labels: map[string]string{"autoheal.action": "recreate"},
+	}}
+	dock.recreateErr[id] = errors.New("image not found")
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	g.checkUnhealthy(context.Background())
+
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Failed to recreate the container!") {
+		t.Errorf("unexpected notifications: %v", notif.actions)
+	}
+	if got := g.tracker.RestartFailures(id); got != 1 {
+		t.Errorf("expected the failed recreate to count as a restart failure, got %d", got)
+	}
+}
+
+func TestRecreate_FailureAfterRemove(t *testing.T) {
+	for _, tt := range []struct {
+		name, newID, step string
+	}{
+		{"create fails", "", "failed to be created"},
+		{"start fails", "fedcba9876543210fedcba", "failed to start"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+
+			id := "abcdef1234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{{
+				ID: id, Names: []string{"/wedged-app"}, State: "running",
+				Labels: map[string]string{"autoheal.action": "recreate"},
+			}}
+			dock.recreateErr[id] = fmt.Errorf("%w: %w", docker.ErrOriginalRemoved, errors.New("port already allocated"))
+			if tt.newID != "" {
+				dock.recreateIDs = map[string]string{id: tt.newID}
+			}
+
+			g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+			g.tracker.RecordRestartFailure(id)
+			g.checkUnhealthy(context.Background())
+
+			if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "[CRITICAL]") ||
+				!strings.Contains(notif.actions[0], tt.step) || !strings.Contains(notif.actions[0], "The container is down") {
+				t.Errorf("unexpected notifications: %v", notif.actions)
+			}
+			if _, ok := g.tracker.Stats(id); ok {
+				t.Error("the removed container should no longer be tracked")
+			}
+			if tt.newID == "" {
+				return
+			}
+			if got := g.tracker.RestartFailures(tt.newID); got != 2 {
+				t.Errorf("expected the failure count to move to the replacement and grow to 2, got %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package guardian
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+)
+
+// replayStartupEvents catches up on health events from the
+// AUTOHEAL_STARTUP_LOOKBACK window before the guardian started. Containers
+// whose last health event in the window was unhealthy, and which the live
+// scan did not already handle, are re-inspected and handled if they are
+// still unhealthy or were left exited (e.g. crashed while unhealthy).
+func (g *Guardian) replayStartupEvents(ctx context.Context, summary *scanSummary) {
+	if g.cfg().StartupLookback <= 0 {
+		return
+	}
+
+	now := g.clock.Now()
+	since := now.Add(-time.Duration(g.cfg().StartupLookback) * time.Second)
+	msgs, err := g.docker.ContainerEvents(ctx, since, now, false)
+	if err != nil {
+		g.log.Error("failed to fetch startup lookback events", "error", err)
+		return
+	}
+
+	// Latest health state per container within the window, in first-seen order
+	unhealthy := make(map[string]bool)
+	var order []string
+	for _, m := range msgs {
+		id := m.Actor.ID
+		switch strings.TrimSpace(string(m.Action)) {
+		case "health_status: unhealthy":
+			if _, ok := unhealthy[id]; !ok {
+				order = append(order, id)
+			}
+			unhealthy[id] = true
+		case "health_status: healthy":
+			unhealthy[id] = false
+		}
+	}
+
+	for _, id := range order {
+		if !unhealthy[id] || summary.Seen[id] {
+			continue
+		}
+
+		info, err := g.docker.InspectContainer(ctx, id)
+		if err != nil || info.State == nil || info.Config == nil {
+			continue
+		}
+		name := strings.TrimPrefix(info.Name, "/")
+		if !g.isMonitored(info.Config.Labels) || g.excluded(ctx, name, info.Config.Labels) {
+			continue
+		}
+
+		state := info.State.Status
+		stillUnhealthy := info.State.Health != nil && info.State.Health.Status == container.Unhealthy
+		if state == container.StateRunning && !stillUnhealthy {
+			continue // recovered on its own
+		}
+		if state != container.StateRunning && state != container.StateExited {
+			continue
+		}
+
+		c := container.Summary{
+			ID:     id,
+			Names:  []string{info.Name},
+			Labels: info.Config.Labels,
+			State:  state,
+		}
+		g.logFor(ctx).Infof("Container %s (%s) went unhealthy within startup lookback (%ds) and is %s - replaying", name, id[:12], g.cfg().StartupLookback, state)
+
+		if summary.Seen == nil {
+			summary.Seen = make(map[string]bool)
+		}
+		summary.Seen[id] = true
+		summary.Unhealthy++
+		g.handleUnhealthy(ctx, c, summary)
+	}
+}
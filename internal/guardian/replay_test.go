@@ -0,0 +1,128 @@
+package guardian
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+)
+
+func lookbackEvents(ids ...string) []events.Message {
+	var msgs []events.Message
+	for _, id := range ids {
+		msgs = append(msgs,
+			events.Message{Type: events.ContainerEventType, Action: "health_status: unhealthy", Actor: events.Actor{ID: id}},
+			events.Message{Type: events.ContainerEventType, Action: events.ActionDie, Actor: events.Actor{ID: id}},
+		)
+	}
+	return msgs
+}
+
+func TestReplayStartupEvents_ActsOnCrashedContainer(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, StartupLookback: 300}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	crashed := "crashed1234567890abcdef"
+	recovered := "recover1234567890abcdef"
+	dock.containerEvents = append(lookbackEvents(crashed),
+		events.Message{Type: events.ContainerEventType, Action: "health_status: unhealthy", Actor: events.Actor{ID: recovered}},
+		events.Message{Type: events.ContainerEventType, Action: "health_status: healthy", Actor: events.Actor{ID: recovered}},
+	)
+	dock.inspectResults[crashed] = container.InspectResponse{
+		Name:   "/crashed-app",
+		Config: &container.Config{Labels: map[string]string{}},
+		State:  &container.State{Status: container.StateExited, ExitCode: 1},
+	}
+	dock.inspectResults[recovered] = container.InspectResponse{
+		Name:   "/recovered-app",
+		Config: &container.Config{Labels: map[string]string{}},
+		State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: container.Healthy}},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.startupScan(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != crashed {
+		t.Fatalf("expected only the crashed container restarted, got %v", dock.restartCalls)
+	}
+	if len(notif.startups) != 1 || notif.startups[0] != "Startup scan: 0 monitored, 1 unhealthy, 1 restarted" {
+		t.Errorf("unexpected startup summary: %v", notif.startups)
+	}
+}
+
+func TestReplayStartupEvents_DedupesLiveScan(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, StartupLookback: 300}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "unhealthy234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/sick-app"}, State: "running"}}
+	dock.containerEvents = []events.Message{
+		{Type: events.ContainerEventType, Action: "health_status: unhealthy", Actor: events.Actor{ID: id}},
+	}
+	dock.inspectResults[id] = container.InspectResponse{
+		Name:   "/sick-app",
+		Config: &container.Config{Labels: map[string]string{}},
+		State:  &container.State{Status: container.StateRunning, Health: &container.Health{Status: container.Unhealthy}},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.startupScan(context.Background())
+
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected a single restart from the live scan, got %v", dock.restartCalls)
+	}
+}
+
+func TestReplayStartupEvents_SkipsExcluded(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, StartupLookback: 300, ExcludeNames: []string{"crashed-*"}}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "crashed1234567890abcdef"
+	dock.containerEvents = lookbackEvents(id)
+	dock.inspectResults[id] = container.InspectResponse{
+		Name:   "/crashed-app",
+		Config: &container.Config{Labels: map[string]string{}},
+		State:  &container.State{Status: container.StateExited, ExitCode: 1},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.startupScan(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("expected an excluded container not to be replayed, got %v", dock.restartCalls)
+	}
+	if len(notif.startups) != 1 || notif.startups[0] != "Startup scan: 0 monitored, 0 unhealthy, 0 restarted" {
+		t.Errorf("excluded container should not count as unhealthy: %v", notif.startups)
+	}
+}
+
+func TestReplayStartupEvents_Disabled(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "crashed1234567890abcdef"
+	dock.containerEvents = lookbackEvents(id)
+	dock.inspectResults[id] = container.InspectResponse{
+		Name:   "/crashed-app",
+		Config: &container.Config{Labels: map[string]string{}},
+		State:  &container.State{Status: container.StateExited},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.startupScan(context.Background())
+
+	if len(dock.restartCalls) != 0 {
+		t.Errorf("expected no replay with AUTOHEAL_STARTUP_LOOKBACK=0, got %v", dock.restartCalls)
+	}
+}
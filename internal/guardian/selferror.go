@@ -0,0 +1,46 @@
+package guardian
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// internalError logs a failure of the guardian itself (a Docker list or
+// inspect call) and counts it towards the self-error alert. Once
+// NOTIFY_ERROR_THRESHOLD failures fall within NOTIFY_ERROR_WINDOW a [CRITICAL]
+// notification is sent on the errors category, at most once per window, since
+// the guardian may no longer be seeing container health at all.
+func (g *Guardian) internalError(ctx context.Context, msg string, err error, args ...any) {
+	g.logFor(ctx).Error(msg, append(args, "error", err)...)
+	if g.cfg().NotifyErrorThreshold <= 0 {
+		return
+	}
+
+	window := time.Duration(g.cfg().NotifyErrorWindow) * time.Second
+	now := g.clock.Now()
+
+	g.selfErrMu.Lock()
+	kept := g.selfErrors[:0]
+	for _, t := range g.selfErrors {
+		if now.Sub(t) < window {
+			kept = append(kept, t)
+		}
+	}
+	g.selfErrors = append(kept, now)
+	count := len(g.selfErrors)
+	fire := count >= g.cfg().NotifyErrorThreshold &&
+		(g.selfErrFiredAt.IsZero() || now.Sub(g.selfErrFiredAt) >= window)
+	if fire {
+		g.selfErrFiredAt = now
+	}
+	g.selfErrMu.Unlock()
+	if !fire {
+		return
+	}
+
+	text := fmt.Sprintf("[CRITICAL] Guardian hit %d internal errors in the last %s (latest: %s: %v) - container health may not be monitored",
+		count, window, msg, err)
+	g.logFor(ctx).Warn(text)
+	g.notifier.Error(text)
+}
@@ -0,0 +1,69 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+)
+
+func TestSelfError_AlertsAfterThreshold(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyErrorThreshold: 3, NotifyErrorWindow: 60}
+	dock := newMockDocker()
+	dock.unhealthyErr = errors.New("Cannot connect to the Docker daemon")
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	for range 2 {
+		g.checkUnhealthy(context.Background())
+		clk.Advance(5 * time.Second)
+	}
+	if len(notif.errors) != 0 {
+		t.Fatalf("expected no alert below the threshold, got %v", notif.errors)
+	}
+
+	g.checkUnhealthy(context.Background())
+	if len(notif.errors) != 1 || !strings.Contains(notif.errors[0], "[CRITICAL]") ||
+		!strings.Contains(notif.errors[0], "failed to list unhealthy containers") {
+		t.Fatalf("expected one self-error alert, got %v", notif.errors)
+	}
+	if len(notif.actions) != 0 {
+		t.Errorf("self-errors must not use the action path, got %v", notif.actions)
+	}
+
+	// Further failures inside the same window are not re-alerted
+	g.checkUnhealthy(context.Background())
+	if len(notif.errors) != 1 {
+		t.Errorf("expected the alert to be rate-limited, got %v", notif.errors)
+	}
+
+	// Once the window has passed, a fresh burst alerts again
+	clk.Advance(time.Minute)
+	for range 3 {
+		g.checkUnhealthy(context.Background())
+	}
+	if len(notif.errors) != 2 {
+		t.Errorf("expected a second alert after the window, got %v", notif.errors)
+	}
+}
+
+func TestSelfError_SpreadOutFailuresDoNotAlert(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", NotifyErrorThreshold: 3, NotifyErrorWindow: 60}
+	dock := newMockDocker()
+	dock.unhealthyErr = errors.New("Cannot connect to the Docker daemon")
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	for range 5 {
+		g.checkUnhealthy(context.Background())
+		clk.Advance(31 * time.Second)
+	}
+	if len(notif.errors) != 0 {
+		t.Errorf("expected no alert when failures fall outside the window, got %v", notif.errors)
+	}
+}
@@ -0,0 +1,47 @@
+package guardian
+
+import "context"
+
+// Stop asks Run to return without starting new work: the event loop and
+// periodic scans end, a scan in progress finishes its current container, and
+// pending debounced events are dropped. Actions already under way keep the
+// context passed to Run; Drain waits for them.
+func (g *Guardian) Stop() {
+	g.stopOnce.Do(func() {
+		g.debounceMu.Lock()
+		close(g.stopCh)
+		for key, timer := range g.debounceTimers {
+			if timer.Stop() {
+				delete(g.debounceTimers, key)
+			}
+		}
+		g.debounceMu.Unlock()
+	})
+}
+
+// stopping reports whether Stop has been called.
+func (g *Guardian) stopping() bool {
+	select {
+	case <-g.stopCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Drain waits for in-flight event handlers and post-restart checks after
+// Stop. It returns false if ctx is done first (a forced shutdown).
+func (g *Guardian) Drain(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		g.working.Wait()
+		g.verifying.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package guardian
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/moby/moby/api/types/container"
+)
+
+// startBlockedRestart sends an unhealthy event for a container whose restart
+// blocks on the returned gate, and waits until the restart is under way.
+func startBlockedRestart(t *testing.T, g *Guardian, dock *mockDocker, id string) chan struct{} {
+	t.Helper()
+	gate := make(chan struct{})
+	dock.restartGate[id] = gate
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, Action: "health_status", HealthStatus: "unhealthy"})
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		dock.mu.Lock()
+		n := len(dock.restartCalls)
+		dock.mu.Unlock()
+		if n == 1 {
+			return gate
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("restart never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShutdown_StopDrainsInFlightActions(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	id := "abcdef1234567890abcdef"
+	gate := startBlockedRestart(t, g, dock, id)
+
+	// A second event arriving after Stop must not start new work
+	g.Stop()
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: "bbbbbb1234567890abcdef", Action: "health_status", HealthStatus: "unhealthy"})
+
+	drained := make(chan bool, 1)
+	go func() { drained <- g.Drain(context.Background()) }()
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a restart was still in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(gate)
+	select {
+	case ok := <-drained:
+		if !ok {
+			t.Error("Drain reported a forced exit")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain did not return after the restart finished")
+	}
+	if err := g.Run(context.Background()); err != nil {
+		t.Errorf("Run after Stop: %v", err)
+	}
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected only the in-flight restart, got %v", dock.restartCalls)
+	}
+}
+
+func TestShutdown_ForcedDrainReturnsImmediately(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	gate := startBlockedRestart(t, g, dock, "abcdef1234567890abcdef")
+	defer close(gate)
+
+	g.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // second signal
+
+	start := time.Now()
+	if g.Drain(ctx) {
+		t.Error("Drain should report the forced exit")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("forced Drain should not wait for in-flight actions")
+	}
+}
@@ -0,0 +1,138 @@
+package guardian
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+)
+
+// persistedState is the AUTOHEAL_STATE_FILE document.
+type persistedState struct {
+	LifetimeRestarts map[string]int       `json:"lifetime_restarts"`     // container name → restarts
+	Quarantined      map[string]time.Time `json:"quarantined,omitempty"` // container ID → when the guardian stopped it
+	Tracker          json.RawMessage      `json:"tracker,omitempty"`     // RestartTracker history, by container ID
+}
+
+// stateStore holds state that survives guardian restarts, writing the state
+// file on every change. Counts are keyed by container name so they carry over
+// when a container is recreated with a new ID.
+type stateStore struct {
+	mu    sync.Mutex
+	path  string
+	state persistedState
+}
+
+// loadState reads the state file at path. A missing file starts empty.
+func loadState(path string) (*stateStore, error) {
+	s := &stateStore{path: path, state: persistedState{LifetimeRestarts: make(map[string]int)}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return s, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if s.state.LifetimeRestarts == nil {
+		s.state.LifetimeRestarts = make(map[string]int)
+	}
+	for name, n := range s.state.LifetimeRestarts {
+		metrics.LifetimeRestarts.WithLabelValues(name).Set(float64(n))
+	}
+	return s, nil
+}
+
+// recordRestart increments a container's lifetime restart count and saves.
+func (s *stateStore) recordRestart(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.LifetimeRestarts[name]++
+	metrics.LifetimeRestarts.WithLabelValues(name).Set(float64(s.state.LifetimeRestarts[name]))
+	return s.save()
+}
+
+// lifetimeRestarts returns a copy of the lifetime restart counts.
+func (s *stateStore) lifetimeRestarts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.state.LifetimeRestarts)
+}
+
+// quarantined returns a copy of the guardian-initiated quarantines.
+func (s *stateStore) quarantined() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.state.Quarantined)
+}
+
+// setQuarantined replaces the persisted quarantines and saves.
+func (s *stateStore) setQuarantined(q map[string]time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Quarantined = q
+	return s.save()
+}
+
+// trackerHistory returns the saved RestartTracker history.
+func (s *stateStore) trackerHistory() json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.state.Tracker)
+}
+
+// setTrackerHistory replaces the saved RestartTracker history and saves.
+func (s *stateStore) setTrackerHistory(data json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Tracker = data
+	return s.save()
+}
+
+// save writes the state atomically via a temporary file. Caller holds mu.
+func (s *stateStore) save() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic replaces path with data via a temporary file in the same
+// directory, so readers never see a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".guardian-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// countLifetimeRestart records a successful restart (or equivalent) in the
+// persisted lifetime counts. No-op without AUTOHEAL_STATE_FILE.
+func (g *Guardian) countLifetimeRestart(name string) {
+	if g.state == nil {
+		return
+	}
+	if err := g.state.recordRestart(name); err != nil {
+		g.log.Warn("failed to save state file", "path", g.cfg().StateFile, "error", err)
+	}
+}
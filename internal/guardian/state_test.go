@@ -0,0 +1,53 @@
+package guardian
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLifetimeRestarts_SurviveReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, StateFile: path}
+	dock := newMockDocker()
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/lifetime-web"}, State: "running"},
+	}
+
+	state, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState on missing file: %v", err)
+	}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	g.state = state
+	g.checkUnhealthy(context.Background())
+	g.tracker.Reset("abcdef1234567890abcdef")
+	g.checkUnhealthy(context.Background())
+
+	// Simulate a guardian restart: the in-process metric is gone, the file remains
+	metrics.LifetimeRestarts.Reset()
+	reloaded, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	g = newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+	g.state = reloaded
+
+	if got := g.Status().LifetimeRestarts["lifetime-web"]; got != 2 {
+		t.Errorf("lifetime restarts after reload = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.LifetimeRestarts.WithLabelValues("lifetime-web")); got != 2 {
+		t.Errorf("docker_guardian_lifetime_restarts_total = %v, want 2", got)
+	}
+
+	g.checkUnhealthy(context.Background())
+	if got := g.Status().LifetimeRestarts["lifetime-web"]; got != 3 {
+		t.Errorf("lifetime restarts should keep counting after reload, got %d", got)
+	}
+}
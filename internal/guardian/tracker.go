@@ -1,7 +1,11 @@
 package guardian
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,7 +16,9 @@ import (
 type TrackerConfig struct {
 	BackoffMultiplier float64       // multiplicative factor for each retry (default 2)
 	BackoffMax        time.Duration // cap on backoff delay (default 300s)
-	BackoffResetAfter time.Duration // healthy for this long resets backoff (default 600s)
+	BackoffResetAfter time.Duration // no restart for this long resets backoff (default 600s)
+	BackoffJitter     float64       // fraction (0-1) the wait is randomised by either way (0 = exact)
+	RestartCooldown   time.Duration // minimum wait after any restart, whatever the backoff (0 = none)
 	RestartBudget     int           // max restarts per window (0 = unlimited)
 	RestartWindow     time.Duration // rolling window for budget (default 300s)
 }
@@ -30,11 +36,28 @@ func DefaultTrackerConfig() TrackerConfig {
 
 // ContainerHistory tracks restart history for a single container.
 type ContainerHistory struct {
-	Restarts       []time.Time   // timestamps of recent restarts
-	BackoffUntil   time.Time     // next allowed restart time
-	BackoffDelay   time.Duration // current backoff delay
-	CircuitOpen    bool          // true = budget exhausted
-	UnhealthyCount int           // consecutive unhealthy detections
+	Restarts        []time.Time   `json:"restarts"`         // timestamps of recent restarts
+	LastRestart     time.Time     `json:"last_restart"`     // most recent restart, kept after Restarts is pruned
+	BackoffUntil    time.Time     `json:"backoff_until"`    // next allowed restart time
+	BackoffDelay    time.Duration `json:"backoff_delay"`    // current backoff delay
+	CircuitOpen     bool          `json:"circuit_open"`     // true = budget exhausted
+	UnhealthyCount  int           `json:"unhealthy_count"`  // consecutive unhealthy detections
+	RestartFailures int           `json:"restart_failures"` // consecutive failed restart attempts
+}
+
+// TrackerStats is a point-in-time view of a container's restart tracking.
+type TrackerStats struct {
+	RecentRestarts   int     `json:"recent_restarts"` // within the restart window
+	BackoffRemaining float64 `json:"backoff_remaining_seconds"`
+	CircuitOpen      bool    `json:"circuit_open"`
+	UnhealthyCount   int     `json:"unhealthy_count"`
+	RestartFailures  int     `json:"restart_failures"`
+}
+
+// TrackedContainer is the tracking state of one container in a Snapshot.
+type TrackedContainer struct {
+	ID string `json:"id"`
+	TrackerStats
 }
 
 // SkipReason describes why a restart was suppressed.
@@ -52,6 +75,12 @@ type RestartTracker struct {
 	history map[string]*ContainerHistory
 	cfg     TrackerConfig
 	clock   clock.Clock
+	rng     *rand.Rand // jitter source; tests swap in a fixed seed
+
+	autoClosed []string // IDs whose circuit closed since the last CloseElapsedCircuits
+
+	saveTo      func(json.RawMessage) error // persists the history (AUTOHEAL_STATE_FILE); nil = memory only
+	onSaveError func(error)                 // called when saveTo fails
 }
 
 // NewRestartTracker creates a tracker with the given config.
@@ -60,7 +89,64 @@ func NewRestartTracker(cfg TrackerConfig, clk clock.Clock) *RestartTracker {
 		history: make(map[string]*ContainerHistory),
 		cfg:     cfg,
 		clock:   clk,
+		rng:     rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)),
+	}
+}
+
+// restore replaces the history with one saved by a previous run. Empty data
+// leaves it as is; on a parse error the history is left empty.
+func (rt *RestartTracker) restore(data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
 	}
+	history := make(map[string]*ContainerHistory)
+	if err := json.Unmarshal(data, &history); err != nil {
+		return fmt.Errorf("parsing tracker history: %w", err)
+	}
+	for id, h := range history {
+		if h == nil {
+			delete(history, id)
+		}
+	}
+	rt.mu.Lock()
+	rt.history = history
+	rt.mu.Unlock()
+	return nil
+}
+
+// Save persists the history. No-op without a state file.
+func (rt *RestartTracker) Save() error {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return rt.save()
+}
+
+// save persists the history through saveTo. Caller holds mu.
+func (rt *RestartTracker) save() error {
+	if rt.saveTo == nil {
+		return nil
+	}
+	data, err := json.Marshal(rt.history)
+	if err != nil {
+		return err
+	}
+	return rt.saveTo(data)
+}
+
+// persist saves after a change, reporting failures through onSaveError.
+// Caller holds mu.
+func (rt *RestartTracker) persist() {
+	if err := rt.save(); err != nil && rt.onSaveError != nil {
+		rt.onSaveError(err)
+	}
+}
+
+// SetConfig replaces the backoff and budget settings, keeping the history
+// recorded so far.
+func (rt *RestartTracker) SetConfig(cfg TrackerConfig) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.cfg = cfg
 }
 
 // ShouldRestart checks if a restart is allowed for the given container.
@@ -74,6 +160,7 @@ func (rt *RestartTracker) ShouldRestart(id string) (bool, SkipReason) {
 
 	// Prune old restarts outside the window
 	rt.pruneOld(h)
+	rt.closeIfElapsed(id, h)
 
 	// Check circuit breaker (budget exhausted)
 	if h.CircuitOpen {
@@ -81,13 +168,14 @@ func (rt *RestartTracker) ShouldRestart(id string) (bool, SkipReason) {
 	}
 
 	// Check backoff
-	if now.Before(h.BackoffUntil) {
+	if now.Before(rt.backoffUntil(h)) {
 		return false, SkipBackoff
 	}
 
 	// Check budget
 	if rt.cfg.RestartBudget > 0 && len(h.Restarts) >= rt.cfg.RestartBudget {
 		h.CircuitOpen = true
+		rt.persist()
 		return false, SkipCircuit
 	}
 
@@ -103,7 +191,14 @@ func (rt *RestartTracker) RecordRestart(id string) {
 	h := rt.getOrCreate(id)
 	now := rt.clock.Now()
 
+	// Fallback for containers never confirmed recovered: a long enough
+	// quiet spell since the last restart starts backoff over.
+	if rt.cfg.BackoffResetAfter > 0 && !h.LastRestart.IsZero() && now.Sub(h.LastRestart) >= rt.cfg.BackoffResetAfter {
+		h.BackoffDelay = 0
+	}
+
 	h.Restarts = append(h.Restarts, now)
+	h.LastRestart = now
 
 	// Calculate next backoff
 	if h.BackoffDelay == 0 {
@@ -114,7 +209,37 @@ func (rt *RestartTracker) RecordRestart(id string) {
 	if h.BackoffDelay > rt.cfg.BackoffMax {
 		h.BackoffDelay = rt.cfg.BackoffMax
 	}
-	h.BackoffUntil = now.Add(h.BackoffDelay)
+	h.BackoffUntil = now.Add(rt.jitter(h.BackoffDelay))
+	rt.persist()
+}
+
+// backoffUntil is when the container may next be restarted: the end of its
+// backoff, or of the post-restart cooldown if that is later. The cooldown
+// outlasts ResetBackoff so a container still booting is not restarted again.
+func (rt *RestartTracker) backoffUntil(h *ContainerHistory) time.Time {
+	until := h.BackoffUntil
+	if rt.cfg.RestartCooldown > 0 && !h.LastRestart.IsZero() {
+		if cooldown := h.LastRestart.Add(rt.cfg.RestartCooldown); cooldown.After(until) {
+			until = cooldown
+		}
+	}
+	return until
+}
+
+// jitter spreads delay by up to ±BackoffJitter of itself so containers that
+// failed together do not all retry at once. The result never exceeds
+// BackoffMax. BackoffDelay itself is left exact so the growth stays
+// predictable; only the wait derived from it varies.
+func (rt *RestartTracker) jitter(delay time.Duration) time.Duration {
+	if rt.cfg.BackoffJitter <= 0 {
+		return delay
+	}
+	offset := (rt.rng.Float64()*2 - 1) * rt.cfg.BackoffJitter * float64(delay)
+	delay += time.Duration(offset)
+	if delay > rt.cfg.BackoffMax {
+		delay = rt.cfg.BackoffMax
+	}
+	return delay
 }
 
 // RecordUnhealthy increments the unhealthy counter for a container.
@@ -139,6 +264,38 @@ func (rt *RestartTracker) UnhealthyCount(id string) int {
 	return 0
 }
 
+// RecordRestartFailure increments the consecutive restart failure counter for
+// a container and returns the new count.
+func (rt *RestartTracker) RecordRestartFailure(id string) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	h := rt.getOrCreate(id)
+	h.RestartFailures++
+	return h.RestartFailures
+}
+
+// RestartFailures returns the consecutive restart failure count for a container.
+func (rt *RestartTracker) RestartFailures(id string) int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if h, ok := rt.history[id]; ok {
+		return h.RestartFailures
+	}
+	return 0
+}
+
+// ResetRestartFailures clears the restart failure counter for a container.
+func (rt *RestartTracker) ResetRestartFailures(id string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if h, ok := rt.history[id]; ok {
+		h.RestartFailures = 0
+	}
+}
+
 // ResetUnhealthy clears the unhealthy counter for a container.
 func (rt *RestartTracker) ResetUnhealthy(id string) {
 	rt.mu.Lock()
@@ -149,12 +306,47 @@ func (rt *RestartTracker) ResetUnhealthy(id string) {
 	}
 }
 
+// ResetBackoff clears backoff for a container confirmed recovered, keeping its
+// restart history so the budget still counts those restarts.
+func (rt *RestartTracker) ResetBackoff(id string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if h, ok := rt.history[id]; ok {
+		h.BackoffDelay = 0
+		h.BackoffUntil = time.Time{}
+		rt.persist()
+	}
+}
+
 // Reset clears backoff and restart history for a container (e.g. when it becomes healthy).
-func (rt *RestartTracker) Reset(id string) {
+// It reports whether this closed an open circuit.
+func (rt *RestartTracker) Reset(id string) bool {
 	rt.mu.Lock()
 	defer rt.mu.Unlock()
 
+	h, ok := rt.history[id]
+	if !ok {
+		return false
+	}
 	delete(rt.history, id)
+	rt.persist()
+	return h.CircuitOpen
+}
+
+// Move re-keys a container's history to a new ID, e.g. after a recreate, so
+// its backoff and failure counts follow the replacement.
+func (rt *RestartTracker) Move(from, to string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	h, ok := rt.history[from]
+	if !ok {
+		return
+	}
+	delete(rt.history, from)
+	rt.history[to] = h
+	rt.persist()
 }
 
 // IsCircuitOpen returns true if the circuit is open for the given container.
@@ -178,13 +370,56 @@ func (rt *RestartTracker) BackoffRemaining(id string) time.Duration {
 	if !ok {
 		return 0
 	}
-	remaining := h.BackoffUntil.Sub(rt.clock.Now())
+	remaining := rt.backoffUntil(h).Sub(rt.clock.Now())
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
 }
 
+// Stats returns the tracking state for the container whose ID starts with id.
+func (rt *RestartTracker) Stats(id string) (TrackerStats, bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	for key, h := range rt.history {
+		if !strings.HasPrefix(key, id) {
+			continue
+		}
+		rt.pruneOld(h)
+		rt.closeIfElapsed(key, h)
+		return rt.stats(h), true
+	}
+	return TrackerStats{}, false
+}
+
+// Snapshot returns the tracking state of every tracked container, sorted by ID.
+func (rt *RestartTracker) Snapshot() []TrackedContainer {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	snap := make([]TrackedContainer, 0, len(rt.history))
+	for id, h := range rt.history {
+		rt.pruneOld(h)
+		rt.closeIfElapsed(id, h)
+		snap = append(snap, TrackedContainer{ID: id, TrackerStats: rt.stats(h)})
+	}
+	slices.SortFunc(snap, func(a, b TrackedContainer) int { return strings.Compare(a.ID, b.ID) })
+	return snap
+}
+
+// stats converts a history into TrackerStats. Caller holds mu.
+func (rt *RestartTracker) stats(h *ContainerHistory) TrackerStats {
+	remaining := max(rt.backoffUntil(h).Sub(rt.clock.Now()), 0)
+	return TrackerStats{
+		RecentRestarts:   len(h.Restarts),
+		BackoffRemaining: remaining.Seconds(),
+		CircuitOpen:      h.CircuitOpen,
+		UnhealthyCount:   h.UnhealthyCount,
+		RestartFailures:  h.RestartFailures,
+	}
+}
+
 // CircuitOpenCount returns the number of containers with open circuits.
 func (rt *RestartTracker) CircuitOpenCount() int {
 	rt.mu.Lock()
@@ -199,6 +434,24 @@ func (rt *RestartTracker) CircuitOpenCount() int {
 	return count
 }
 
+// CloseElapsedCircuits closes every open circuit whose restarts have aged out
+// of the window and returns the IDs of all circuits that auto-closed since the
+// previous call, including those closed by ShouldRestart.
+func (rt *RestartTracker) CloseElapsedCircuits() []string {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	for id, h := range rt.history {
+		if h.CircuitOpen {
+			rt.pruneOld(h)
+			rt.closeIfElapsed(id, h)
+		}
+	}
+	closed := rt.autoClosed
+	rt.autoClosed = nil
+	return closed
+}
+
 // FormatSkipReason returns a human-readable string for a skip reason.
 func (rt *RestartTracker) FormatSkipReason(id, name string, reason SkipReason) string {
 	switch reason {
@@ -221,6 +474,17 @@ func (rt *RestartTracker) getOrCreate(id string) *ContainerHistory {
 	return h
 }
 
+// closeIfElapsed closes an open circuit once pruning has brought the container
+// back under its budget. Caller holds mu.
+func (rt *RestartTracker) closeIfElapsed(id string, h *ContainerHistory) {
+	if !h.CircuitOpen || len(h.Restarts) >= rt.cfg.RestartBudget {
+		return
+	}
+	h.CircuitOpen = false
+	rt.autoClosed = append(rt.autoClosed, id)
+	rt.persist()
+}
+
 func (rt *RestartTracker) pruneOld(h *ContainerHistory) {
 	if rt.cfg.RestartWindow <= 0 {
 		return
@@ -1,8 +1,16 @@
 package guardian
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/moby/moby/api/types/container"
 )
 
 func TestTracker_AllowsFirstRestart(t *testing.T) {
@@ -90,6 +98,75 @@ func TestTracker_BackoffMax(t *testing.T) {
 	}
 }
 
+func TestTracker_NoJitterIsExact(t *testing.T) {
+	clk := newMockClock(time.Now())
+	rt := NewRestartTracker(DefaultTrackerConfig(), clk)
+
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second} {
+		rt.RecordRestart("abc123")
+		if got := rt.BackoffRemaining("abc123"); got != want {
+			t.Fatalf("expected exactly %v backoff without jitter, got %v", want, got)
+		}
+		clk.Advance(want)
+	}
+}
+
+func TestTracker_JitterStaysWithinBounds(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.BackoffJitter = 0.5
+	cfg.BackoffMax = 12 * time.Second
+	rt := NewRestartTracker(cfg, clk)
+	rt.rng = rand.New(rand.NewPCG(1, 2))
+
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("c%d", i)
+		rt.RecordRestart(id)
+		got := rt.BackoffRemaining(id)
+		// 10s ±50%, with the upper half cut off by the 12s cap
+		if got < 5*time.Second || got > cfg.BackoffMax {
+			t.Fatalf("jittered backoff %v outside [5s, %v]", got, cfg.BackoffMax)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected jitter to vary the backoff between containers")
+	}
+
+	// The stored delay is not jittered, so growth stays predictable
+	rt.RecordRestart("c0")
+	if d := rt.history["c0"].BackoffDelay; d != cfg.BackoffMax {
+		t.Errorf("expected BackoffDelay capped at %v, got %v", cfg.BackoffMax, d)
+	}
+}
+
+func TestTracker_RestartCooldownOutlastsBackoff(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.RestartCooldown = 30 * time.Second
+	rt := NewRestartTracker(cfg, clk)
+
+	rt.RecordRestart("abc123")
+	// Confirmed recovery clears the backoff but not the cooldown
+	rt.ResetBackoff("abc123")
+	clk.Advance(15 * time.Second)
+	if allowed, reason := rt.ShouldRestart("abc123"); allowed || reason != SkipBackoff {
+		t.Fatalf("expected backoff skip inside the cooldown, got allowed=%v reason=%s", allowed, reason)
+	}
+	if got := rt.BackoffRemaining("abc123"); got != 15*time.Second {
+		t.Errorf("expected 15s remaining of the cooldown, got %v", got)
+	}
+	if stats, _ := rt.Stats("abc123"); stats.BackoffRemaining != 15 {
+		t.Errorf("expected /status to report the cooldown's 15s, got %vs", stats.BackoffRemaining)
+	}
+
+	clk.Advance(15 * time.Second)
+	if allowed, _ := rt.ShouldRestart("abc123"); !allowed {
+		t.Error("expected restart allowed once the cooldown has passed")
+	}
+}
+
 func TestTracker_BudgetExhausted(t *testing.T) {
 	clk := newMockClock(time.Now())
 	cfg := DefaultTrackerConfig()
@@ -117,6 +194,58 @@ func TestTracker_BudgetExhausted(t *testing.T) {
 	}
 }
 
+func TestTracker_CircuitAutoClosesAfterWindow(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.RestartBudget = 2
+	cfg.RestartWindow = 300 * time.Second
+	rt := NewRestartTracker(cfg, clk)
+
+	rt.RecordRestart("abc123")
+	clk.Advance(11 * time.Second)
+	rt.RecordRestart("abc123")
+	clk.Advance(21 * time.Second)
+	if allowed, reason := rt.ShouldRestart("abc123"); allowed || reason != SkipCircuit {
+		t.Fatalf("expected circuit open, got allowed=%v reason=%s", allowed, reason)
+	}
+	if closed := rt.CloseElapsedCircuits(); len(closed) != 0 {
+		t.Errorf("circuit should stay open inside the window, got %v", closed)
+	}
+
+	clk.Advance(cfg.RestartWindow)
+	if allowed, reason := rt.ShouldRestart("abc123"); !allowed {
+		t.Errorf("restart should be allowed once the window elapsed, got reason=%s", reason)
+	}
+	if rt.IsCircuitOpen("abc123") {
+		t.Error("circuit should have auto-closed")
+	}
+	if closed := rt.CloseElapsedCircuits(); len(closed) != 1 || closed[0] != "abc123" {
+		t.Errorf("expected the close transition to be reported once, got %v", closed)
+	}
+	if closed := rt.CloseElapsedCircuits(); len(closed) != 0 {
+		t.Errorf("transition should only be reported once, got %v", closed)
+	}
+}
+
+func TestTracker_CloseElapsedCircuitsSweeps(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.RestartBudget = 1
+	rt := NewRestartTracker(cfg, clk)
+
+	rt.RecordRestart("a")
+	clk.Advance(11 * time.Second)
+	rt.ShouldRestart("a") // opens the circuit
+
+	clk.Advance(cfg.RestartWindow)
+	if closed := rt.CloseElapsedCircuits(); len(closed) != 1 || closed[0] != "a" {
+		t.Errorf("expected sweep to close the idle circuit, got %v", closed)
+	}
+	if rt.CircuitOpenCount() != 0 {
+		t.Errorf("expected no open circuits, got %d", rt.CircuitOpenCount())
+	}
+}
+
 func TestTracker_BudgetUnlimited(t *testing.T) {
 	clk := newMockClock(time.Now())
 	cfg := DefaultTrackerConfig()
@@ -222,3 +351,133 @@ func TestContainerAction(t *testing.T) {
 		}
 	}
 }
+
+func TestTracker_BackoffResetsAfterQuietPeriod(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.RestartBudget = 0
+	rt := NewRestartTracker(cfg, clk)
+
+	rt.RecordRestart("abc123")
+	clk.Advance(11 * time.Second)
+	rt.RecordRestart("abc123") // 20s
+
+	// Shorter than BackoffResetAfter: backoff keeps growing
+	clk.Advance(21 * time.Second)
+	rt.RecordRestart("abc123")
+	if remaining := rt.BackoffRemaining("abc123"); remaining < 39*time.Second {
+		t.Errorf("expected ~40s backoff, got %v", remaining)
+	}
+
+	// No restart for BackoffResetAfter: backoff starts over
+	clk.Advance(cfg.BackoffResetAfter)
+	rt.RecordRestart("abc123")
+	if remaining := rt.BackoffRemaining("abc123"); remaining > 10*time.Second {
+		t.Errorf("expected backoff back at 10s after quiet period, got %v", remaining)
+	}
+}
+
+func TestTracker_ResetBackoffKeepsHistory(t *testing.T) {
+	clk := newMockClock(time.Now())
+	rt := NewRestartTracker(DefaultTrackerConfig(), clk)
+
+	rt.RecordRestart("abc123")
+	clk.Advance(11 * time.Second)
+	rt.RecordRestart("abc123")
+
+	rt.ResetBackoff("abc123")
+	if allowed, reason := rt.ShouldRestart("abc123"); !allowed {
+		t.Errorf("verified recovery should clear backoff immediately, got reason=%s", reason)
+	}
+	stats, _ := rt.Stats("abc123")
+	if stats.RecentRestarts != 2 {
+		t.Errorf("restart history should be kept for the budget, got %d", stats.RecentRestarts)
+	}
+
+	rt.RecordRestart("abc123")
+	if remaining := rt.BackoffRemaining("abc123"); remaining > 10*time.Second {
+		t.Errorf("expected backoff to start over at 10s, got %v", remaining)
+	}
+}
+
+func TestTracker_StateFileKeepsCircuitOpen(t *testing.T) {
+	clk := newMockClock(time.Now())
+	cfg := DefaultTrackerConfig()
+	cfg.RestartBudget = 2
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := loadState(path)
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	rt := NewRestartTracker(cfg, clk)
+	rt.saveTo = state.setTrackerHistory
+	rt.RecordRestart("abc123")
+	clk.Advance(time.Minute)
+	rt.RecordRestart("abc123")
+	clk.Advance(time.Minute)
+	if allowed, reason := rt.ShouldRestart("abc123"); allowed || reason != SkipCircuit {
+		t.Fatalf("expected circuit open before reload, got allowed=%v reason=%s", allowed, reason)
+	}
+
+	reloadedState, err := loadState(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	reloaded := NewRestartTracker(cfg, clk)
+	if err := reloaded.restore(reloadedState.trackerHistory()); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if !reloaded.IsCircuitOpen("abc123") {
+		t.Fatal("circuit should stay open across a tracker reload")
+	}
+	if allowed, reason := reloaded.ShouldRestart("abc123"); allowed || reason != SkipCircuit {
+		t.Errorf("expected circuit skip after reload, got allowed=%v reason=%s", allowed, reason)
+	}
+
+	// Clearing the container is saved too
+	reloaded.saveTo = reloadedState.setTrackerHistory
+	reloaded.Reset("abc123")
+	again, _ := loadState(path)
+	fresh := NewRestartTracker(cfg, clk)
+	if err := fresh.restore(again.trackerHistory()); err != nil || fresh.IsCircuitOpen("abc123") {
+		t.Errorf("expected the reset to be persisted, got err=%v circuit open=%v", err, fresh.IsCircuitOpen("abc123"))
+	}
+}
+
+func TestTracker_RestoreCorruptOrEmptyStartsEmpty(t *testing.T) {
+	clk := newMockClock(time.Now())
+
+	if rt := NewRestartTracker(DefaultTrackerConfig(), clk); rt.restore(nil) != nil || len(rt.history) != 0 {
+		t.Errorf("no saved history: history=%v", rt.history)
+	}
+
+	rt := NewRestartTracker(DefaultTrackerConfig(), clk)
+	if err := rt.restore(json.RawMessage("{not json")); err == nil {
+		t.Error("expected corrupt history to be reported")
+	}
+	if allowed, _ := rt.ShouldRestart("abc123"); !allowed {
+		t.Error("corrupt state should start empty")
+	}
+}
+
+func TestStatus_OpenCircuitsAndUnhealthy(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.tracker.cfg.RestartBudget = 1
+
+	dock.unhealthyContainers = []container.Summary{{ID: "abcdef1234567890abcdef", Names: []string{"/app"}, State: "running"}}
+	g.checkUnhealthy(context.Background())
+	clk.Advance(15 * time.Second) // past the backoff with the budget spent: the next check opens the circuit
+	g.checkUnhealthy(context.Background())
+
+	st := g.Status()
+	if st.Unhealthy != 1 {
+		t.Errorf("expected 1 unhealthy, got %d", st.Unhealthy)
+	}
+	if len(st.OpenCircuits) != 1 || st.OpenCircuits[0].ID != "abcdef1234567890abcdef" || !st.OpenCircuits[0].CircuitOpen {
+		t.Errorf("unexpected open circuits: %+v", st.OpenCircuits)
+	}
+}
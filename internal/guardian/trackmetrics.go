@@ -0,0 +1,27 @@
+package guardian
+
+import "github.com/Will-Luck/Docker-Guardian/internal/metrics"
+
+// recordRestart records a restart attempt in the tracker and stamps the
+// container's docker_guardian_container_last_restart_timestamp gauge.
+func (g *Guardian) recordRestart(id, name string) {
+	g.tracker.RecordRestart(id)
+	metrics.ContainerLastRestart.WithLabelValues(name).Set(float64(g.clock.Now().Unix()))
+}
+
+// resetTracking forgets a container that is healthy again or gone, and drops
+// its per-container gauges so stale series don't linger on dashboards. It
+// reports whether the container's circuit was open.
+func (g *Guardian) resetTracking(id, name string) bool {
+	closed := g.tracker.Reset(id)
+	metrics.ContainerLastRestart.DeleteLabelValues(name)
+	metrics.ContainerUnhealthyCount.DeleteLabelValues(name)
+	return closed
+}
+
+// resetUnhealthy starts a container's unhealthy count over after a successful
+// action, so AUTOHEAL_UNHEALTHY_THRESHOLD applies afresh if it stays unhealthy.
+func (g *Guardian) resetUnhealthy(id, name string) {
+	g.tracker.ResetUnhealthy(id)
+	metrics.ContainerUnhealthyCount.DeleteLabelValues(name)
+}
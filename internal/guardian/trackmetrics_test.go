@@ -0,0 +1,48 @@
+package guardian
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestContainerGauges_SetOnRestartAndDroppedWhenHealthy(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, UnhealthyThreshold: 2}
+	dock := newMockDocker()
+	clk := newMockClock(time.Unix(1_700_000_000, 0))
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/gauged"}, State: "running"}}
+
+	g.checkUnhealthy(context.Background())
+	if got := testutil.ToFloat64(metrics.ContainerUnhealthyCount.WithLabelValues("gauged")); got != 1 {
+		t.Errorf("unhealthy count = %v, want 1 below the threshold", got)
+	}
+	if len(dock.restartCalls) != 0 {
+		t.Fatalf("should wait for the threshold, got %v", dock.restartCalls)
+	}
+
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 1 {
+		t.Fatalf("expected a restart at the threshold, got %v", dock.restartCalls)
+	}
+	if got := testutil.ToFloat64(metrics.ContainerLastRestart.WithLabelValues("gauged")); got != 1_700_000_000 {
+		t.Errorf("last restart timestamp = %v, want 1700000000", got)
+	}
+
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, ContainerName: "gauged", Action: "health_status", HealthStatus: "healthy"})
+	// DeleteLabelValues reports whether the series still existed
+	if metrics.ContainerLastRestart.DeleteLabelValues("gauged") {
+		t.Error("expected the restart series removed once healthy")
+	}
+	if metrics.ContainerUnhealthyCount.DeleteLabelValues("gauged") {
+		t.Error("expected the unhealthy series removed once healthy")
+	}
+}
@@ -3,11 +3,15 @@ package guardian
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/moby/moby/api/types/container"
 )
 
 // shouldNotify returns false if the container has autoheal.notify=false label.
@@ -18,150 +22,578 @@ func shouldNotify(labels map[string]string) bool {
 	return true
 }
 
+// displayName picks a stable, friendly name from a container's names. Docker
+// lists link aliases as "/other/name" alongside the real "/name", in no
+// guaranteed order; the shortest name without a nested path is preferred,
+// ties broken alphabetically so the choice is the same every cycle.
+func displayName(names []string) string {
+	best := ""
+	bestAlias := true
+	for _, n := range names {
+		n = strings.TrimPrefix(n, "/")
+		if n == "" {
+			continue
+		}
+		alias := strings.Contains(n, "/")
+		switch {
+		case best == "",
+			bestAlias && !alias,
+			alias == bestAlias && (len(n) < len(best) || (len(n) == len(best) && n < best)):
+			best, bestAlias = n, alias
+		}
+	}
+	return best
+}
+
+// notifyServices returns the services listed in the autoheal.notify.only label
+// (e.g. "pagerduty,telegram"), or nil if the container is not pinned.
+func notifyServices(labels map[string]string) []string {
+	var services []string
+	for _, s := range strings.Split(labels["autoheal.notify.only"], ",") {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			services = append(services, s)
+		}
+	}
+	return services
+}
+
 // containerAction returns the action to take for a container based on its labels.
-// Possible values: "restart" (default), "stop", "notify", "none".
+// Possible values: "restart" (default), "hardrestart", "recreate", "stop",
+// "notify", "none".
 func containerAction(labels map[string]string) string {
 	if action, ok := labels["autoheal.action"]; ok {
 		switch action {
-		case "restart", "stop", "notify", "none":
+		case "restart", "hardrestart", "recreate", "stop", "notify", "none":
 			return action
 		}
 	}
 	return "restart"
 }
 
-// checkUnhealthy finds unhealthy containers and handles them based on action labels.
-func (g *Guardian) checkUnhealthy(ctx context.Context) {
-	containers, err := g.docker.UnhealthyContainers(ctx, g.cfg.ContainerLabel, g.cfg.OnlyMonitorRunning)
-	if err != nil {
-		g.log.Error("failed to list unhealthy containers", "error", err)
-		return
-	}
+// scanOnly reports whether autoheal.reaction=scan opts a container out of
+// the event-driven fast path, leaving it to periodic scans. Any other value
+// (or none) means event.
+func scanOnly(labels map[string]string) bool {
+	return labels["autoheal.reaction"] == "scan"
+}
 
-	metrics.UnhealthyContainers.Set(float64(len(containers)))
-	metrics.CircuitOpenContainers.Set(float64(g.tracker.CircuitOpenCount()))
+// onMatchLabel maps health-check output patterns to actions, e.g.
+// "disk.full=notify;connection refused=restart".
+const onMatchLabel = "autoheal.action.on-match"
 
-	for _, c := range containers {
-		// Skip containers opted out via label
-		if c.Labels["autoheal"] == "False" {
+// matchedAction returns the action of the first autoheal.action.on-match entry
+// whose regex matches the health-check output. Entries are separated by ';' and
+// split on their last '='; invalid patterns or actions are logged and ignored.
+func (g *Guardian) matchedAction(ctx context.Context, name, rules, output string) (string, bool) {
+	for _, rule := range strings.Split(rules, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
 			continue
 		}
-
-		if len(c.Names) == 0 {
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 {
+			g.logFor(ctx).Warn("ignoring malformed "+onMatchLabel+" entry", "container", name, "entry", rule)
 			continue
 		}
-
-		id := c.ID
-		shortID := id[:12]
-		name := strings.TrimPrefix(c.Names[0], "/")
-
-		// Check per-container action label
-		action := containerAction(c.Labels)
-		if action == "none" {
+		pattern, action := rule[:i], strings.TrimSpace(rule[i+1:])
+		switch action {
+		case "restart", "hardrestart", "recreate", "stop", "notify", "none":
+		default:
+			g.logFor(ctx).Warn("ignoring "+onMatchLabel+" entry with unknown action", "container", name, "entry", rule)
 			continue
 		}
-
-		if string(c.State) == "paused" {
-			now := g.clock.Now().Format("02-01-2006 15:04:05")
-			fmt.Printf("%s Container %s (%s) is paused - skipping\n", now, name, shortID)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			g.logFor(ctx).Warn("ignoring "+onMatchLabel+" entry with invalid pattern", "container", name, "entry", rule, "error", err)
 			continue
 		}
+		if re.MatchString(output) {
+			return action, true
+		}
+	}
+	return "", false
+}
 
-		if string(c.State) == "restarting" {
-			now := g.clock.Now().Format("02-01-2006 15:04:05")
-			fmt.Printf("%s Container %s (%s) found to be restarting - don't restart\n", now, name, shortID)
-			continue
+// unhealthySince returns when the current run of failing health checks began,
+// taken from the Start of the earliest consecutive failing entry at the end of
+// the health log. Docker keeps only the last few entries, so for long streaks
+// this is a lower bound. Returns false if the latest check did not fail.
+func unhealthySince(info container.InspectResponse) (time.Time, bool) {
+	if info.State == nil || info.State.Health == nil {
+		return time.Time{}, false
+	}
+	entries := info.State.Health.Log
+	var since time.Time
+	for i := len(entries) - 1; i >= 0 && entries[i].ExitCode != 0; i-- {
+		since = entries[i].Start
+	}
+	return since, !since.IsZero()
+}
+
+// startPeriodRemaining returns how much of the container's healthcheck start
+// period (--health-start-period) is left, measured from State.StartedAt.
+// Reports false once it is over, or when the inspect data has no start period
+// or no parsable StartedAt.
+func startPeriodRemaining(info container.InspectResponse, now time.Time) (time.Duration, bool) {
+	if info.Config == nil || info.Config.Healthcheck == nil || info.Config.Healthcheck.StartPeriod <= 0 || info.State == nil {
+		return 0, false
+	}
+	started, err := time.Parse(time.RFC3339Nano, info.State.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+	left := info.Config.Healthcheck.StartPeriod - now.Sub(started)
+	return left, left > 0
+}
+
+// scanSummary counts what a single checkUnhealthy pass found and did.
+type scanSummary struct {
+	ListErr   error // non-nil if the daemon could not be queried
+	Unhealthy int
+	Restarted int
+	Stopped   int
+
+	Seen map[string]bool // IDs of unhealthy containers listed by the scan
+}
+
+// stopTimeout returns the stop timeout for a container, honouring the
+// autoheal.stop.timeout label over AUTOHEAL_DEFAULT_STOP_TIMEOUT.
+func (g *Guardian) stopTimeout(labels map[string]string) int {
+	if v, ok := labels["autoheal.stop.timeout"]; ok {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			return parsed
 		}
+	}
+	return g.cfg().DefaultStopTimeout
+}
 
-		// Check unhealthy threshold (default 1 = immediate action)
-		if g.cfg.UnhealthyThreshold > 1 {
-			if !g.tracker.RecordUnhealthy(id, g.cfg.UnhealthyThreshold) {
-				now := g.clock.Now().Format("02-01-2006 15:04:05")
-				count := g.tracker.UnhealthyCount(id)
-				fmt.Printf("%s Container %s (%s) unhealthy (%d/%d) - waiting for threshold\n",
-					now, name, shortID, count, g.cfg.UnhealthyThreshold)
-				continue
-			}
+// stopSignal returns the signal for action=stop from the autoheal.stop.signal
+// label, or "" for the default SIGTERM.
+func stopSignal(labels map[string]string) string {
+	return strings.TrimSpace(labels["autoheal.stop.signal"])
+}
+
+// unhealthyThreshold returns the consecutive unhealthy detections required
+// before acting: the autoheal.unhealthy.threshold label when it is a positive
+// integer, otherwise AUTOHEAL_UNHEALTHY_THRESHOLD.
+func (g *Guardian) unhealthyThreshold(labels map[string]string) int {
+	if v, ok := labels["autoheal.unhealthy.threshold"]; ok {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			return parsed
 		}
+	}
+	return g.cfg().UnhealthyThreshold
+}
 
-		if g.shouldSkip(ctx, id, name, c.Labels) {
-			continue
+// swarmServiceID returns the Swarm service a task container belongs to, or ""
+// when Swarm handling is disabled or the container is not Swarm-managed.
+func (g *Guardian) swarmServiceID(labels map[string]string) string {
+	if !g.cfg().SwarmServices {
+		return ""
+	}
+	name, ok := labels["com.docker.swarm.service.name"]
+	if !ok {
+		return ""
+	}
+	if id := labels["com.docker.swarm.service.id"]; id != "" {
+		return id
+	}
+	return name
+}
+
+// checkUnhealthy finds unhealthy containers and handles them based on action labels.
+func (g *Guardian) checkUnhealthy(ctx context.Context) scanSummary {
+	var summary scanSummary
+
+	containers, err := g.docker.UnhealthyContainers(ctx, g.cfg().ContainerLabel, g.cfg().OnlyMonitorRunning)
+	if err != nil {
+		g.internalError(ctx, "failed to list unhealthy containers", err)
+		summary.ListErr = err
+		return summary
+	}
+
+	summary.Unhealthy = len(containers)
+	summary.Seen = make(map[string]bool, len(containers))
+	metrics.UnhealthyContainers.Set(float64(len(containers)))
+	g.observeUnhealthyCount(len(containers))
+	g.reportClosedCircuits(ctx, containers)
+	metrics.CircuitOpenContainers.Set(float64(g.tracker.CircuitOpenCount()))
+
+	for _, c := range orderByDependency(containers) {
+		if g.stopping() {
+			break // shutting down: finish the current container, start no more
 		}
+		summary.Seen[c.ID] = true
+		g.handleUnhealthy(ctx, c, &summary)
+	}
 
-		// Handle notify-only action
-		if action == "notify" {
-			g.notifier.Action(fmt.Sprintf("Container %s (%s) found to be unhealthy (action=notify)", name, shortID))
-			continue
+	return summary
+}
+
+// reportClosedCircuits announces circuits that auto-closed because their
+// restarts aged out of AUTOHEAL_RESTART_WINDOW, pairing with the circuit-open
+// alert. Names come from the scan when the container is still unhealthy.
+func (g *Guardian) reportClosedCircuits(ctx context.Context, containers []container.Summary) {
+	for _, id := range g.tracker.CloseElapsedCircuits() {
+		name := shortContainerID(id)
+		if i := slices.IndexFunc(containers, func(c container.Summary) bool { return c.ID == id }); i >= 0 && len(containers[i].Names) > 0 {
+			name = displayName(containers[i].Names)
+		} else if info, err := g.docker.InspectContainer(ctx, id); err == nil {
+			name = strings.TrimPrefix(info.Name, "/")
 		}
+		metrics.CircuitAutoClosedTotal.WithLabelValues(name).Inc()
+		msg := fmt.Sprintf("Container %s (%s) circuit auto-closed (budget window elapsed) - restarts allowed again", name, shortContainerID(id))
+		g.logFor(ctx).Info(msg)
+		if g.cfg().NotifyCircuitClose {
+			g.notifier.Action(g.notifyEvent(ctx, name, id, msg))
+		}
+	}
+}
+
+// reportRecovered sends the all-clear for a container that turned healthy
+// while its circuit was open, pairing with the circuit-open alert.
+func (g *Guardian) reportRecovered(ctx context.Context, id, name string) {
+	msg := fmt.Sprintf("Container %s (%s) recovered (healthy again) - circuit closed", name, shortContainerID(id))
+	g.logFor(ctx).Info(msg)
+	g.notifier.Recovery(g.notifyEvent(ctx, name, id, msg))
+}
+
+// claimHandling marks a container as being handled by handleUnhealthy.
+// Returns false if another scan or event already is.
+func (g *Guardian) claimHandling(id string) bool {
+	g.handlingMu.Lock()
+	defer g.handlingMu.Unlock()
+	if g.handling[id] {
+		return false
+	}
+	if g.handling == nil {
+		g.handling = make(map[string]bool)
+	}
+	g.handling[id] = true
+	return true
+}
+
+func (g *Guardian) releaseHandling(id string) {
+	g.handlingMu.Lock()
+	delete(g.handling, id)
+	g.handlingMu.Unlock()
+}
+
+// handleUnhealthy applies guards and the configured action to a single unhealthy container.
+func (g *Guardian) handleUnhealthy(ctx context.Context, c container.Summary, summary *scanSummary) {
+	if len(c.Names) == 0 {
+		return
+	}
+
+	// ID allow/deny lists override every other rule
+	name := displayName(c.Names)
+	if !g.inScope(c.ID, name) {
+		return
+	}
+
+	// Skip containers opted out via label
+	if c.Labels["autoheal"] == "False" {
+		return
+	}
+
+	id := c.ID
+	shortID := id[:12]
+	ctx = withNotifyContext(ctx, c.Labels)
+
+	if g.excluded(ctx, name, c.Labels) {
+		return
+	}
+
+	if g.inMaintenance(ctx, id, name, c.Labels) {
+		return
+	}
+
+	// A recovery loop already owns this container
+	if g.recovering(id) {
+		g.logFor(ctx).Infof("Container %s (%s) unhealthy - recovery in progress, skipping", name, shortID)
+		return
+	}
 
-		// Circuit breaker check (for restart and stop actions)
-		if allowed, reason := g.tracker.ShouldRestart(id); !allowed {
-			msg := g.tracker.FormatSkipReason(id, name, reason)
-			now := g.clock.Now().Format("02-01-2006 15:04:05")
-			fmt.Printf("%s %s\n", now, msg)
-			metrics.SkipsTotal.WithLabelValues(name, string(reason)).Inc()
-			if reason == SkipCircuit {
-				g.notifier.Action(fmt.Sprintf("[CRITICAL] %s", msg))
+	// A scan and a debounced event may reach the same container concurrently
+	if !g.claimHandling(id) {
+		g.logFor(ctx).Debug("container already being handled, skipping", "container", name, "id", shortID)
+		return
+	}
+	defer g.releaseHandling(id)
+
+	// Check per-container action label; health-check output can override it
+	action := containerAction(c.Labels)
+	healthLog, healthFetched := "", false
+	if rules := c.Labels[onMatchLabel]; rules != "" {
+		var err error
+		if healthLog, err = g.docker.ContainerHealthLog(ctx, id); err == nil {
+			healthFetched = true
+			if matched, ok := g.matchedAction(ctx, name, rules, healthLog); ok && matched != action {
+				g.logFor(ctx).Infof("Container %s (%s) health output matched %s - action=%s", name, shortID, onMatchLabel, matched)
+				action = matched
 			}
-			continue
 		}
+	}
+	if action == "none" {
+		return
+	}
+
+	if string(c.State) == "paused" {
+		g.logFor(ctx).Infof("Container %s (%s) is paused - skipping", name, shortID)
+		return
+	}
 
-		timeout := g.cfg.DefaultStopTimeout
-		if v, ok := c.Labels["autoheal.stop.timeout"]; ok {
-			if parsed, err := strconv.Atoi(v); err == nil {
-				timeout = parsed
+	if string(c.State) == "restarting" {
+		g.logFor(ctx).Infof("Container %s (%s) found to be restarting - don't restart", name, shortID)
+		return
+	}
+
+	// Check unhealthy threshold (default 1 = immediate action). Below it, an
+	// early notification fires once NOTIFY_UNHEALTHY_THRESHOLD is reached.
+	if threshold := g.unhealthyThreshold(c.Labels); threshold > 1 {
+		reached := g.tracker.RecordUnhealthy(id, threshold)
+		count := g.tracker.UnhealthyCount(id)
+		metrics.ContainerUnhealthyCount.WithLabelValues(name).Set(float64(count))
+		if !reached {
+			g.logFor(ctx).Debugf("Container %s (%s) unhealthy (%d/%d) - waiting for threshold", name, shortID, count, threshold)
+			if count == g.cfg().NotifyUnhealthyThreshold && shouldNotify(c.Labels) {
+				g.notifier.Action(g.notifyEvent(ctx, name, id, fmt.Sprintf("Unhealthy container %s (%s) detected (%d/%d) - action at threshold",
+					name, shortID, count, threshold)))
 			}
+			return
 		}
+	}
+
+	if g.Disabled() {
+		g.logFor(ctx).Infof("Container %s (%s) found to be unhealthy - actions disabled, skipping", name, shortID)
+		metrics.SkipsTotal.WithLabelValues(name, "disabled").Inc()
+		return
+	}
+
+	// Without inspect data both warmup checks fall back to acting straight away
+	info, inspectErr := g.docker.InspectContainer(ctx, id)
+
+	// Failing checks inside the container's own --health-start-period are expected
+	if left, ok := startPeriodRemaining(info, g.clock.Now()); inspectErr == nil && ok {
+		g.logFor(ctx).Infof("Container %s (%s) unhealthy within its healthcheck start period (%s left) - skipping", name, shortID, left.Round(time.Second))
+		g.notifier.Skip(g.decisionEvent(ctx, name, id, "skip", "start_period", fmt.Sprintf("Container %s (%s) skipped - healthcheck start period", name, shortID)))
+		metrics.SkipsTotal.WithLabelValues(name, "start_period").Inc()
+		g.recordDecision(ctx, name, id, "skip", "start_period")
+		return
+	}
 
-		// Handle stop action (quarantine)
-		if action == "stop" {
-			now := g.clock.Now().Format("02-01-2006 15:04:05")
-			fmt.Printf("%s Container %s (%s) found to be unhealthy - Stopping container (action=stop)\n", now, name, shortID)
-			notify := shouldNotify(c.Labels)
-			if err := g.docker.StopContainer(ctx, id, timeout); err != nil {
-				g.log.Error("failed to stop container", "container", name, "id", shortID, "error", err)
-				if notify {
-					g.notifier.Action(fmt.Sprintf("Container %s (%s) found to be unhealthy. Failed to stop (quarantine)!", name, shortID))
-				}
-				metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
-			} else {
-				if notify {
-					g.notifier.Action(fmt.Sprintf("Container %s (%s) found to be unhealthy. Stopped (quarantined).", name, shortID))
-				}
-				metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+	// Require the current failing streak to have lasted AUTOHEAL_UNHEALTHY_MIN_DURATION
+	if g.cfg().UnhealthyMinDuration > 0 && inspectErr == nil {
+		if since, ok := unhealthySince(info); ok {
+			minDuration := time.Duration(g.cfg().UnhealthyMinDuration) * time.Second
+			if age := g.clock.Since(since); age < minDuration {
+				g.logFor(ctx).Infof("Container %s (%s) unhealthy for %s (< %ds) - waiting", name, shortID, age.Round(time.Second), g.cfg().UnhealthyMinDuration)
+				return
 			}
-			g.tracker.RecordRestart(id)
-			continue
 		}
+	}
+
+	if g.shouldSkip(ctx, id, name, c.Labels) {
+		return
+	}
 
-		// Default: restart
-		now := g.clock.Now().Format("02-01-2006 15:04:05")
-		fmt.Printf("%s Container %s (%s) found to be unhealthy - Restarting container now with %ds timeout\n",
-			now, name, shortID, timeout)
+	// Handle notify-only action
+	if action == "notify" {
+		g.notifier.Action(g.decisionEvent(ctx, name, id, "notify", "success", fmt.Sprintf("Container %s (%s) found to be unhealthy (action=notify)", name, shortID)))
+		g.recordDecision(ctx, name, id, "notify", "success")
+		return
+	}
 
-		// Fetch healthcheck output before restart (for notification context)
-		healthSuffix := ""
-		if healthLog, err := g.docker.ContainerHealthLog(ctx, id); err == nil && healthLog != "" {
-			healthSuffix = " Health output: " + healthLog
+	// Circuit breaker check (for restart and stop actions)
+	if allowed, reason := g.tracker.ShouldRestart(id); !allowed {
+		msg := g.tracker.FormatSkipReason(id, name, reason)
+		g.logFor(ctx).Info(msg)
+		metrics.SkipsTotal.WithLabelValues(name, string(reason)).Inc()
+		g.recordDecision(ctx, name, id, "skip", string(reason))
+		if reason == SkipCircuit {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "skip", string(reason), fmt.Sprintf("[CRITICAL] %s", msg)))
 		}
+		return
+	}
+
+	// Fetch healthcheck output before restart (for notification context)
+	if !healthFetched && g.cfg().NotifyIncludeHealthLog {
+		healthLog, _ = g.docker.ContainerHealthLog(ctx, id)
+	}
+	healthSuffix := ""
+	if healthLog != "" && g.cfg().NotifyIncludeHealthLog {
+		healthSuffix = " Health output: " + healthLog
+	}
+
+	g.runAction(ctx, c, name, action, actionTrigger{
+		reason:       "found to be unhealthy",
+		healthSuffix: healthSuffix,
+		notify:       shouldNotify(c.Labels),
+		siblings:     true,
+	}, summary)
+}
+
+// actionTrigger describes why runAction is acting on a container.
+type actionTrigger struct {
+	reason       string // completes "Container web (abc123)", e.g. "found to be unhealthy"
+	healthSuffix string // appended to notifications, empty unless NOTIFY_INCLUDE_HEALTH_LOG
+	notify       bool
+	siblings     bool // restart compose siblings too; a bulk restart reaches them itself
+}
+
+// runAction performs a container's configured action (stop, Swarm service
+// update, recreate, hard restart or restart) once the caller's guards and
+// the circuit breaker have let it through, and returns the result: success,
+// failure or a skip reason.
+func (g *Guardian) runAction(ctx context.Context, c container.Summary, name, action string, t actionTrigger, summary *scanSummary) string {
+	id := c.ID
+	shortID := id[:12]
+	notify, healthSuffix := t.notify, t.healthSuffix
+	timeout := g.stopTimeout(c.Labels)
+
+	// Handle stop action (quarantine)
+	if action == "stop" {
+		signal := stopSignal(c.Labels)
+		if signal != "" {
+			g.logFor(ctx).Infof("Container %s (%s) %s - Stopping container with %s (action=stop)", name, shortID, t.reason, signal)
+		} else {
+			g.logFor(ctx).Infof("Container %s (%s) %s - Stopping container (action=stop)", name, shortID, t.reason)
+		}
+		result, advance := "success", true
+		if err := g.docker.StopContainerSignal(ctx, id, signal, timeout); err != nil {
+			if g.containerGone(ctx, id, name, err) {
+				return "container-gone"
+			}
+			result = "failure"
+			var class docker.ErrorClass
+			class, advance = g.actionFailed(name, "stop", err)
+			g.logFor(ctx).Error("failed to stop container", "container", name, "id", shortID, "class", class, "error", err)
+			if notify {
+				g.notifier.Action(g.decisionEvent(ctx, name, id, "stop", "failure", fmt.Sprintf("Container %s (%s) %s. Failed to stop (quarantine): %s!", name, shortID, t.reason, class.Describe())))
+			}
+			metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+			g.recordDecision(ctx, name, id, "stop", "failure")
+		} else {
+			if notify {
+				g.notifier.Action(g.decisionEvent(ctx, name, id, "stop", "success", fmt.Sprintf("Container %s (%s) %s. Stopped (quarantined).", name, shortID, t.reason)))
+			}
+			metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+			g.recordDecision(ctx, name, id, "stop", "success")
+			g.logFor(ctx).Info("stopped container", "container", name, "id", shortID)
+			g.recordQuarantine(id)
+			summary.Stopped++
+		}
+		if advance {
+			g.recordRestart(id, name)
+		}
+		return result
+	}
+
+	// Swarm tasks: restarting the task is futile, force a service update instead
+	if serviceID := g.swarmServiceID(c.Labels); serviceID != "" {
+		service := c.Labels["com.docker.swarm.service.name"]
+		// One update replaces every task, so unhealthy replicas of the same
+		// service share a tracker entry and its backoff instead of each
+		// forcing another rolling update
+		serviceKey := "service:" + serviceID
+		if allowed, reason := g.tracker.ShouldRestart(serviceKey); !allowed {
+			g.logFor(ctx).Infof("Container %s (%s) %s - Swarm service %s was force-updated recently (%s), not updating again", name, shortID, t.reason, service, reason)
+			metrics.SkipsTotal.WithLabelValues(name, "service-updated").Inc()
+			g.recordDecision(ctx, name, id, "skip", "service-updated")
+			return "service-updated"
+		}
+		g.logFor(ctx).Infof("Container %s (%s) %s - Forcing update of Swarm service %s", name, shortID, t.reason, service)
 
-		notify := shouldNotify(c.Labels)
 		start := time.Now()
-		if err := g.docker.RestartContainer(ctx, id, timeout); err != nil {
-			g.log.Error("failed to restart container", "container", name, "id", shortID, "error", err)
+		result, advance := "success", true
+		if err := g.docker.ForceServiceUpdate(ctx, serviceID); err != nil {
+			result = "failure"
+			var class docker.ErrorClass
+			class, advance = g.actionFailed(name, "service-update", err)
+			g.logFor(ctx).Error("failed to update swarm service", "container", name, "service", service, "class", class, "error", err)
 			if notify {
-				g.notifier.Action(fmt.Sprintf("Container %s (%s) found to be unhealthy. Failed to restart the container!%s", name, shortID, healthSuffix))
+				g.notifier.Action(g.decisionEvent(ctx, name, id, "service-update", "failure", fmt.Sprintf("Container %s (%s) %s. Failed to force-update Swarm service %s: %s!%s", name, shortID, t.reason, service, class.Describe(), healthSuffix)))
 			}
 			metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+			g.recordDecision(ctx, name, id, "service-update", "failure")
 		} else {
 			if notify {
-				g.notifier.Action(fmt.Sprintf("Container %s (%s) found to be unhealthy. Successfully restarted the container!%s", name, shortID, healthSuffix))
+				g.notifier.Action(g.decisionEvent(ctx, name, id, "service-update", "success", fmt.Sprintf("Container %s (%s) %s. Successfully force-updated Swarm service %s!%s", name, shortID, t.reason, service, healthSuffix)))
 			}
 			metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+			g.logFor(ctx).Info("force-updated swarm service", "container", name, "service", service)
+			g.recordDecision(ctx, name, id, "service-update", "success")
+			g.resetUnhealthy(id, name)
+			summary.Restarted++
 		}
 		metrics.RestartDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
 
-		g.tracker.RecordRestart(id)
-		g.runPostRestartScript(name, shortID, string(c.State), timeout)
+		if advance {
+			g.recordRestart(id, name)
+			g.tracker.RecordRestart(serviceKey)
+		}
+		g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: id, Action: "service-update", Result: result, State: string(c.State), Timeout: timeout})
+		return result
+	}
+
+	// autoheal.action=recreate, or restarts keep failing: recreate the container
+	if action == "recreate" || g.shouldRecreate(id) {
+		return g.recreateUnhealthy(ctx, c, name, t, action != "recreate", summary)
+	}
+
+	if action == "hardrestart" {
+		return g.hardRestartUnhealthy(ctx, c, name, t, summary)
+	}
+
+	// Default: restart
+	g.logFor(ctx).Infof("Container %s (%s) %s - Restarting container now with %ds timeout", name, shortID, t.reason, timeout)
+
+	start := time.Now()
+	restarted, advance := false, true
+	if err := g.docker.RestartContainer(ctx, id, timeout); err != nil {
+		if g.containerGone(ctx, id, name, err) {
+			return "container-gone"
+		}
+		var class docker.ErrorClass
+		class, advance = g.actionFailed(name, "restart", err)
+		g.logFor(ctx).Error("failed to restart container", "container", name, "id", shortID, "class", class, "error", err)
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "restart", "failure", fmt.Sprintf("Container %s (%s) %s. Failed to restart the container: %s!%s", name, shortID, t.reason, class.Describe(), healthSuffix)))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "failure").Inc()
+		g.recordDecision(ctx, name, id, "restart", "failure")
+		if advance {
+			g.tracker.RecordRestartFailure(id)
+		}
+	} else {
+		if notify {
+			g.notifier.Action(g.decisionEvent(ctx, name, id, "restart", "success", fmt.Sprintf("Container %s (%s) %s. Successfully restarted the container!%s", name, shortID, t.reason, healthSuffix)))
+		}
+		metrics.RestartsTotal.WithLabelValues(name, "success").Inc()
+		g.logFor(ctx).Info("restarted container", "container", name, "id", shortID)
+		g.recordDecision(ctx, name, id, "restart", "success")
+		g.tracker.ResetRestartFailures(id)
+		g.resetUnhealthy(id, name)
+		g.watchCrashloop(ctx, id, name, notify)
+		restarted = true
+		summary.Restarted++
+	}
+	metrics.RestartDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+	if advance {
+		g.recordRestart(id, name)
+	}
+	result := "failure"
+	if restarted {
+		result = "success"
+	}
+	g.runPostRestartScript(postRestart{Container: name, ShortID: shortID, ID: id, Action: "restart", Result: result, State: string(c.State), Timeout: timeout})
+
+	if restarted {
+		if t.siblings {
+			g.restartComposeSiblings(ctx, id, name, c.Labels)
+		}
+		g.startRecovery(ctx, c, name, notify)
 	}
+	return result
 }
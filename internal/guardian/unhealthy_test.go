@@ -3,13 +3,20 @@ package guardian
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
+	"github.com/Will-Luck/Docker-Guardian/internal/docker"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	cerrdefs "github.com/containerd/errdefs"
 	"github.com/moby/moby/api/types/container"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestCheckUnhealthy_RestartsContainer(t *testing.T) {
@@ -34,13 +41,13 @@ func TestCheckUnhealthy_RestartsContainer(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkUnhealthy(context.Background())
 
@@ -77,13 +84,13 @@ func TestCheckUnhealthy_SkipsOptedOut(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkUnhealthy(context.Background())
 
@@ -114,13 +121,13 @@ func TestCheckUnhealthy_SkipsEmptyNames(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	// Should not panic
 	g.checkUnhealthy(context.Background())
@@ -152,13 +159,13 @@ func TestCheckUnhealthy_SkipsRestarting(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkUnhealthy(context.Background())
 
@@ -190,13 +197,13 @@ func TestCheckUnhealthy_RestartFailure(t *testing.T) {
 	dock.restartErr["abcdef1234567890abcdef"] = errors.New("restart failed")
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkUnhealthy(context.Background())
 
@@ -227,13 +234,13 @@ func TestCheckUnhealthy_CustomTimeout(t *testing.T) {
 	}
 
 	g := &Guardian{
-		cfg:      cfg,
 		docker:   dock,
 		notifier: notif,
-		log:      logging.New(false),
+		log:      logging.New(false, slog.LevelDebug),
 		clock:    clk,
 		tracker:  NewRestartTracker(DefaultTrackerConfig(), clk),
 	}
+	g.conf.Store(cfg)
 
 	g.checkUnhealthy(context.Background())
 
@@ -241,3 +248,853 @@ func TestCheckUnhealthy_CustomTimeout(t *testing.T) {
 		t.Fatalf("expected 1 restart, got %d", len(dock.restartCalls))
 	}
 }
+
+func TestCheckUnhealthy_StopActionPassesSignal(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   string
+	}{
+		{"signal label", map[string]string{"autoheal.action": "stop", "autoheal.stop.signal": "SIGQUIT"}, "SIGQUIT"},
+		{"no signal label", map[string]string{"autoheal.action": "stop"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+			dock := newMockDocker()
+			dock.unhealthyContainers = []container.Summary{
+				{ID: "abcdef1234567890abcdef", Names: []string{"/dumper"}, State: "running", Labels: tt.labels},
+			}
+			g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+			g.checkUnhealthy(context.Background())
+
+			if len(dock.stopCalls) != 1 || len(dock.restartCalls) != 0 {
+				t.Fatalf("expected one stop and no restart, got stops %v restarts %v", dock.stopCalls, dock.restartCalls)
+			}
+			if !slices.Equal(dock.stopSignals, []string{tt.want}) {
+				t.Errorf("stop signals = %q, want [%q]", dock.stopSignals, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckUnhealthy_SwarmServiceUpdate(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+		SwarmServices:      true,
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{
+			ID:    "swarm01234567890abcdef",
+			Names: []string{"/web.1.xyz"},
+			State: "running",
+			Labels: map[string]string{
+				"com.docker.swarm.service.name": "web",
+				"com.docker.swarm.service.id":   "svc123",
+			},
+		},
+		{
+			ID:     "plain01234567890abcdef",
+			Names:  []string{"/plain-app"},
+			State:  "running",
+			Labels: map[string]string{},
+		},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.serviceUpdateCalls) != 1 || dock.serviceUpdateCalls[0] != "svc123" {
+		t.Errorf("expected service update for svc123, got %v", dock.serviceUpdateCalls)
+	}
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "plain01234567890abcdef" {
+		t.Errorf("expected container restart only for plain container, got %v", dock.restartCalls)
+	}
+}
+
+func TestCheckUnhealthy_SwarmServiceUpdatedOncePerService(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+		SwarmServices:      true,
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	labels := map[string]string{
+		"com.docker.swarm.service.name": "web",
+		"com.docker.swarm.service.id":   "svc123",
+	}
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "swarm01234567890abcdef", Names: []string{"/web.1.xyz"}, State: "running", Labels: labels},
+		{ID: "swarm11234567890abcdef", Names: []string{"/web.2.abc"}, State: "running", Labels: labels},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.serviceUpdateCalls) != 1 {
+		t.Fatalf("expected one service update for two unhealthy replicas, got %v", dock.serviceUpdateCalls)
+	}
+	if len(notif.actions) != 1 {
+		t.Errorf("expected one notification for the update, got %v", notif.actions)
+	}
+
+	// The next scan, while the update rolls out, leaves the service alone
+	clk.Advance(time.Second)
+	g.checkUnhealthy(context.Background())
+	if len(dock.serviceUpdateCalls) != 1 {
+		t.Errorf("expected the service backoff to hold off another update, got %v", dock.serviceUpdateCalls)
+	}
+
+	// Once the backoff has passed, a still-unhealthy replica updates it again
+	clk.Advance(time.Hour)
+	g.checkUnhealthy(context.Background())
+	if len(dock.serviceUpdateCalls) != 2 {
+		t.Errorf("expected a second update after the backoff, got %v", dock.serviceUpdateCalls)
+	}
+}
+
+func TestCheckUnhealthy_SwarmClassifiesUpdateErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		err         error
+		wantText    string
+		wantBackoff bool
+	}{
+		{"permission", fmt.Errorf("update: %w", cerrdefs.ErrPermissionDenied), "permission denied", false},
+		{"other", errors.New("boom"), "unexpected error", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, SwarmServices: true}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+
+			id := "swarm01234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/web.1.xyz"}, State: "running",
+				Labels: map[string]string{"com.docker.swarm.service.name": "web", "com.docker.swarm.service.id": "svc123"}}}
+			dock.serviceUpdateErr["svc123"] = tt.err
+
+			g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+			g.checkUnhealthy(context.Background())
+
+			if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Failed to force-update Swarm service web: "+tt.wantText) {
+				t.Errorf("expected failure notification naming %q, got %v", tt.wantText, notif.actions)
+			}
+			if advanced := g.tracker.BackoffRemaining("service:svc123") > 0; advanced != tt.wantBackoff {
+				t.Errorf("service backoff advanced = %v, want %v", advanced, tt.wantBackoff)
+			}
+			if advanced := g.tracker.BackoffRemaining(id) > 0; advanced != tt.wantBackoff {
+				t.Errorf("container backoff advanced = %v, want %v", advanced, tt.wantBackoff)
+			}
+		})
+	}
+}
+
+func TestCheckUnhealthy_SwarmDisabledRestartsTask(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+		SwarmServices:      false,
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{
+			ID:     "swarm01234567890abcdef",
+			Names:  []string{"/web.1.xyz"},
+			State:  "running",
+			Labels: map[string]string{"com.docker.swarm.service.name": "web"},
+		},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.serviceUpdateCalls) != 0 {
+		t.Errorf("expected no service updates with swarm handling disabled, got %v", dock.serviceUpdateCalls)
+	}
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected 1 container restart, got %d", len(dock.restartCalls))
+	}
+}
+
+func scopeTestContainers() []container.Summary {
+	return []container.Summary{
+		{ID: "aaaaaa1234567890aaaaaa", Names: []string{"/web-1"}, State: "running"},
+		{ID: "bbbbbb1234567890bbbbbb", Names: []string{"/web-2"}, State: "running"},
+		{ID: "cccccc1234567890cccccc", Names: []string{"/db"}, State: "running"},
+	}
+}
+
+func TestCheckUnhealthy_DenylistNeverActioned(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:       "all",
+		DefaultStopTimeout:   10,
+		ContainerIDDenylist:  []string{"cccccc123456", "web-2"},
+		ContainerIDAllowlist: []string{"web-*", "db"}, // denylist still wins
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	dock.unhealthyContainers = scopeTestContainers()
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "aaaaaa1234567890aaaaaa" {
+		t.Errorf("expected only web-1 restarted, got %v", dock.restartCalls)
+	}
+}
+
+func TestCheckUnhealthy_AllowlistRestrictsScope(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:       "all",
+		DefaultStopTimeout:   10,
+		ContainerIDAllowlist: []string{"bbbbbb"},
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+	dock.unhealthyContainers = scopeTestContainers()
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "bbbbbb1234567890bbbbbb" {
+		t.Errorf("expected only allowlisted web-2 restarted, got %v", dock.restartCalls)
+	}
+	if len(notif.actions) != 1 {
+		t.Errorf("expected 1 notification, got %v", notif.actions)
+	}
+}
+
+func TestIsExcluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		label    string
+		patterns []string
+		cName    string
+		labels   map[string]string
+		want     bool
+	}{
+		{"no config", "", nil, "batch-1", nil, false},
+		{"exact name", "", []string{"batch-1"}, "batch-1", nil, true},
+		{"glob suffix", "", []string{"batch-*"}, "batch-nightly", nil, true},
+		{"glob prefix", "", []string{"*-job"}, "cleanup-job", nil, true},
+		{"glob no match", "", []string{"batch-*"}, "web", nil, false},
+		{"leading slash name", "", []string{"batch-*"}, "/batch-1", nil, true},
+		{"leading slash pattern", "", []string{"/batch-1"}, "batch-1", nil, true},
+		{"empty pattern ignored", "", []string{""}, "", nil, false},
+		{"star matches all", "", []string{"*"}, "anything", nil, true},
+		{"label present", "guardian.exclude", nil, "web", map[string]string{"guardian.exclude": "true"}, true},
+		{"label empty value", "guardian.exclude", nil, "web", map[string]string{"guardian.exclude": ""}, true},
+		{"label false", "guardian.exclude", nil, "web", map[string]string{"guardian.exclude": "false"}, false},
+		{"label absent", "guardian.exclude", nil, "web", map[string]string{"autoheal": "true"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGuardian(&config.Config{ExcludeLabel: tt.label, ExcludeNames: tt.patterns}, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+			if got := g.isExcluded(tt.cName, tt.labels); got != tt.want {
+				t.Errorf("isExcluded(%q, %v) = %v, want %v", tt.cName, tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckUnhealthy_ExcludedNeverActioned(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:     "all",
+		DefaultStopTimeout: 10,
+		ExcludeNames:       []string{"web-*"},
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	dock.unhealthyContainers = scopeTestContainers()
+
+	g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+	g.checkUnhealthy(context.Background())
+
+	if len(dock.restartCalls) != 1 || dock.restartCalls[0] != "cccccc1234567890cccccc" {
+		t.Errorf("expected only db restarted, got %v", dock.restartCalls)
+	}
+}
+
+func TestCheckUnhealthy_ThresholdCountsResetAfterRestartAndHealthy(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, UnhealthyThreshold: 3}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+
+	// Two detections, then the container recovers on its own
+	g.checkUnhealthy(context.Background())
+	g.checkUnhealthy(context.Background())
+	g.handleEvent(context.Background(), docker.ContainerEvent{ContainerID: id, ContainerName: "app", Action: "health_status", HealthStatus: "healthy"})
+	if n := g.tracker.UnhealthyCount(id); n != 0 {
+		t.Fatalf("healthy event should reset the count, got %d", n)
+	}
+
+	for range 3 {
+		g.checkUnhealthy(context.Background())
+	}
+	if len(dock.restartCalls) != 1 {
+		t.Fatalf("expected a restart after three fresh detections, got %v", dock.restartCalls)
+	}
+	if n := g.tracker.UnhealthyCount(id); n != 0 {
+		t.Errorf("successful restart should reset the count, got %d", n)
+	}
+
+	// Still unhealthy once the backoff has passed: the threshold applies again
+	clk.Advance(15 * time.Second)
+	g.checkUnhealthy(context.Background())
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 1 {
+		t.Errorf("expected no restart before the threshold is reached again, got %v", dock.restartCalls)
+	}
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("expected a second restart at the threshold, got %v", dock.restartCalls)
+	}
+}
+
+func TestCheckUnhealthy_ThresholdLabelOverridesGlobal(t *testing.T) {
+	paths := []struct {
+		name  string
+		check func(g *Guardian, id string)
+	}{
+		{"scan", func(g *Guardian, _ string) { g.checkUnhealthy(context.Background()) }},
+		{"event", func(g *Guardian, id string) { g.checkContainerByID(context.Background(), id) }},
+	}
+	for _, p := range paths {
+		t.Run(p.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, UnhealthyThreshold: 1}
+			dock := newMockDocker()
+			id := "abcdef1234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{
+				{ID: id, Names: []string{"/flaky"}, State: "running", Labels: map[string]string{"autoheal.unhealthy.threshold": "3"}},
+			}
+			g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(time.Now()))
+
+			for i := 1; i <= 2; i++ {
+				p.check(g, id)
+				if slices.Contains(dock.restartCalls, id) {
+					t.Fatalf("flaky restarted after %d detection(s), want 3", i)
+				}
+			}
+			p.check(g, id)
+			if !slices.Contains(dock.restartCalls, id) {
+				t.Errorf("expected flaky restarted at the third detection, got %v", dock.restartCalls)
+			}
+		})
+	}
+
+	// An invalid label falls back to the global threshold of 1
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, UnhealthyThreshold: 1}
+	g := newTestGuardian(cfg, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+	if got := g.unhealthyThreshold(map[string]string{"autoheal.unhealthy.threshold": "soon"}); got != 1 {
+		t.Errorf("invalid label threshold = %d, want the global 1", got)
+	}
+}
+
+func TestCheckUnhealthy_NotifyThresholdBeforeAction(t *testing.T) {
+	cfg := &config.Config{
+		ContainerLabel:           "all",
+		DefaultStopTimeout:       10,
+		UnhealthyThreshold:       3,
+		NotifyUnhealthyThreshold: 1,
+	}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/test-app"}, State: "running"},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+
+	// Detection 1: early notification, no action
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 0 {
+		t.Fatalf("expected no restart at count 1, got %d", len(dock.restartCalls))
+	}
+	if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "detected (1/3)") {
+		t.Fatalf("expected early notification at count 1, got %v", notif.actions)
+	}
+
+	// Detection 2: nothing new
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 0 || len(notif.actions) != 1 {
+		t.Fatalf("expected no action or notification at count 2, got restarts=%d notifications=%v",
+			len(dock.restartCalls), notif.actions)
+	}
+
+	// Detection 3: action
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 1 {
+		t.Fatalf("expected restart at count 3, got %d", len(dock.restartCalls))
+	}
+	if len(notif.actions) != 2 || !strings.Contains(notif.actions[1], "Successfully restarted") {
+		t.Errorf("expected restart notification at count 3, got %v", notif.actions)
+	}
+}
+
+func healthLogInspect(now time.Time, failingFor ...time.Duration) container.InspectResponse {
+	log := []*container.HealthcheckResult{
+		{Start: now.Add(-10 * time.Minute), End: now.Add(-10 * time.Minute), ExitCode: 0},
+	}
+	for _, d := range failingFor {
+		log = append(log, &container.HealthcheckResult{Start: now.Add(-d), End: now.Add(-d + time.Second), ExitCode: 1})
+	}
+	return container.InspectResponse{
+		State: &container.State{Health: &container.Health{Status: container.Unhealthy, Log: log}},
+	}
+}
+
+func TestUnhealthySince(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	since, ok := unhealthySince(healthLogInspect(now, 90*time.Second, 60*time.Second, 30*time.Second))
+	if !ok || !since.Equal(now.Add(-90*time.Second)) {
+		t.Errorf("got %v (%v), want start of earliest failing entry", since, ok)
+	}
+
+	if _, ok := unhealthySince(healthLogInspect(now)); ok {
+		t.Error("expected no failing streak when the latest check passed")
+	}
+}
+
+func TestCheckUnhealthy_MinDuration(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := "abcdef1234567890abcdef"
+
+	tests := []struct {
+		name        string
+		failingFor  []time.Duration
+		wantRestart bool
+	}{
+		{"inside window", []time.Duration{20 * time.Second, 10 * time.Second}, false},
+		{"outside window", []time.Duration{90 * time.Second, 60 * time.Second, 30 * time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, UnhealthyMinDuration: 60}
+			dock := newMockDocker()
+			dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/flappy"}, State: "running"}}
+			dock.inspectResults[id] = healthLogInspect(now, tt.failingFor...)
+
+			g := newTestGuardian(cfg, dock, &mockNotifier{}, newMockClock(now))
+			g.checkUnhealthy(context.Background())
+
+			if got := len(dock.restartCalls) == 1; got != tt.wantRestart {
+				t.Errorf("restarted = %v, want %v", got, tt.wantRestart)
+			}
+		})
+	}
+}
+
+func TestCheckUnhealthy_HonoursHealthcheckStartPeriod(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	id := "abcdef1234567890abcdef"
+
+	tests := []struct {
+		name        string
+		startedAgo  time.Duration
+		wantRestart bool
+	}{
+		{"inside start period", 20 * time.Second, false},
+		{"outside start period", 90 * time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+			dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/slowboot"}, State: "running"}}
+			dock.inspectResults[id] = container.InspectResponse{
+				State:  &container.State{Running: true, StartedAt: now.Add(-tt.startedAgo).Format(time.RFC3339Nano)},
+				Config: &container.Config{Healthcheck: &container.HealthConfig{StartPeriod: time.Minute}},
+			}
+
+			g := newTestGuardian(cfg, dock, notif, newMockClock(now))
+			g.checkUnhealthy(context.Background())
+
+			if got := len(dock.restartCalls) == 1; got != tt.wantRestart {
+				t.Errorf("restarted = %v, want %v", got, tt.wantRestart)
+			}
+			skipped := len(notif.skips) == 1 && strings.Contains(notif.skips[0], "healthcheck start period")
+			if skipped == tt.wantRestart {
+				t.Errorf("start period skip notification = %v, skips %v", skipped, notif.skips)
+			}
+		})
+	}
+}
+
+func TestHandleUnhealthy_NotifyOnlyLabel(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/payments"}, State: "running",
+			Labels: map[string]string{"autoheal.notify.only": "PagerDuty, telegram"}},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/web"}, State: "running"},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(notif.actionEvents) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(notif.actionEvents))
+	}
+	pinned := notif.actionEvents[0].Services
+	if len(pinned) != 2 || pinned[0] != "pagerduty" || pinned[1] != "telegram" {
+		t.Errorf("payments should be pinned to [pagerduty telegram], got %v", pinned)
+	}
+	if s := notif.actionEvents[1].Services; len(s) != 0 {
+		t.Errorf("unlabelled container should use all services, got %v", s)
+	}
+}
+
+func TestHandleUnhealthy_ComposeFieldsInEvent(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/shop-web-1"}, State: "running",
+			Labels: map[string]string{composeProjectLabel: "shop", composeServiceLabel: "web"}},
+		{ID: "bbbbbb1234567890abcdef", Names: []string{"/standalone"}, State: "running"},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(notif.actionEvents) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(notif.actionEvents))
+	}
+	if e := notif.actionEvents[0]; e.Project != "shop" || e.Service != "web" {
+		t.Errorf("compose container: project %q service %q, want shop/web", e.Project, e.Service)
+	}
+	if e := notif.actionEvents[1]; e.Project != "" || e.Service != "standalone" {
+		t.Errorf("plain container: project %q service %q, want empty project and the container name", e.Project, e.Service)
+	}
+}
+
+func TestDisplayName_PrefersStableFriendlyName(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"/web"}, "web"},
+		{[]string{"/proxy/web", "/web"}, "web"},
+		{[]string{"/web", "/proxy/web"}, "web"},
+		{[]string{"/web-primary", "/web"}, "web"},
+		{[]string{"/b-app", "/a-app"}, "a-app"},
+		{[]string{"/proxy/web"}, "proxy/web"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := displayName(tt.names); got != tt.want {
+			t.Errorf("displayName(%v) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestHandleUnhealthy_UsesDisplayName(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	dock.unhealthyContainers = []container.Summary{
+		{ID: "abcdef1234567890abcdef", Names: []string{"/proxy/api", "/api"}, State: "running"},
+	}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.checkUnhealthy(context.Background())
+
+	if len(notif.actionEvents) != 1 || notif.actionEvents[0].Container != "api" {
+		t.Fatalf("expected notification for container %q, got %+v", "api", notif.actionEvents)
+	}
+}
+
+func TestHandleUnhealthy_ContainerGoneIsSkip(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/deployed-away"}, State: "running"}}
+	dock.restartErr[id] = fmt.Errorf("restart: %w", cerrdefs.ErrNotFound.WithMessage("No such container: "+id))
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	g.history = newDecisionLog(10)
+	g.checkUnhealthy(context.Background())
+
+	if len(notif.actions) != 0 {
+		t.Errorf("expected no failure notification, got %v", notif.actions)
+	}
+	if !g.history.contains("deployed-away", "skip", "container-gone") {
+		t.Errorf("expected container-gone skip, got %v", g.Decisions())
+	}
+	if g.history.contains("deployed-away", "restart", "failure") {
+		t.Error("container-gone must not be recorded as a restart failure")
+	}
+	if _, tracked := g.tracker.Stats(id); tracked {
+		t.Error("container-gone must not consume restart budget")
+	}
+}
+
+func TestHandleUnhealthy_OnMatchOverridesAction(t *testing.T) {
+	rules := `disk.full=notify; connection refused=restart`
+	tests := []struct {
+		name        string
+		output      string
+		wantRestart bool
+		wantNotify  string
+	}{
+		{"matching pattern notifies only", "write failed: disk full", false, "action=notify"},
+		{"non-matching uses default restart", "HTTP 500 from /health", true, "Successfully restarted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+			clk := newMockClock(time.Now())
+
+			id := "abcdef1234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{
+				{ID: id, Names: []string{"/app"}, State: "running", Labels: map[string]string{onMatchLabel: rules}},
+			}
+			dock.healthLogResults[id] = tt.output
+
+			g := newTestGuardian(cfg, dock, notif, clk)
+			g.checkUnhealthy(context.Background())
+
+			if restarted := len(dock.restartCalls) == 1; restarted != tt.wantRestart {
+				t.Errorf("restarted = %v, want %v (calls %v)", restarted, tt.wantRestart, dock.restartCalls)
+			}
+			if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], tt.wantNotify) {
+				t.Errorf("expected one notification containing %q, got %v", tt.wantNotify, notif.actions)
+			}
+		})
+	}
+}
+
+func TestCheckUnhealthy_IncludesHealthLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		include bool
+		output  string
+		want    string
+	}{
+		{"enabled appends output", true, "curl: (7) Failed to connect to localhost port 8080", "Successfully restarted the container! Health output: curl: (7) Failed to connect to localhost port 8080"},
+		{"enabled without a health log", true, "", "Successfully restarted the container!"},
+		{"disabled omits output", false, "curl: (7) Failed to connect to localhost port 8080", "Successfully restarted the container!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, NotifyIncludeHealthLog: tt.include}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+
+			id := "abcdef1234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+			dock.healthLogResults[id] = tt.output
+
+			g := newTestGuardian(cfg, dock, notif, newMockClock(time.Now()))
+			g.checkUnhealthy(context.Background())
+
+			if len(notif.actions) != 1 || !strings.HasSuffix(notif.actions[0], tt.want) {
+				t.Errorf("expected one notification ending %q, got %v", tt.want, notif.actions)
+			}
+		})
+	}
+}
+
+func TestMatchedAction_IgnoresInvalidEntries(t *testing.T) {
+	g := newTestGuardian(&config.Config{}, newMockDocker(), &mockNotifier{}, newMockClock(time.Now()))
+
+	rules := "([=notify; timeout=reboot; noequals; time(out)?=stop"
+	if action, ok := g.matchedAction(context.Background(), "app", rules, "probe timeout"); !ok || action != "stop" {
+		t.Errorf("got %q/%v, want stop from the first valid matching entry", action, ok)
+	}
+	if _, ok := g.matchedAction(context.Background(), "app", rules, "all fine"); ok {
+		t.Error("expected no match")
+	}
+}
+
+func TestHandleUnhealthy_ClassifiesRestartErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantText    string
+		wantCircuit bool // failure advances backoff/budget
+	}{
+		{"permission", fmt.Errorf("restart: %w", cerrdefs.ErrPermissionDenied), "permission denied", false},
+		{"conflict", fmt.Errorf("restart: %w", cerrdefs.ErrConflict), "conflicting container state", false},
+		{"timeout", fmt.Errorf("restart: %w", context.DeadlineExceeded), "timed out", true},
+		{"other", errors.New("boom"), "unexpected error", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+			dock := newMockDocker()
+			notif := &mockNotifier{}
+			clk := newMockClock(time.Now())
+
+			id := "abcdef1234567890abcdef"
+			dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+			dock.restartErr[id] = tt.err
+
+			g := newTestGuardian(cfg, dock, notif, clk)
+			g.checkUnhealthy(context.Background())
+
+			if len(notif.actions) != 1 || !strings.Contains(notif.actions[0], "Failed to restart the container: "+tt.wantText) {
+				t.Errorf("expected failure notification naming %q, got %v", tt.wantText, notif.actions)
+			}
+			if advanced := g.tracker.BackoffRemaining(id) > 0; advanced != tt.wantCircuit {
+				t.Errorf("circuit advanced = %v, want %v", advanced, tt.wantCircuit)
+			}
+			if failures := g.tracker.RestartFailures(id); (failures == 1) != tt.wantCircuit {
+				t.Errorf("consecutive restart failures = %d, want advanced=%v", failures, tt.wantCircuit)
+			}
+		})
+	}
+}
+
+func TestContainerGone_CountsRaceInScanAndEventPaths(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "racerr1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/raced"}, State: "running"}}
+	dock.restartErr[id] = fmt.Errorf("restart: %w", cerrdefs.ErrNotFound)
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	races := metrics.ActionRacesTotal.WithLabelValues("raced")
+	before := testutil.ToFloat64(races)
+
+	g.checkUnhealthy(context.Background())         // scan path
+	g.checkContainerByID(context.Background(), id) // event path
+
+	if got := testutil.ToFloat64(races) - before; got != 2 {
+		t.Errorf("docker_guardian_action_races_total increased by %v, want 2", got)
+	}
+	if len(notif.actions) != 0 {
+		t.Errorf("expected no failure notification, got %v", notif.actions)
+	}
+}
+
+func TestCheckUnhealthy_NotifiesCircuitAutoClose(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, NotifyCircuitClose: true}
+	dock := newMockDocker()
+	notif := &mockNotifier{}
+	clk := newMockClock(time.Now())
+
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/flappy"}, State: "running"}}
+
+	g := newTestGuardian(cfg, dock, notif, clk)
+	trackerCfg := DefaultTrackerConfig()
+	trackerCfg.RestartBudget = 1
+	g.tracker = NewRestartTracker(trackerCfg, clk)
+
+	g.checkUnhealthy(context.Background()) // restart
+	clk.Advance(11 * time.Second)
+	g.checkUnhealthy(context.Background()) // circuit opens
+	if !g.tracker.IsCircuitOpen(id) {
+		t.Fatal("expected circuit open")
+	}
+
+	// Container recovers; the window rolls over while nothing is unhealthy
+	dock.unhealthyContainers = nil
+	dock.inspectResults[id] = container.InspectResponse{Name: "/flappy"}
+	clk.Advance(trackerCfg.RestartWindow)
+	g.checkUnhealthy(context.Background())
+
+	last := notif.actions[len(notif.actions)-1]
+	if !strings.Contains(last, "flappy") || !strings.Contains(last, "circuit auto-closed (budget window elapsed)") {
+		t.Errorf("expected auto-close notification, got %q", last)
+	}
+	g.checkUnhealthy(context.Background())
+	if strings.Count(strings.Join(notif.actions, "\n"), "auto-closed") != 1 {
+		t.Errorf("auto-close should be notified once, got %v", notif.actions)
+	}
+}
+
+func TestHealthyEvent_ClosingOpenCircuitSendsOneRecovery(t *testing.T) {
+	clk := newMockClock(time.Now())
+	notif := &mockNotifier{}
+	g := newTestGuardian(&config.Config{ContainerLabel: "all"}, newMockDocker(), notif, clk)
+	g.tracker = NewRestartTracker(TrackerConfig{BackoffMultiplier: 2, BackoffMax: time.Second, RestartBudget: 1, RestartWindow: time.Hour}, clk)
+
+	id := "abcdef1234567890abcdef"
+	healthy := docker.ContainerEvent{ContainerID: id, ContainerName: "app", Action: "health_status", HealthStatus: "healthy"}
+
+	// Healthy without an open circuit: nothing to announce
+	g.handleEvent(context.Background(), healthy)
+
+	g.tracker.RecordRestart(id)
+	clk.Advance(2 * time.Second)
+	if allowed, _ := g.tracker.ShouldRestart(id); allowed || !g.tracker.IsCircuitOpen(id) {
+		t.Fatal("expected the circuit to be open")
+	}
+
+	g.handleEvent(context.Background(), healthy)
+	g.handleEvent(context.Background(), healthy)
+
+	if len(notif.recoveries) != 1 {
+		t.Fatalf("expected exactly one recovery notification, got %v", notif.recoveries)
+	}
+	if !strings.Contains(notif.recoveries[0], "app") || !strings.Contains(notif.recoveries[0], "circuit closed") {
+		t.Errorf("unexpected recovery text: %s", notif.recoveries[0])
+	}
+	if g.tracker.IsCircuitOpen(id) {
+		t.Error("expected the circuit closed after the healthy event")
+	}
+}
+
+func TestCheckUnhealthy_NoSecondRestartWithinCooldown(t *testing.T) {
+	cfg := &config.Config{ContainerLabel: "all", DefaultStopTimeout: 10, PostRestartCooldown: 30}
+	dock := newMockDocker()
+	clk := newMockClock(time.Now())
+	id := "abcdef1234567890abcdef"
+	dock.unhealthyContainers = []container.Summary{{ID: id, Names: []string{"/app"}, State: "running"}}
+	g := newTestGuardian(cfg, dock, &mockNotifier{}, clk)
+	g.tracker = NewRestartTracker(trackerConfig(cfg), clk)
+
+	g.checkUnhealthy(context.Background())
+	// Past the 10s initial backoff but still booting
+	clk.Advance(20 * time.Second)
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 1 {
+		t.Fatalf("expected no second restart within the cooldown, got %v", dock.restartCalls)
+	}
+
+	clk.Advance(11 * time.Second)
+	g.checkUnhealthy(context.Background())
+	if len(dock.restartCalls) != 2 {
+		t.Errorf("expected a restart once the cooldown passed, got %v", dock.restartCalls)
+	}
+}
@@ -0,0 +1,159 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/guardian"
+)
+
+// Guardian is the subset of guardian state exposed over HTTP.
+type Guardian interface {
+	Ready() bool
+	Status() guardian.Status
+	SetDisabled(disabled bool, source string)
+	ContainerTimeline(nameOrID string) (guardian.ContainerTimeline, bool)
+	RestartAll(ctx context.Context, force bool) ([]guardian.BulkResult, error)
+	ResetContainer(ctx context.Context, nameOrID string) (guardian.ResetResult, error)
+	TriggerScan()
+}
+
+// Handler returns the HTTP handler for the guardian API.
+//
+//	GET  /healthz — liveness: 200 while the process is serving requests
+//	GET  /readyz  — readiness: 200 once the guardian is connected and scanning, 503 otherwise
+//	GET  /status  — JSON snapshot: readiness, detection mode, unhealthy count, open circuits, uptime and recent decisions
+//	GET  /containers/{nameOrID}/history — recent decisions and tracker state for one container
+//	POST /disable — halt all actions (requires ADMIN_TOKEN)
+//	POST /enable  — resume actions (requires ADMIN_TOKEN)
+//	POST /restart-all?confirm=true[&force=true] — restart every monitored container (requires ADMIN_TOKEN)
+//	POST /containers/{nameOrID}/reset — clear one container's backoff and circuit breaker (requires ADMIN_TOKEN)
+//	POST /rescan  — queue a full scan now (requires ADMIN_TOKEN)
+//
+// The POST control endpoints are only registered when adminToken is set, so
+// without one they answer 404 like any other unknown path.
+func Handler(g Guardian, adminToken string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !g.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, "not ready")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ready")
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(g.Status()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("GET /containers/{nameOrID}/history", func(w http.ResponseWriter, r *http.Request) {
+		timeline, ok := g.ContainerTimeline(r.PathValue("nameOrID"))
+		if !ok {
+			http.Error(w, "unknown container", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(timeline); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	if adminToken == "" {
+		return mux
+	}
+	mux.Handle("POST /disable", requireToken(adminToken, func(w http.ResponseWriter, _ *http.Request) {
+		g.SetDisabled(true, "POST /disable")
+		fmt.Fprintln(w, "disabled")
+	}))
+	mux.Handle("POST /enable", requireToken(adminToken, func(w http.ResponseWriter, _ *http.Request) {
+		g.SetDisabled(false, "POST /enable")
+		fmt.Fprintln(w, "enabled")
+	}))
+	mux.Handle("POST /restart-all", requireToken(adminToken, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("confirm") != "true" {
+			http.Error(w, "restart-all requires confirm=true", http.StatusBadRequest)
+			return
+		}
+		// Finish the batch even if the caller disconnects mid-way
+		ctx := context.WithoutCancel(r.Context())
+		results, err := g.RestartAll(ctx, r.URL.Query().Get("force") == "true")
+		switch {
+		case errors.Is(err, guardian.ErrDisabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	mux.Handle("POST /containers/{nameOrID}/reset", requireToken(adminToken, func(w http.ResponseWriter, r *http.Request) {
+		result, err := g.ResetContainer(r.Context(), r.PathValue("nameOrID"))
+		switch {
+		case errors.Is(err, guardian.ErrUnknownContainer):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	mux.Handle("POST /rescan", requireToken(adminToken, func(w http.ResponseWriter, _ *http.Request) {
+		g.TriggerScan()
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintln(w, "scan queued")
+	}))
+	return mux
+}
+
+// requireToken guards a control endpoint with a bearer token.
+func requireToken(token string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Serve starts the guardian API HTTP server on the given port.
+// Returns immediately; the server runs in the background.
+// If port is 0, the API is disabled and this is a no-op.
+func Serve(port int, g Guardian, adminToken string) {
+	if port == 0 {
+		return
+	}
+
+	// Bind before returning so the port is held before privileges are dropped
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		fmt.Printf("status server error: %v\n", err)
+		return
+	}
+	go func() {
+		if err := http.Serve(ln, Handler(g, adminToken)); err != nil { //nolint:gosec // Read endpoints intentionally unauthenticated; control endpoints require ADMIN_TOKEN
+			fmt.Printf("status server error: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,307 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/guardian"
+)
+
+type fakeGuardian struct {
+	ready     bool
+	disabled  bool
+	decisions []guardian.Decision
+	circuits  []guardian.TrackedContainer
+	forced    *bool // force flag passed to the last RestartAll
+	reset     []string
+	scans     int
+}
+
+func (f *fakeGuardian) Ready() bool { return f.ready }
+
+func (f *fakeGuardian) Status() guardian.Status {
+	return guardian.Status{Ready: f.ready, Mode: "polling", Disabled: f.disabled, Decisions: f.decisions, OpenCircuits: f.circuits}
+}
+
+func (f *fakeGuardian) SetDisabled(disabled bool, _ string) { f.disabled = disabled }
+
+func (f *fakeGuardian) ContainerTimeline(nameOrID string) (guardian.ContainerTimeline, bool) {
+	timeline := guardian.ContainerTimeline{Decisions: []guardian.Decision{}}
+	for _, d := range f.decisions {
+		if d.Container == nameOrID || d.ID == nameOrID {
+			timeline.Container, timeline.ID = d.Container, d.ID
+			timeline.Decisions = append(timeline.Decisions, d)
+		}
+	}
+	return timeline, len(timeline.Decisions) > 0
+}
+
+func (f *fakeGuardian) RestartAll(_ context.Context, force bool) ([]guardian.BulkResult, error) {
+	if f.disabled {
+		return nil, guardian.ErrDisabled
+	}
+	f.forced = &force
+	return []guardian.BulkResult{{Container: "web", ID: "abcdef123456", Result: "success"}}, nil
+}
+
+func (f *fakeGuardian) ResetContainer(_ context.Context, nameOrID string) (guardian.ResetResult, error) {
+	if nameOrID != "web" {
+		return guardian.ResetResult{}, guardian.ErrUnknownContainer
+	}
+	f.reset = append(f.reset, nameOrID)
+	return guardian.ResetResult{Container: "web", ID: "abcdef123456", CircuitOpen: true}, nil
+}
+
+func (f *fakeGuardian) TriggerScan() { f.scans++ }
+
+func TestHealthz_AlwaysOK(t *testing.T) {
+	h := Handler(&fakeGuardian{ready: false}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d, want 200", rec.Code)
+	}
+}
+
+func TestReadyz_NotReady(t *testing.T) {
+	h := Handler(&fakeGuardian{ready: false}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", rec.Code)
+	}
+}
+
+func TestReadyz_Ready(t *testing.T) {
+	h := Handler(&fakeGuardian{ready: true}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d, want 200", rec.Code)
+	}
+}
+
+func TestStatus_JSON(t *testing.T) {
+	h := Handler(&fakeGuardian{ready: true, decisions: []guardian.Decision{
+		{Container: "web", ID: "abcdef123456", Action: "restart", Result: "success"},
+	}}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+	var got guardian.Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !got.Ready || len(got.Decisions) != 1 || got.Decisions[0].Container != "web" {
+		t.Errorf("unexpected status: %+v", got)
+	}
+}
+
+func TestStatus_JSONShape(t *testing.T) {
+	h := Handler(&fakeGuardian{ready: true, circuits: []guardian.TrackedContainer{
+		{ID: "abcdef1234567890", TrackerStats: guardian.TrackerStats{CircuitOpen: true, BackoffRemaining: 42}},
+	}}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, key := range []string{"unhealthy", "open_circuits", "event_stream_connected", "uptime_seconds"} {
+		if _, ok := got[key]; !ok {
+			t.Errorf("missing %q in %s", key, rec.Body.String())
+		}
+	}
+	var circuits []map[string]any
+	if err := json.Unmarshal(got["open_circuits"], &circuits); err != nil {
+		t.Fatalf("open_circuits: %v", err)
+	}
+	if len(circuits) != 1 || circuits[0]["id"] != "abcdef1234567890" ||
+		circuits[0]["circuit_open"] != true || circuits[0]["backoff_remaining_seconds"] != float64(42) {
+		t.Errorf("unexpected open_circuits: %s", got["open_circuits"])
+	}
+}
+
+func TestDisableEnable_RequiresToken(t *testing.T) {
+	g := &fakeGuardian{}
+	h := Handler(g, "s3cret")
+
+	for _, tc := range []struct {
+		name, auth string
+		want       int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"valid", "Bearer s3cret", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/disable", nil)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("got %d, want %d", rec.Code, tc.want)
+			}
+		})
+	}
+	if !g.disabled {
+		t.Fatal("expected guardian disabled after authorised POST /disable")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/enable", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || g.disabled {
+		t.Errorf("expected enable to succeed, got %d disabled=%v", rec.Code, g.disabled)
+	}
+}
+
+func TestDisable_NoTokenConfigured(t *testing.T) {
+	g := &fakeGuardian{}
+	req := httptest.NewRequest(http.MethodPost, "/disable", nil)
+	req.Header.Set("Authorization", "Bearer anything")
+	rec := httptest.NewRecorder()
+	Handler(g, "").ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound || g.disabled {
+		t.Errorf("expected 404 and no state change, got %d disabled=%v", rec.Code, g.disabled)
+	}
+}
+
+func TestContainerHistory(t *testing.T) {
+	h := Handler(&fakeGuardian{decisions: []guardian.Decision{
+		{Container: "web", ID: "abcdef123456", Action: "restart", Result: "failure"},
+		{Container: "db", ID: "bbbbbb123456", Action: "skip", Result: "grace"},
+		{Container: "web", ID: "abcdef123456", Action: "restart", Result: "success"},
+	}}, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/containers/web/history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+	var got guardian.ContainerTimeline
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got.Decisions) != 2 || got.Decisions[0].Result != "failure" || got.Decisions[1].Result != "success" {
+		t.Errorf("unexpected decisions: %+v", got.Decisions)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/containers/missing/history", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown container: got %d, want 404", rec.Code)
+	}
+}
+
+func TestRestartAll(t *testing.T) {
+	post := func(g *fakeGuardian, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		Handler(g, "s3cret").ServeHTTP(rec, req)
+		return rec
+	}
+
+	g := &fakeGuardian{}
+	if rec := post(g, "/restart-all"); rec.Code != http.StatusBadRequest || g.forced != nil {
+		t.Errorf("without confirm: got %d, ran=%v", rec.Code, g.forced != nil)
+	}
+
+	rec := post(g, "/restart-all?confirm=true")
+	if rec.Code != http.StatusOK || g.forced == nil || *g.forced {
+		t.Fatalf("confirmed: got %d, forced=%v", rec.Code, g.forced)
+	}
+	var got []guardian.BulkResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got) != 1 || got[0].Container != "web" || got[0].Result != "success" {
+		t.Errorf("unexpected results: %+v", got)
+	}
+
+	if rec := post(g, "/restart-all?confirm=true&force=true"); rec.Code != http.StatusOK || !*g.forced {
+		t.Errorf("force: got %d, forced=%v", rec.Code, *g.forced)
+	}
+
+	if rec := post(&fakeGuardian{disabled: true}, "/restart-all?confirm=true"); rec.Code != http.StatusConflict {
+		t.Errorf("disabled: got %d, want 409", rec.Code)
+	}
+}
+
+func TestResetAndRescan_RequireToken(t *testing.T) {
+	for _, target := range []string{"/containers/web/reset", "/rescan"} {
+		g := &fakeGuardian{}
+		for _, tc := range []struct {
+			name, token, auth string
+			want              int
+		}{
+			{"no token configured", "", "Bearer anything", http.StatusNotFound},
+			{"missing", "s3cret", "", http.StatusUnauthorized},
+			{"wrong", "s3cret", "Bearer nope", http.StatusUnauthorized},
+		} {
+			req := httptest.NewRequest(http.MethodPost, target, nil)
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			Handler(g, tc.token).ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("%s %s: got %d, want %d", target, tc.name, rec.Code, tc.want)
+			}
+		}
+		if len(g.reset) != 0 || g.scans != 0 {
+			t.Errorf("%s: unauthorised requests reached the guardian: reset=%v scans=%d", target, g.reset, g.scans)
+		}
+	}
+}
+
+func TestResetContainer(t *testing.T) {
+	g := &fakeGuardian{}
+	h := Handler(g, "s3cret")
+	post := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/containers/web/reset")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+	var got guardian.ResetResult
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Container != "web" || !got.CircuitOpen || len(g.reset) != 1 {
+		t.Errorf("result = %+v, resets = %v", got, g.reset)
+	}
+
+	if rec := post("/containers/nope/reset"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown container: got %d, want 404", rec.Code)
+	}
+	if rec := post("/rescan"); rec.Code != http.StatusAccepted || g.scans != 1 {
+		t.Errorf("rescan: got %d scans=%d, want 202 and one scan", rec.Code, g.scans)
+	}
+}
@@ -1,8 +1,11 @@
 package logging
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
 )
 
 // Logger wraps slog for structured logging.
@@ -10,13 +13,56 @@ type Logger struct {
 	*slog.Logger
 }
 
-// New creates a Logger that outputs text or JSON depending on config.
-func New(jsonMode bool) *Logger {
+// New creates a Logger that outputs text or JSON depending on config,
+// dropping records below level.
+func New(jsonMode bool, level slog.Level) *Logger {
+	return NewWriter(os.Stdout, jsonMode, level)
+}
+
+// NewWriter creates a Logger like New but writing to w.
+func NewWriter(w io.Writer, jsonMode bool, level slog.Level) *Logger {
+	opts := &slog.HandlerOptions{Level: level}
 	var handler slog.Handler
 	if jsonMode {
-		handler = slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+		handler = slog.NewJSONHandler(w, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
+		handler = slog.NewTextHandler(w, opts)
 	}
 	return &Logger{slog.New(handler)}
 }
+
+// ParseLevel maps a LOG_LEVEL value (debug, info, warn or error, in any
+// case) to its slog level.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("unknown log level %q", s)
+}
+
+// With returns a Logger that includes the given attributes in every record.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{l.Logger.With(args...)}
+}
+
+// Debugf logs a formatted progress line at debug level.
+func (l *Logger) Debugf(format string, args ...any) {
+	l.Debug(fmt.Sprintf(format, args...))
+}
+
+// Infof logs a formatted progress line at info level.
+func (l *Logger) Infof(format string, args ...any) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a formatted progress line at warn level.
+func (l *Logger) Warnf(format string, args ...any) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
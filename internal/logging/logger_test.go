@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewWriter_DebugSuppressedAtInfo(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewWriter(&buf, false, slog.LevelInfo)
+
+	log.Debugf("Container %s unhealthy (1/3) - waiting for threshold", "web")
+	log.Infof("Container %s found to be unhealthy", "web")
+
+	out := buf.String()
+	if strings.Contains(out, "waiting for threshold") {
+		t.Errorf("debug line logged at info level: %q", out)
+	}
+	if !strings.Contains(out, "Container web found to be unhealthy") {
+		t.Errorf("info line missing: %q", out)
+	}
+
+	buf.Reset()
+	NewWriter(&buf, false, slog.LevelDebug).Debugf("Container %s unhealthy (1/3) - waiting for threshold", "web")
+	if !strings.Contains(buf.String(), "waiting for threshold") {
+		t.Errorf("debug line missing at debug level: %q", buf.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"", slog.LevelInfo, false},
+		{"debug", slog.LevelDebug, false},
+		{"INFO", slog.LevelInfo, false},
+		{"warn", slog.LevelWarn, false},
+		{"warning", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", slog.LevelInfo, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v, error=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -20,16 +21,52 @@ var (
 		Help: "Total skipped containers by reason.",
 	}, []string{"container", "reason"})
 
+	ExcludedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_excluded_total",
+		Help: "Total times a container was left alone by AUTOHEAL_EXCLUDE_LABEL or AUTOHEAL_EXCLUDE_NAMES.",
+	}, []string{"container"})
+
 	NotificationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "docker_guardian_notifications_total",
 		Help: "Total notification sends by service and result.",
 	}, []string{"service", "result"})
 
+	NotificationRetriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_notification_retries_total",
+		Help: "Total notification send attempts retried after a failure, by service.",
+	}, []string{"service"})
+
+	NotificationAttempts = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "docker_guardian_notification_attempts",
+		Help:    "Attempts needed for a successful notification send.",
+		Buckets: []float64{1, 2, 3},
+	}, []string{"service"})
+
+	NotificationsDroppedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "docker_guardian_notifications_dropped_total",
+		Help: "Total notifications dropped by the NOTIFY_MAX_PER_WINDOW global cap.",
+	})
+
+	NotificationsQueueFullTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_notifications_queue_full_total",
+		Help: "Total notification sends dropped because the service's delivery queue was full.",
+	}, []string{"service"})
+
 	EventsProcessedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "docker_guardian_events_processed_total",
 		Help: "Total Docker events processed by action.",
 	}, []string{"action"})
 
+	EventsDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_events_dropped_total",
+		Help: "Total Docker events dropped by EVENT_PROCESS_RATE, by action.",
+	}, []string{"action"})
+
+	EventsDebouncedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "docker_guardian_events_debounced_total",
+		Help: "Total Docker events coalesced by debouncing (superseded a pending event for the same container).",
+	})
+
 	UnhealthyContainers = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "docker_guardian_unhealthy_containers",
 		Help: "Current number of unhealthy containers.",
@@ -40,11 +77,66 @@ var (
 		Help: "Number of containers with open circuit breakers.",
 	})
 
+	CircuitAutoClosedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_circuit_auto_closed_total",
+		Help: "Open circuit breakers closed because the restart window elapsed.",
+	}, []string{"container"})
+
 	EventStreamConnected = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "docker_guardian_event_stream_connected",
 		Help: "1 if connected to Docker event stream, 0 otherwise.",
 	})
 
+	DockerUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "docker_guardian_docker_up",
+		Help: "1 if the Docker daemon answered the latest ping, 0 otherwise.",
+	})
+
+	Disabled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "docker_guardian_disabled",
+		Help: "1 while all guardian actions are disabled (GUARDIAN_DISABLED or POST /disable), 0 otherwise.",
+	})
+
+	DockerAPIThrottledTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "docker_guardian_docker_api_throttled_total",
+		Help: "Total Docker API calls delayed by DOCKER_API_RATE_LIMIT.",
+	})
+
+	CrashloopDetectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_crashloop_detected_total",
+		Help: "Restarts that succeeded but the container exited again within AUTOHEAL_CRASHLOOP_WINDOW.",
+	}, []string{"container"})
+
+	ActionRacesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_action_races_total",
+		Help: "Actions abandoned because the container was removed between detection and action.",
+	}, []string{"container"})
+
+	ActionErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "docker_guardian_action_errors_total",
+		Help: "Failed container actions by action and error class (permission, conflict, timeout, other).",
+	}, []string{"container", "action", "class"})
+
+	RecentDecision = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "docker_guardian_recent_decision",
+		Help: "Unix time of the latest retained decision per container, action and result (bounded by AUTOHEAL_DECISION_HISTORY).",
+	}, []string{"container", "action", "result"})
+
+	LifetimeRestarts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "docker_guardian_lifetime_restarts_total",
+		Help: "Cumulative restarts per container across guardian restarts, loaded from AUTOHEAL_STATE_FILE.",
+	}, []string{"container"})
+
+	ContainerLastRestart = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "docker_guardian_container_last_restart_timestamp",
+		Help: "Unix time of the latest restart attempt per container; removed once it is healthy again.",
+	}, []string{"container"})
+
+	ContainerUnhealthyCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "docker_guardian_container_unhealthy_count",
+		Help: "Consecutive unhealthy detections per container towards AUTOHEAL_UNHEALTHY_THRESHOLD.",
+	}, []string{"container"})
+
 	RestartDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "docker_guardian_restart_duration_seconds",
 		Help:    "Time taken to restart a container.",
@@ -62,11 +154,29 @@ func init() {
 	prometheus.MustRegister(
 		RestartsTotal,
 		SkipsTotal,
+		ExcludedTotal,
 		NotificationsTotal,
+		NotificationRetriesTotal,
+		NotificationAttempts,
+		NotificationsDroppedTotal,
+		NotificationsQueueFullTotal,
 		EventsProcessedTotal,
+		EventsDroppedTotal,
+		EventsDebouncedTotal,
 		UnhealthyContainers,
 		CircuitOpenContainers,
+		CircuitAutoClosedTotal,
 		EventStreamConnected,
+		DockerUp,
+		Disabled,
+		DockerAPIThrottledTotal,
+		CrashloopDetectedTotal,
+		ActionRacesTotal,
+		ActionErrorsTotal,
+		RecentDecision,
+		LifetimeRestarts,
+		ContainerLastRestart,
+		ContainerUnhealthyCount,
 		RestartDuration,
 		EventProcessingDuration,
 	)
@@ -83,9 +193,14 @@ func Serve(port int) {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
 
+	// Bind before returning so the port is held before privileges are dropped
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		fmt.Printf("metrics server error: %v\n", err)
+		return
+	}
 	go func() {
-		addr := fmt.Sprintf(":%d", port)
-		if err := http.ListenAndServe(addr, mux); err != nil { //nolint:gosec // Metrics endpoint, intentionally unauthenticated
+		if err := http.Serve(ln, mux); err != nil { //nolint:gosec // Metrics endpoint, intentionally unauthenticated
 			fmt.Printf("metrics server error: %v\n", err)
 		}
 	}()
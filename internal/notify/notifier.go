@@ -2,56 +2,228 @@ package notify
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"net"
 	"net/http"
 	"net/smtp"
 	"net/url"
+	"os"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
 	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"golang.org/x/time/rate"
 )
 
 // Notifier is the interface for sending notifications from the guardian.
 type Notifier interface {
 	Startup(text string)
-	Action(text string)
-	Skip(text string)
+	Heartbeat(text string)
+	Action(evt Event)
+	Skip(evt Event)
+	Recovery(evt Event)
+	Error(text string)
 	Close()
 }
 
+// Event is a notification message plus the context it was raised in.
+// Container fields are empty for guardian-level notifications.
+type Event struct {
+	Text          string
+	Container     string // container name, without leading slash
+	ContainerID   string
+	Project       string // com.docker.compose.project, empty outside Compose
+	Service       string // com.docker.compose.service, or the container name outside Compose
+	CorrelationID string // ties the notification to the Docker event that triggered it
+	Action        string // decision reported, e.g. restart, stop, skip (empty if none)
+	Result        string // outcome of Action, e.g. success, failure or the skip reason
+
+	// Services restricts delivery to the named services (autoheal.notify.only).
+	// Empty means every configured service.
+	Services []string
+}
+
+// routesTo reports whether the event should be delivered to the named service.
+func (e Event) routesTo(service string) bool {
+	if len(e.Services) == 0 {
+		return true
+	}
+	for _, s := range e.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
 // Dispatcher sends notifications to all configured services.
 type Dispatcher struct {
+	log    *logging.Logger
+	client *http.Client
+
+	// Config and everything derived from it, swapped as a whole by Reload
+	settings atomic.Pointer[settings]
+
+	// Bounded delivery pool. Each service is pinned to one worker queue so
+	// its notifications are sent in order; Close drains the queues.
+	queues  []chan delivery
+	queueMu sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+
+	// Rate limiting: per container+event key → current window
+	rateMu      sync.Mutex
+	rateLimit   map[string]rateWindow
+	rateSweptAt time.Time // last prune of expired keys
+
+	// Deduplication: hash of message text → last time it was sent
+	dedup        map[uint64]time.Time
+	dedupSweptAt time.Time
+
+	// NOTIFY_BATCH_WINDOW digest: actions held until the timer flushes them
+	batchMu    sync.Mutex
+	batch      []Event
+	batchTimer *time.Timer
+
+	// Backoff between retried sends
+	retryDelays []time.Duration
+
+	// Telegram Bot API base URL, overridden in tests
+	telegramAPI string
+
+	// Cancelled on Close so pending retries abort instead of delaying shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// settings is the part of a Dispatcher built from config.
+type settings struct {
 	cfg      *config.Config
-	log      *logging.Logger
-	client   *http.Client
 	resolved []string
-	wg       sync.WaitGroup
 
-	// Rate limiting: per container+event key → last notification time
-	rateMu    sync.Mutex
-	rateLimit map[string]time.Time
+	// Global cap across all containers (nil = unlimited)
+	global *rate.Limiter
+
+	// Footer appended to every message (nil = none)
+	footer   *template.Template
+	hostname string
+
+	// Logs hint appended to container action messages (nil = none)
+	logsHint *template.Template
+
+	// NOTIFY_TEMPLATE_* replacements for built-in messages, by kind
+	// ("action", "skip", "startup"); missing kinds keep the built-in text
+	templates map[string]*template.Template
 }
 
 // NewDispatcher creates a notification dispatcher from config.
 func NewDispatcher(cfg *config.Config, log *logging.Logger) *Dispatcher {
-	return &Dispatcher{
-		cfg: cfg,
-		log: log,
-		client: &http.Client{
-			Timeout: time.Duration(cfg.CurlTimeout) * time.Second,
-		},
-		resolved:  cfg.ResolvedNotifyEvents(),
-		rateLimit: make(map[string]time.Time),
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		log:         log,
+		client:      &http.Client{}, // per-request deadlines come from timeout()
+		rateLimit:   make(map[string]rateWindow),
+		dedup:       make(map[uint64]time.Time),
+		retryDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		telegramAPI: "https://api.telegram.org",
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	d.settings.Store(newSettings(cfg, log))
+	d.startWorkers(cfg.NotifyWorkers, cfg.NotifyQueueSize)
+	return d
+}
+
+// Reload swaps in a new config for subsequent notifications. Rate limit and
+// dedup history is kept; the global cap starts a fresh window. The worker
+// pool is sized at startup and does not change.
+func (d *Dispatcher) Reload(cfg *config.Config) {
+	d.settings.Store(newSettings(cfg, d.log))
+}
+
+func newSettings(cfg *config.Config, log *logging.Logger) *settings {
+	s := &settings{
+		cfg:      cfg,
+		resolved: cfg.ResolvedNotifyEvents(),
+	}
+	if cfg.NotifyFooter != "" {
+		footer, err := template.New("footer").Parse(cfg.NotifyFooter)
+		if err != nil {
+			log.Warn("ignoring invalid NOTIFY_FOOTER", "error", err)
+		} else {
+			s.footer = footer
+		}
+	}
+	s.hostname = cfg.NotifyHostname
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+	for kind, text := range map[string]string{
+		"action":  cfg.NotifyTemplateAction,
+		"skip":    cfg.NotifyTemplateSkip,
+		"startup": cfg.NotifyTemplateStartup,
+	} {
+		if text == "" {
+			continue
+		}
+		tmpl, err := template.New(kind).Parse(text)
+		if err != nil {
+			log.Warn("ignoring invalid notification template", "kind", kind, "error", err)
+			continue
+		}
+		if s.templates == nil {
+			s.templates = make(map[string]*template.Template)
+		}
+		s.templates[kind] = tmpl
+	}
+	if cfg.NotifyLogsHint != "" {
+		hint, err := template.New("logs-hint").Parse(cfg.NotifyLogsHint)
+		if err != nil {
+			log.Warn("ignoring invalid NOTIFY_LOGS_HINT", "error", err)
+		} else {
+			s.logsHint = hint
+		}
+	}
+	if cfg.NotifyMaxPerWindow > 0 && cfg.NotifyGlobalWindow > 0 {
+		// Token bucket: a full window's worth of burst, refilled evenly across the window
+		window := time.Duration(cfg.NotifyGlobalWindow) * time.Second
+		s.global = rate.NewLimiter(rate.Every(window/time.Duration(cfg.NotifyMaxPerWindow)), cfg.NotifyMaxPerWindow)
 	}
+	return s
 }
 
-// Close waits for in-flight notification goroutines to finish, with a 10-second timeout.
+// cfg returns the config currently in effect.
+func (d *Dispatcher) cfg() *config.Config {
+	return d.settings.Load().cfg
+}
+
+// Close sends any batched actions, cancels pending retries, sends what is
+// still queued (one attempt each) and waits for the workers to finish, with a 10-second timeout.
 func (d *Dispatcher) Close() {
+	d.flushBatch()
+	d.cancel()
+	d.queueMu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, q := range d.queues {
+			close(q)
+		}
+	}
+	d.queueMu.Unlock()
 	done := make(chan struct{})
 	go func() {
 		d.wg.Wait()
@@ -67,34 +239,40 @@ func (d *Dispatcher) Close() {
 // ConfiguredServices returns a human-readable list of configured notification services.
 func (d *Dispatcher) ConfiguredServices() string {
 	var services []string
-	if d.cfg.WebhookURL != "" {
+	if d.cfg().WebhookURL != "" {
 		services = append(services, "webhook")
 	}
-	if d.cfg.AppriseURL != "" {
+	if d.cfg().AppriseURL != "" {
 		services = append(services, "apprise")
 	}
-	if d.cfg.GotifyURL != "" {
+	if d.cfg().GotifyURL != "" {
 		services = append(services, "gotify")
 	}
-	if d.cfg.DiscordWebhook != "" {
+	if d.cfg().DiscordWebhook != "" {
 		services = append(services, "discord")
 	}
-	if d.cfg.SlackWebhook != "" {
+	if d.cfg().SlackWebhook != "" {
 		services = append(services, "slack")
 	}
-	if d.cfg.TelegramToken != "" {
+	if d.cfg().TelegramToken != "" {
 		services = append(services, "telegram")
 	}
-	if d.cfg.PushoverToken != "" {
+	if d.cfg().PushoverToken != "" {
 		services = append(services, "pushover")
 	}
-	if d.cfg.PushbulletToken != "" {
+	if d.cfg().PushbulletToken != "" {
 		services = append(services, "pushbullet")
 	}
-	if d.cfg.LunaSeaWebhook != "" {
+	if d.cfg().LunaSeaWebhook != "" {
 		services = append(services, "lunasea")
 	}
-	if d.cfg.EmailSMTP != "" {
+	if d.cfg().TeamsWebhook != "" {
+		services = append(services, "teams")
+	}
+	if d.cfg().NtfyURL != "" && d.cfg().NtfyTopic != "" {
+		services = append(services, "ntfy")
+	}
+	if d.cfg().EmailSMTP != "" {
 		services = append(services, "email")
 	}
 	if len(services) == 0 {
@@ -104,7 +282,7 @@ func (d *Dispatcher) ConfiguredServices() string {
 }
 
 func (d *Dispatcher) hasEvent(event string) bool {
-	for _, e := range d.resolved {
+	for _, e := range d.settings.Load().resolved {
 		if e == event {
 			return true
 		}
@@ -112,38 +290,145 @@ func (d *Dispatcher) hasEvent(event string) bool {
 	return false
 }
 
-// isRateLimited checks if a notification for this key is rate-limited.
-// Returns true if the notification should be suppressed.
+// rateWindow is one key's NOTIFY_RATE_LIMIT window: when it opened and how
+// many notifications it has let through.
+type rateWindow struct {
+	start time.Time
+	sent  int
+}
+
+// rateKey builds the rate-limit key for a notification from its container
+// and event category, so distinct containers never limit each other.
+// Host-level notifications without a container fall back to the start of
+// the text.
+func rateKey(evt Event, category string) string {
+	name := evt.Container
+	if name == "" {
+		name = evt.Text
+		if len(name) > 50 {
+			name = name[:50]
+		}
+	}
+	return category + "|" + name
+}
+
+// isRateLimited checks if a notification for this key is rate-limited:
+// NOTIFY_RATE_LIMIT_BURST notifications are allowed per NOTIFY_RATE_LIMIT
+// window. Returns true if the notification should be suppressed.
 func (d *Dispatcher) isRateLimited(key string) bool {
-	if d.cfg.NotifyRateLimit <= 0 {
+	if d.cfg().NotifyRateLimit <= 0 {
 		return false
 	}
 
 	d.rateMu.Lock()
 	defer d.rateMu.Unlock()
 
-	window := time.Duration(d.cfg.NotifyRateLimit) * time.Second
-	if last, ok := d.rateLimit[key]; ok {
-		if time.Since(last) < window {
-			return true
+	window := time.Duration(d.cfg().NotifyRateLimit) * time.Second
+	d.pruneRateLimit(window)
+	w, ok := d.rateLimit[key]
+	if !ok || time.Since(w.start) >= window {
+		w = rateWindow{start: time.Now()}
+	}
+	if w.sent >= max(d.cfg().NotifyRateLimitBurst, 1) {
+		return true
+	}
+	w.sent++
+	d.rateLimit[key] = w
+	return false
+}
+
+// pruneRateLimit drops keys whose window has expired, so keys for removed
+// containers don't accumulate. It sweeps at most once per window; expired
+// keys would not suppress anything anyway. Caller holds rateMu.
+func (d *Dispatcher) pruneRateLimit(window time.Duration) {
+	now := time.Now()
+	if now.Sub(d.rateSweptAt) < window {
+		return
+	}
+	d.rateSweptAt = now
+	for key, w := range d.rateLimit {
+		if now.Sub(w.start) >= window {
+			delete(d.rateLimit, key)
 		}
 	}
-	d.rateLimit[key] = time.Now()
+}
+
+// isDuplicate reports whether identical text was already sent within
+// NOTIFY_DEDUP_WINDOW, so repeats generated every cycle (e.g. the same skip
+// in polling mode) are suppressed. Unlike the rate limit, which keys on the
+// start of the message, this compares the whole text.
+func (d *Dispatcher) isDuplicate(text string) bool {
+	if d.cfg().NotifyDedupWindow <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(text))
+	sum := h.Sum64()
+
+	d.rateMu.Lock()
+	defer d.rateMu.Unlock()
+
+	window := time.Duration(d.cfg().NotifyDedupWindow) * time.Second
+	now := time.Now()
+	if now.Sub(d.dedupSweptAt) >= window {
+		d.dedupSweptAt = now
+		for k, last := range d.dedup {
+			if now.Sub(last) >= window {
+				delete(d.dedup, k)
+			}
+		}
+	}
+	if last, ok := d.dedup[sum]; ok && now.Sub(last) < window {
+		d.log.Info("duplicate notification suppressed", "window_seconds", d.cfg().NotifyDedupWindow, "text", text)
+		return true
+	}
+	d.dedup[sum] = now
 	return false
 }
 
+// isGloballyCapped reports whether a notification should be dropped by the
+// NOTIFY_MAX_PER_WINDOW cap. [CRITICAL] notifications are never dropped.
+func (d *Dispatcher) isGloballyCapped(text string) bool {
+	s := d.settings.Load()
+	if s.global == nil || strings.Contains(text, "[CRITICAL]") {
+		return false
+	}
+	if s.global.Allow() {
+		return false
+	}
+	metrics.NotificationsDroppedTotal.Inc()
+	d.log.Warn("notification dropped by global cap", "max_per_window", s.cfg.NotifyMaxPerWindow,
+		"window_seconds", s.cfg.NotifyGlobalWindow, "text", text)
+	return true
+}
+
 // Startup sends a startup notification.
 func (d *Dispatcher) Startup(text string) {
 	if !d.hasEvent("startup") {
 		return
 	}
-	d.dispatch(text, false)
+	evt := Event{Text: text}
+	if d.isRateLimited(rateKey(evt, "startup")) {
+		return
+	}
+	evt.Text = d.renderTemplate("startup", evt)
+	d.dispatch(evt, severityNormal, false)
+}
+
+// Heartbeat sends a periodic liveness summary.
+func (d *Dispatcher) Heartbeat(text string) {
+	if !d.hasEvent("heartbeat") {
+		return
+	}
+	d.dispatch(Event{Text: text}, severityNormal, false)
 }
 
 // Action sends an action notification (success or failure).
 // Action events use retry on failure.
-func (d *Dispatcher) Action(text string) {
-	if strings.Contains(text, "Failed") || strings.Contains(text, "[CRITICAL]") {
+func (d *Dispatcher) Action(evt Event) {
+	text := evt.Text
+	sev := severityOf(text)
+	if strings.Contains(text, "Failed") || sev == severityCritical {
 		if !d.hasEvent("actions") && !d.hasEvent("failures") {
 			return
 		}
@@ -153,237 +438,732 @@ func (d *Dispatcher) Action(text string) {
 		}
 	}
 
-	// Rate limit using the first ~50 chars as a key (contains container name)
-	key := text
-	if len(key) > 50 {
-		key = key[:50]
+	// Critical alerts get their own bucket so a routine notification for the
+	// same container can't hold one back.
+	category := "action"
+	if sev == severityCritical {
+		category = "critical"
+	}
+	if d.isRateLimited(rateKey(evt, category)) || d.isDuplicate(text) {
+		return
+	}
+
+	evt.Text = d.renderTemplate("action", evt)
+	if hint := d.renderLogsHint(evt); hint != "" {
+		evt.Text += "\n" + hint
+	}
+	// Critical alerts and per-container routed events are never held back
+	if sev == severityNormal && len(evt.Services) == 0 && d.addToBatch(evt) {
+		return
+	}
+	d.dispatch(evt, sev, true)
+}
+
+// addToBatch holds an action for the NOTIFY_BATCH_WINDOW digest, starting
+// the flush timer for the first one. It reports false when batching is off.
+func (d *Dispatcher) addToBatch(evt Event) bool {
+	window := d.cfg().NotifyBatchWindow
+	if window <= 0 {
+		return false
+	}
+	d.batchMu.Lock()
+	defer d.batchMu.Unlock()
+	d.batch = append(d.batch, evt)
+	if d.batchTimer == nil {
+		d.batchTimer = time.AfterFunc(time.Duration(window)*time.Second, d.flushBatch)
+	}
+	return true
+}
+
+// flushBatch sends the held actions: a single one unchanged, several as one
+// summary.
+func (d *Dispatcher) flushBatch() {
+	d.batchMu.Lock()
+	defer d.batchMu.Unlock()
+	if d.batchTimer != nil {
+		d.batchTimer.Stop()
+		d.batchTimer = nil
 	}
-	if d.isRateLimited(key) {
+	events := d.batch
+	d.batch = nil
+	switch len(events) {
+	case 0:
 		return
+	case 1:
+		d.dispatch(events[0], severityNormal, true)
+	default:
+		d.dispatch(batchSummary(events), severityNormal, true)
 	}
+}
 
-	d.dispatch(text, true)
+// batchSummary coalesces held actions into one event, e.g. "3 containers
+// restarted: a, b, c", followed by each original message.
+func batchSummary(events []Event) Event {
+	var names []string
+	var details strings.Builder
+	restarted := true
+	for _, e := range events {
+		if e.Container != "" && !slices.Contains(names, e.Container) {
+			names = append(names, e.Container)
+		}
+		if e.Action != "restart" || e.Result != "success" {
+			restarted = false
+		}
+		details.WriteString("\n- " + e.Text)
+	}
+	what := "container actions"
+	if restarted {
+		what = "containers restarted"
+	}
+	return Event{Text: fmt.Sprintf("%d %s: %s%s", len(events), what, strings.Join(names, ", "), details.String())}
 }
 
 // Skip sends a skip notification.
-func (d *Dispatcher) Skip(text string) {
-	if !d.hasEvent("skips") {
+func (d *Dispatcher) Skip(evt Event) {
+	if !d.hasEvent("skips") || d.isRateLimited(rateKey(evt, "skip")) || d.isDuplicate(evt.Text) {
+		return
+	}
+	sev := severityOf(evt.Text)
+	evt.Text = d.renderTemplate("skip", evt)
+	d.dispatch(evt, sev, false)
+}
+
+// Recovery sends the all-clear for a container whose open circuit was
+// cleared by it turning healthy, pairing with the circuit-open alert.
+func (d *Dispatcher) Recovery(evt Event) {
+	if !d.hasEvent("recovery") || d.isRateLimited(rateKey(evt, "recovery")) {
 		return
 	}
-	d.dispatch(text, false)
+	d.dispatch(evt, severityNormal, true)
+}
+
+// Error sends a guardian self-error alert (repeated Docker API failures),
+// on the errors category so it is separate from container notifications.
+func (d *Dispatcher) Error(text string) {
+	if !d.hasEvent("errors") {
+		return
+	}
+	d.dispatch(Event{Text: text}, severityOf(text), true)
+}
+
+// severity is how urgently a notification should be presented. It is taken
+// from the original text, before any NOTIFY_TEMPLATE_* rendering can drop the
+// marker.
+type severity int
+
+const (
+	severityNormal severity = iota
+	severityCritical
+)
+
+// severityOf reports severityCritical for texts carrying the [CRITICAL]
+// marker (open circuits, gave-up recoveries, mass-unhealthy alarms).
+func severityOf(text string) severity {
+	if strings.Contains(text, "[CRITICAL]") {
+		return severityCritical
+	}
+	return severityNormal
 }
 
-func (d *Dispatcher) dispatch(text string, retry bool) {
-	if d.cfg.NotifyHostname != "" {
-		text = "[" + d.cfg.NotifyHostname + "] " + text
+func (d *Dispatcher) dispatch(evt Event, sev severity, retry bool) {
+	text := evt.Text
+	if d.isGloballyCapped(text) {
+		return
+	}
+	tags := d.tags()
+	if len(tags) > 0 {
+		var prefix strings.Builder
+		for _, t := range tags {
+			prefix.WriteString("[" + t.value + "]")
+		}
+		text = prefix.String() + " " + text
+	}
+	if d.cfg().NotifyComposeNames && evt.Project != "" {
+		text += fmt.Sprintf("\nService %s in project %s", evt.Service, evt.Project)
+	}
+	if footer := d.renderFooter(evt); footer != "" {
+		text += "\n" + footer
 	}
 
 	if d.hasEvent("debug") {
 		now := time.Now().Format("2006-01-02T15:04:05-0700")
+		suffix := ""
+		if evt.CorrelationID != "" {
+			suffix = " (correlation_id=" + evt.CorrelationID + ")"
+		}
 		services := d.ConfiguredServices()
 		for _, svc := range strings.Split(services, " ") {
-			if svc != "none" {
-				fmt.Printf("%s [notify] → %s: %s\n", now, svc, text)
+			if svc != "none" && evt.routesTo(svc) {
+				fmt.Printf("%s [notify] → %s: %s%s\n", now, svc, text, suffix)
 			}
 		}
 	}
 
-	if d.cfg.WebhookURL != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("webhook", retry, func() error {
-				return d.sendJSON(d.cfg.WebhookURL, map[string]string{d.cfg.WebhookJSONKey: text})
-			})
-		}()
+	if d.cfg().WebhookURL != "" && evt.routesTo("webhook") {
+		d.enqueue("webhook", retry, text, func() error {
+			payload := make(map[string]string, len(tags)+1)
+			for _, t := range tags {
+				payload[t.key] = t.value
+			}
+			payload[d.cfg().WebhookJSONKey] = d.fit("webhook", text)
+			return d.sendWebhook(payload)
+		})
 	}
-	if d.cfg.AppriseURL != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("apprise", retry, func() error {
-				return d.sendJSON(d.cfg.AppriseURL, map[string]string{"title": "Docker-Guardian", "body": text})
-			})
-		}()
+	if d.cfg().AppriseURL != "" && evt.routesTo("apprise") {
+		d.enqueue("apprise", retry, text, func() error {
+			return d.sendJSON("apprise", d.cfg().AppriseURL, map[string]string{"title": "Docker-Guardian", "body": d.fit("apprise", text)})
+		})
 	}
-	if d.cfg.GotifyURL != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("gotify", retry, func() error {
-				return d.sendJSON(d.cfg.GotifyURL+"/message?token="+d.cfg.GotifyToken,
-					map[string]any{"title": "Docker-Guardian", "message": text, "priority": 5})
-			})
-		}()
+	if d.cfg().GotifyURL != "" && evt.routesTo("gotify") {
+		d.enqueue("gotify", retry, text, func() error {
+			return d.sendJSON("gotify", d.cfg().GotifyURL+"/message?token="+d.cfg().GotifyToken,
+				map[string]any{"title": "Docker-Guardian", "message": d.fit("gotify", text), "priority": gotifyPriority(sev)})
+		})
 	}
-	if d.cfg.DiscordWebhook != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("discord", retry, func() error {
-				return d.sendJSON(d.cfg.DiscordWebhook, map[string]any{
-					"embeds": []map[string]any{{"title": "Docker-Guardian", "description": text, "color": 3066993}},
-				})
-			})
-		}()
+	if d.cfg().DiscordWebhook != "" && evt.routesTo("discord") {
+		d.enqueue("discord", retry, text, func() error {
+			embed := map[string]any{"title": "Docker-Guardian", "description": d.fit("discord", text), "color": discordColor(sev)}
+			if len(tags) > 0 {
+				fields := make([]map[string]any, 0, len(tags))
+				for _, t := range tags {
+					fields = append(fields, map[string]any{"name": t.key, "value": t.value, "inline": true})
+				}
+				embed["fields"] = fields
+			}
+			return d.sendJSON("discord", d.cfg().DiscordWebhook, map[string]any{"embeds": []map[string]any{embed}})
+		})
 	}
-	if d.cfg.SlackWebhook != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("slack", retry, func() error {
-				return d.sendJSON(d.cfg.SlackWebhook, map[string]string{"text": "*Docker-Guardian*\n" + text})
-			})
-		}()
+	if d.cfg().SlackWebhook != "" && evt.routesTo("slack") {
+		d.enqueue("slack", retry, text, func() error {
+			return d.sendJSON("slack", d.cfg().SlackWebhook, slackPayload(d.fit("slack", "*Docker-Guardian*\n"+text), sev))
+		})
 	}
-	if d.cfg.TelegramToken != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("telegram", retry, func() error {
-				return d.sendJSON("https://api.telegram.org/bot"+d.cfg.TelegramToken+"/sendMessage",
-					map[string]string{"chat_id": d.cfg.TelegramChatID, "text": "Docker-Guardian: " + text})
+	if d.cfg().TelegramToken != "" && evt.routesTo("telegram") {
+		// One sendMessage per chat, each its own delivery with its own retries
+		for _, chatID := range telegramChatIDs(d.cfg().TelegramChatID) {
+			d.enqueue("telegram", retry, text, func() error {
+				payload := map[string]any{"chat_id": chatID, "text": d.fit("telegram", "Docker-Guardian: "+text)}
+				if d.cfg().TelegramThreadID != 0 {
+					payload["message_thread_id"] = d.cfg().TelegramThreadID
+				}
+				return d.sendJSON("telegram", d.telegramAPI+"/bot"+d.cfg().TelegramToken+"/sendMessage", payload)
 			})
-		}()
+		}
 	}
-	if d.cfg.PushoverToken != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("pushover", retry, func() error {
-				return d.sendForm("https://api.pushover.net/1/messages.json", map[string]string{
-					"token": d.cfg.PushoverToken, "user": d.cfg.PushoverUser,
-					"title": "Docker-Guardian", "message": text,
-				})
+	if d.cfg().PushoverToken != "" && evt.routesTo("pushover") {
+		d.enqueue("pushover", retry, text, func() error {
+			return d.sendForm("pushover", "https://api.pushover.net/1/messages.json", map[string]string{
+				"token": d.cfg().PushoverToken, "user": d.cfg().PushoverUser,
+				"title": "Docker-Guardian", "message": d.fit("pushover", text),
+				"priority": pushoverPriority(sev),
 			})
-		}()
+		})
 	}
-	if d.cfg.PushbulletToken != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("pushbullet", retry, func() error {
-				return d.sendJSONWithHeader("https://api.pushbullet.com/v2/pushes",
-					"Access-Token", d.cfg.PushbulletToken,
-					map[string]string{"type": "note", "title": "Docker-Guardian", "body": text})
-			})
-		}()
+	if d.cfg().PushbulletToken != "" && evt.routesTo("pushbullet") {
+		d.enqueue("pushbullet", retry, text, func() error {
+			return d.sendJSONWithHeader("pushbullet", "https://api.pushbullet.com/v2/pushes",
+				"Access-Token", d.cfg().PushbulletToken,
+				map[string]string{"type": "note", "title": "Docker-Guardian", "body": d.fit("pushbullet", text)})
+		})
 	}
-	if d.cfg.LunaSeaWebhook != "" {
-		d.wg.Add(1)
-		go func() {
-			defer d.wg.Done()
-			d.sendWithRetry("lunasea", retry, func() error {
-				return d.sendJSON(d.cfg.LunaSeaWebhook, map[string]string{"title": "Docker-Guardian", "body": text})
-			})
-		}()
+	if d.cfg().LunaSeaWebhook != "" && evt.routesTo("lunasea") {
+		d.enqueue("lunasea", retry, text, func() error {
+			return d.sendJSON("lunasea", d.cfg().LunaSeaWebhook, map[string]string{"title": "Docker-Guardian", "body": d.fit("lunasea", text)})
+		})
+	}
+	if d.cfg().TeamsWebhook != "" && evt.routesTo("teams") {
+		d.enqueue("teams", retry, text, func() error {
+			return d.sendTeams(d.fit("teams", text), sev)
+		})
+	}
+	if d.cfg().NtfyURL != "" && d.cfg().NtfyTopic != "" && evt.routesTo("ntfy") {
+		d.enqueue("ntfy", retry, text, func() error {
+			return d.sendNtfy(d.fit("ntfy", text), sev)
+		})
+	}
+	if d.cfg().EmailSMTP != "" && evt.routesTo("email") {
+		d.enqueue("email", retry, text, func() error {
+			return d.sendEmail(d.fit("email", text))
+		})
+	}
+}
+
+// telegramChatIDs splits NOTIFY_TELEGRAM_CHAT_ID into its comma-separated
+// chat IDs, ignoring blanks.
+func telegramChatIDs(raw string) []string {
+	var ids []string
+	for _, id := range strings.Split(raw, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// tag is one routing tag prepended to notifications and sent as a
+// structured field where the service supports it.
+type tag struct {
+	key   string
+	value string
+}
+
+// tags returns the configured routing tags, broadest first:
+// environment, cluster, hostname.
+func (d *Dispatcher) tags() []tag {
+	var tags []tag
+	for _, t := range []tag{
+		{"environment", d.cfg().NotifyEnvironment},
+		{"cluster", d.cfg().NotifyCluster},
+		{"hostname", d.cfg().NotifyHostname},
+	} {
+		if t.value != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// gotifyPriority maps severity onto Gotify's 0-10 priority scale.
+func gotifyPriority(sev severity) int {
+	if sev == severityCritical {
+		return 8
+	}
+	return 5
+}
+
+// discordColor is the embed colour: red for critical, green otherwise.
+func discordColor(sev severity) int {
+	if sev == severityCritical {
+		return 15158332
+	}
+	return 3066993
+}
+
+// slackPayload sends critical messages as a "danger" attachment so they get
+// a red bar; everything else stays a plain text message.
+func slackPayload(text string, sev severity) any {
+	if sev == severityCritical {
+		return map[string]any{"attachments": []map[string]string{{"color": "danger", "text": text}}}
+	}
+	return map[string]string{"text": text}
+}
+
+// pushoverPriority is 1 (high, bypasses quiet hours) for critical messages.
+func pushoverPriority(sev severity) string {
+	if sev == severityCritical {
+		return "1"
+	}
+	return "0"
+}
+
+// sendTeams posts a legacy MessageCard, the format Teams incoming webhooks
+// accept. Failures and [CRITICAL] alerts are coloured red.
+func (d *Dispatcher) sendTeams(text string, sev severity) error {
+	color := "2EB886"
+	if strings.Contains(text, "Failed") || sev == severityCritical {
+		color = "D13438"
 	}
-	if d.cfg.EmailSMTP != "" {
+	return d.sendJSON("teams", d.cfg().TeamsWebhook, map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": color,
+		"summary":    "Docker-Guardian",
+		"sections": []map[string]any{{
+			"activityTitle": "Docker-Guardian",
+			"text":          text,
+		}},
+	})
+}
+
+// sendNtfy publishes the plain text to {NOTIFY_NTFY_URL}/{NOTIFY_NTFY_TOPIC}.
+// Failures are sent at high priority and [CRITICAL] alerts as urgent.
+func (d *Dispatcher) sendNtfy(text string, sev severity) error {
+	priority, tags := "default", "white_check_mark"
+	switch {
+	case sev == severityCritical:
+		priority, tags = "urgent", "rotating_light"
+	case strings.Contains(text, "Failed"):
+		priority, tags = "high", "warning"
+	}
+	endpoint := strings.TrimRight(d.cfg().NtfyURL, "/") + "/" + url.PathEscape(d.cfg().NtfyTopic)
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(text))
+	if err != nil {
+		d.log.Warn("failed to create notification request", "error", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", "Docker-Guardian")
+	req.Header.Set("Priority", priority)
+	req.Header.Set("Tags", tags)
+	return d.post("ntfy", req)
+}
+
+// delivery is one queued send to a single service.
+type delivery struct {
+	service string
+	retry   bool
+	send    func() error
+}
+
+// startWorkers starts the delivery pool: n workers, each with a queue of size
+// pending sends.
+func (d *Dispatcher) startWorkers(n, size int) {
+	n = max(n, 1)
+	if size < 1 {
+		size = 100
+	}
+	d.queues = make([]chan delivery, n)
+	for i := range d.queues {
+		q := make(chan delivery, size)
+		d.queues[i] = q
 		d.wg.Add(1)
 		go func() {
 			defer d.wg.Done()
-			d.sendWithRetry("email", retry, func() error {
-				return d.sendEmail(text)
-			})
+			for job := range q {
+				d.sendWithRetry(d.ctx, job.service, job.retry, job.send)
+			}
 		}()
 	}
 }
 
+// enqueue hands a send to the service's worker. A full queue drops the send
+// and counts it, so a notification storm can't stall the guardian; [CRITICAL]
+// messages wait for room instead. Sends after Close are discarded.
+func (d *Dispatcher) enqueue(service string, retry bool, text string, send func() error) {
+	d.queueMu.RLock()
+	defer d.queueMu.RUnlock()
+	if d.closed {
+		return
+	}
+	h := fnv.New32a()
+	h.Write([]byte(service))
+	q := d.queues[h.Sum32()%uint32(len(d.queues))]
+
+	job := delivery{service: service, retry: retry, send: send}
+	if strings.Contains(text, "[CRITICAL]") {
+		q <- job
+		return
+	}
+	select {
+	case q <- job:
+	default:
+		metrics.NotificationsQueueFullTotal.WithLabelValues(service).Inc()
+		d.log.Warn("notification queue full, dropping", "service", service, "queue_size", cap(q))
+	}
+}
+
+// serviceMaxLength is each service's hard limit on the message field, in
+// characters. Services not listed accept any length.
+var serviceMaxLength = map[string]int{
+	"discord":  4096, // embed description
+	"slack":    40000,
+	"telegram": 4096,
+	"pushover": 1024,
+	"teams":    28000, // incoming webhooks reject payloads over 28 KB
+	"ntfy":     4096,  // longer bodies are turned into attachments
+}
+
+// fit truncates text to the service's message limit (or NOTIFY_MAX_LENGTH,
+// whichever is lower), ending it with an ellipsis, so an oversized alert is
+// shortened rather than rejected. It runs after all enrichment.
+func (d *Dispatcher) fit(service, text string) string {
+	limit := serviceMaxLength[service]
+	if m := d.cfg().NotifyMaxLength; m > 0 && (limit == 0 || m < limit) {
+		limit = m
+	}
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit-1]) + "…"
+}
+
+// footerData is the data available to NOTIFY_FOOTER placeholders.
+type footerData struct {
+	Hostname      string
+	Container     string
+	ContainerID   string
+	Project       string
+	Service       string
+	CorrelationID string
+}
+
+// renderFooter executes NOTIFY_FOOTER for an event. Rendering happens after
+// rate limiting, so the footer never affects the rate-limit key.
+func (d *Dispatcher) renderFooter(evt Event) string {
+	s := d.settings.Load()
+	if s.footer == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	err := s.footer.Execute(&buf, footerData{
+		Hostname:      s.hostname,
+		Container:     evt.Container,
+		ContainerID:   evt.ContainerID,
+		Project:       evt.Project,
+		Service:       evt.Service,
+		CorrelationID: evt.CorrelationID,
+	})
+	if err != nil {
+		d.log.Warn("failed to render NOTIFY_FOOTER", "error", err)
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// templateData is the data available to NOTIFY_TEMPLATE_* placeholders.
+// Text is the built-in message.
+type templateData struct {
+	Text          string
+	Container     string
+	ContainerID   string
+	ShortID       string
+	Action        string
+	Result        string
+	Hostname      string
+	Project       string
+	Service       string
+	CorrelationID string
+}
+
+// renderTemplate replaces the event text using the NOTIFY_TEMPLATE_* for
+// kind, keeping the built-in text when none is set or rendering fails.
+// Rendering happens after rate limiting and deduplication, which key on the
+// built-in text.
+func (d *Dispatcher) renderTemplate(kind string, evt Event) string {
+	s := d.settings.Load()
+	tmpl := s.templates[kind]
+	if tmpl == nil {
+		return evt.Text
+	}
+	shortID := evt.ContainerID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, templateData{
+		Text:          evt.Text,
+		Container:     evt.Container,
+		ContainerID:   evt.ContainerID,
+		ShortID:       shortID,
+		Action:        evt.Action,
+		Result:        evt.Result,
+		Hostname:      s.hostname,
+		Project:       evt.Project,
+		Service:       evt.Service,
+		CorrelationID: evt.CorrelationID,
+	})
+	if err != nil {
+		d.log.Warn("failed to render notification template", "kind", kind, "error", err)
+		return evt.Text
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// logsHintData is the data available to NOTIFY_LOGS_HINT placeholders.
+type logsHintData struct {
+	Container string
+	ShortID   string
+}
+
+// renderLogsHint executes NOTIFY_LOGS_HINT for a container action event.
+// Guardian-level events have no container to point at and get no hint.
+func (d *Dispatcher) renderLogsHint(evt Event) string {
+	hint := d.settings.Load().logsHint
+	if hint == nil || evt.Container == "" {
+		return ""
+	}
+	shortID := evt.ContainerID
+	if len(shortID) > 12 {
+		shortID = shortID[:12]
+	}
+	var buf bytes.Buffer
+	if err := hint.Execute(&buf, logsHintData{Container: evt.Container, ShortID: shortID}); err != nil {
+		d.log.Warn("failed to render NOTIFY_LOGS_HINT", "error", err)
+		return ""
+	}
+	return strings.TrimSpace(buf.String())
+}
+
 // sendWithRetry retries a send function up to 3 times with exponential backoff.
-// Only retries if retry=true. Tracks metrics per service.
-func (d *Dispatcher) sendWithRetry(service string, retry bool, fn func() error) {
+// Only retries if retry=true, and stops retrying once ctx is cancelled. Tracks
+// metrics per service: each retried attempt counts towards retries, and
+// failure means every attempt failed.
+func (d *Dispatcher) sendWithRetry(ctx context.Context, service string, retry bool, fn func() error) {
 	maxAttempts := 1
 	if retry {
 		maxAttempts = 3
 	}
 
-	delays := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
-
 	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			metrics.NotificationRetriesTotal.WithLabelValues(service).Inc()
+		}
 		if err := fn(); err == nil {
 			metrics.NotificationsTotal.WithLabelValues(service, "success").Inc()
+			metrics.NotificationAttempts.WithLabelValues(service).Observe(float64(attempt + 1))
 			return
 		}
 		// Only retry if we have attempts left
 		if attempt < maxAttempts-1 {
-			time.Sleep(delays[attempt])
+			select {
+			case <-time.After(d.retryDelays[attempt]):
+			case <-ctx.Done():
+				metrics.NotificationsTotal.WithLabelValues(service, "failure").Inc()
+				return
+			}
 		}
 	}
 	metrics.NotificationsTotal.WithLabelValues(service, "failure").Inc()
 }
 
-func (d *Dispatcher) sendJSON(targetURL string, payload any) error {
-	body, err := json.Marshal(payload)
-	if err != nil {
-		d.log.Error("failed to marshal notification payload", "error", err)
-		return err
+// timeout returns the per-request deadline for a service: its
+// NOTIFY_<SERVICE>_TIMEOUT override, or CURL_TIMEOUT.
+func (d *Dispatcher) timeout(service string) time.Duration {
+	if t, ok := d.cfg().NotifyTimeouts[service]; ok && t > 0 {
+		return time.Duration(t) * time.Second
 	}
-	resp, err := d.client.Post(targetURL, "application/json", bytes.NewReader(body))
+	return time.Duration(d.cfg().CurlTimeout) * time.Second
+}
+
+// post sends req with the service's timeout and checks for a 2xx response.
+func (d *Dispatcher) post(service string, req *http.Request) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout(service))
+	defer cancel()
+	resp, err := d.client.Do(req.WithContext(ctx))
 	if err != nil {
-		d.log.Warn("notification send failed", "url", targetURL, "error", err)
+		d.log.Warn("notification send failed", "url", req.URL.String(), "error", err)
 		return err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		d.log.Warn("notification returned non-2xx status", "url", targetURL, "status", resp.StatusCode)
+		d.log.Warn("notification returned non-2xx status", "url", req.URL.String(), "status", resp.StatusCode)
 		return fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
 	return nil
 }
 
-func (d *Dispatcher) sendJSONWithHeader(targetURL, headerKey, headerVal string, payload any) error {
+func (d *Dispatcher) sendJSON(service, targetURL string, payload any) error {
+	return d.sendJSONWithHeader(service, targetURL, "", "", payload)
+}
+
+func (d *Dispatcher) sendJSONWithHeader(service, targetURL, headerKey, headerVal string, payload any) error {
+	req, _, err := d.jsonRequest(targetURL, payload)
+	if err != nil {
+		return err
+	}
+	if headerKey != "" {
+		req.Header.Set(headerKey, headerVal)
+	}
+	return d.post(service, req)
+}
+
+// jsonRequest builds a JSON POST of payload, also returning the encoded body.
+func (d *Dispatcher) jsonRequest(targetURL string, payload any) (*http.Request, []byte, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		d.log.Warn("failed to marshal notification payload", "error", err)
-		return err
+		return nil, nil, err
 	}
 	req, err := http.NewRequest(http.MethodPost, targetURL, bytes.NewReader(body))
 	if err != nil {
 		d.log.Warn("failed to create notification request", "error", err)
-		return err
+		return nil, nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set(headerKey, headerVal)
-	resp, err := d.client.Do(req)
+	return req, body, nil
+}
+
+// sendWebhook posts to the generic WEBHOOK_URL. With WEBHOOK_SECRET set the
+// body is signed in an X-Guardian-Signature: sha256=<hex> header so the
+// receiver can reject spoofed posts.
+func (d *Dispatcher) sendWebhook(payload any) error {
+	req, body, err := d.jsonRequest(d.cfg().WebhookURL, payload)
 	if err != nil {
-		d.log.Warn("notification send failed", "url", targetURL, "error", err)
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		d.log.Warn("notification returned non-2xx status", "url", targetURL, "status", resp.StatusCode)
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	if secret := d.cfg().WebhookSecret; secret != "" {
+		req.Header.Set("X-Guardian-Signature", webhookSignature(secret, body))
 	}
-	return nil
+	return d.post("webhook", req)
+}
+
+// webhookSignature is the X-Guardian-Signature value for body.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
 
-func (d *Dispatcher) sendForm(endpoint string, fields map[string]string) error {
+func (d *Dispatcher) sendForm(service, endpoint string, fields map[string]string) error {
 	vals := url.Values{}
 	for k, v := range fields {
 		vals.Set(k, v)
 	}
-	resp, err := d.client.Post(endpoint, "application/x-www-form-urlencoded", strings.NewReader(vals.Encode()))
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(vals.Encode()))
 	if err != nil {
-		d.log.Warn("notification send failed", "url", endpoint, "error", err)
+		d.log.Warn("failed to create notification request", "error", err)
 		return err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		d.log.Warn("notification returned non-2xx status", "url", endpoint, "status", resp.StatusCode)
-		return fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-	return nil
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return d.post(service, req)
 }
 
+// sendEmail delivers over SMTP like smtp.SendMail, but with the whole
+// exchange bounded by the email timeout.
 func (d *Dispatcher) sendEmail(text string) error {
 	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: Docker-Guardian Alert\r\n\r\n%s",
-		d.cfg.EmailFrom, d.cfg.EmailTo, text)
+		d.cfg().EmailFrom, d.cfg().EmailTo, text)
 
-	auth := smtp.PlainAuth("", d.cfg.EmailUser, d.cfg.EmailPass, strings.Split(d.cfg.EmailSMTP, ":")[0])
-	err := smtp.SendMail(d.cfg.EmailSMTP, auth, d.cfg.EmailFrom, []string{d.cfg.EmailTo}, []byte(msg))
+	err := d.smtpSend([]byte(msg))
 	if err != nil {
 		d.log.Warn("email notification failed", "error", err)
 		return err
 	}
 	return nil
 }
+
+func (d *Dispatcher) smtpSend(msg []byte) error {
+	host := strings.Split(d.cfg().EmailSMTP, ":")[0]
+	timeout := d.timeout("email")
+	conn, err := net.DialTimeout("tcp", d.cfg().EmailSMTP, timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if ok, _ := c.Extension("AUTH"); ok && d.cfg().EmailUser != "" {
+		if err := c.Auth(smtp.PlainAuth("", d.cfg().EmailUser, d.cfg().EmailPass, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(d.cfg().EmailFrom); err != nil {
+		return err
+	}
+	if err := c.Rcpt(d.cfg().EmailTo); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -1,14 +1,33 @@
 package notify
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Will-Luck/Docker-Guardian/internal/config"
 	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+	"github.com/Will-Luck/Docker-Guardian/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func newTestDispatcher(cfg *config.Config) *Dispatcher {
-	return NewDispatcher(cfg, logging.New(false))
+	return NewDispatcher(cfg, logging.New(false, slog.LevelDebug))
 }
 
 func TestConfiguredServices(t *testing.T) {
@@ -26,6 +45,9 @@ func TestConfiguredServices(t *testing.T) {
 			GotifyToken:    "tok",
 			DiscordWebhook: "http://discord.example.com",
 		}, "gotify discord"},
+		{"teams", &config.Config{CurlTimeout: 5, NotifyEvents: "actions", TeamsWebhook: "http://teams.example.com"}, "teams"},
+		{"ntfy", &config.Config{CurlTimeout: 5, NotifyEvents: "actions", NtfyURL: "https://ntfy.sh", NtfyTopic: "guardian"}, "ntfy"},
+		{"ntfy without topic", &config.Config{CurlTimeout: 5, NotifyEvents: "actions", NtfyURL: "https://ntfy.sh"}, "none"},
 	}
 
 	for _, tt := range tests {
@@ -83,3 +105,928 @@ func TestHasEventDebug(t *testing.T) {
 		t.Error("'debug' should include startup")
 	}
 }
+
+func TestGlobalCap_DropsExcessLetsCriticalThrough(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:        5,
+		NotifyEvents:       "actions",
+		WebhookURL:         srv.URL,
+		WebhookJSONKey:     "text",
+		NotifyMaxPerWindow: 3,
+		NotifyGlobalWindow: 3600,
+	})
+
+	for i := range 10 {
+		d.Action(Event{Text: fmt.Sprintf("Container app-%d (%012d) found to be unhealthy. Successfully restarted the container!", i, i)})
+	}
+	d.Action(Event{Text: "[CRITICAL] Container db circuit open"})
+	d.Action(Event{Text: "[CRITICAL] Container cache circuit open"})
+	d.Close()
+
+	if got := hits.Load(); got != 5 {
+		t.Errorf("sent %d notifications, want 5 (3 capped + 2 critical)", got)
+	}
+}
+
+func TestGlobalCap_DisabledByDefault(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions"})
+	for range 100 {
+		if d.isGloballyCapped("Container app found to be unhealthy") {
+			t.Fatal("global cap should be disabled when NOTIFY_MAX_PER_WINDOW is 0")
+		}
+	}
+}
+
+func TestSendWithRetry_CountsRetries(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions"})
+	d.retryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+
+	const service = "retry-test"
+	calls := 0
+	d.sendWithRetry(context.Background(), service, true, func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	if calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls)
+	}
+	if got := testutil.ToFloat64(metrics.NotificationRetriesTotal.WithLabelValues(service)); got != 1 {
+		t.Errorf("retries = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.NotificationsTotal.WithLabelValues(service, "success")); got != 1 {
+		t.Errorf("success = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.NotificationsTotal.WithLabelValues(service, "failure")); got != 0 {
+		t.Errorf("failure = %v, want 0", got)
+	}
+}
+
+func TestSendWithRetry_StopsOnCancel(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions"})
+	d.retryDelays = []time.Duration{time.Minute, time.Minute, time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	start := time.Now()
+	d.sendWithRetry(ctx, "cancel-test", true, func() error {
+		calls++
+		cancel() // shutdown while the first attempt is failing
+		return errors.New("timeout")
+	})
+
+	if calls != 1 {
+		t.Errorf("send invoked %d times, want 1 (no retry after cancellation)", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry wait not aborted promptly: %v", elapsed)
+	}
+	if got := testutil.ToFloat64(metrics.NotificationsTotal.WithLabelValues("cancel-test", "failure")); got != 1 {
+		t.Errorf("failure = %v, want 1", got)
+	}
+}
+
+func TestDispatch_PinnedServices(t *testing.T) {
+	var webhookHits, discordHits, slackHits atomic.Int32
+	server := func(hits *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			hits.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+	webhook, discord, slack := server(&webhookHits), server(&discordHits), server(&slackHits)
+	defer webhook.Close()
+	defer discord.Close()
+	defer slack.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:    5,
+		NotifyEvents:   "actions",
+		WebhookURL:     webhook.URL,
+		WebhookJSONKey: "text",
+		DiscordWebhook: discord.URL,
+		SlackWebhook:   slack.URL,
+	})
+
+	d.Action(Event{Text: "Container payments restarted", Container: "payments", Services: []string{"discord", "slack"}})
+	d.Action(Event{Text: "Container web restarted", Container: "web"})
+	d.Close()
+
+	if got := webhookHits.Load(); got != 1 {
+		t.Errorf("webhook: expected only the unpinned notification, got %d", got)
+	}
+	if got := discordHits.Load(); got != 2 {
+		t.Errorf("discord: expected both notifications, got %d", got)
+	}
+	if got := slackHits.Load(); got != 2 {
+		t.Errorf("slack: expected both notifications, got %d", got)
+	}
+}
+
+func TestDispatch_AppendsFooter(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		bodies <- payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:    5,
+		NotifyEvents:   "actions",
+		NotifyHostname: "prod-1",
+		NotifyFooter:   "guardian@{{.Hostname}} | runbook: https://wiki.example.com/{{.Container}}",
+		WebhookURL:     server.URL,
+		WebhookJSONKey: "text",
+	})
+
+	d.Action(Event{Text: "Container web restarted", Container: "web"})
+	d.Close()
+
+	want := "[prod-1] Container web restarted\nguardian@prod-1 | runbook: https://wiki.example.com/web"
+	if got := <-bodies; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDispatch_ComposeNames(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		bodies <- payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:        5,
+		NotifyEvents:       "actions",
+		NotifyComposeNames: true,
+		NotifyFooter:       "{{.Service}}@{{.Project}}",
+		WebhookURL:         server.URL,
+		WebhookJSONKey:     "text",
+	})
+
+	d.Action(Event{Text: "Container shop-web-1 restarted", Container: "shop-web-1", Project: "shop", Service: "web"})
+	d.Action(Event{Text: "Container solo restarted", Container: "solo", Service: "solo"})
+	d.Close()
+
+	got := []string{<-bodies, <-bodies}
+	want := []string{"Container shop-web-1 restarted\nService web in project shop\nweb@shop", "Container solo restarted\nsolo@"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDispatch_RoutingTags(t *testing.T) {
+	payloads := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		payloads <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:       5,
+		NotifyEvents:      "actions",
+		NotifyEnvironment: "prod",
+		NotifyCluster:     "cluster-a",
+		NotifyHostname:    "host-1",
+		WebhookURL:        server.URL,
+		WebhookJSONKey:    "text",
+	})
+
+	d.Action(Event{Text: "Container web restarted", Container: "web"})
+	d.Close()
+
+	got := <-payloads
+	want := map[string]string{
+		"text":        "[prod][cluster-a][host-1] Container web restarted",
+		"environment": "prod",
+		"cluster":     "cluster-a",
+		"hostname":    "host-1",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestDispatch_MessageTemplates(t *testing.T) {
+	bodies := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		bodies <- payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:           5,
+		NotifyEvents:          "actions,skips,startup",
+		NotifyHostname:        "host-1",
+		NotifyTemplateAction:  "guardian host={{.Hostname}} container={{.Container}} id={{.ShortID}} action={{.Action}} result={{.Result}}",
+		NotifyTemplateStartup: "{{.Container", // malformed: keeps the built-in text
+		WebhookURL:            server.URL,
+		WebhookJSONKey:        "text",
+	})
+
+	d.Action(Event{Text: "Container web (abcdef123456) found to be unhealthy. Successfully restarted the container!",
+		Container: "web", ContainerID: "abcdef1234567890", Action: "restart", Result: "success"})
+	d.Skip(Event{Text: "Container web (abcdef123456) skipped - grace period", Container: "web"})
+	d.Startup("Docker-Guardian started")
+	d.Close()
+
+	want := []string{
+		"[host-1] guardian host=host-1 container=web id=abcdef123456 action=restart result=success",
+		"[host-1] Container web (abcdef123456) skipped - grace period",
+		"[host-1] Docker-Guardian started",
+	}
+	for i, w := range want {
+		if got := <-bodies; got != w {
+			t.Errorf("message %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestDispatch_TeamsMessageCard(t *testing.T) {
+	cards := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var card map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		cards <- card
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", TeamsWebhook: server.URL})
+	d.Action(Event{Text: "Container web (abcdef123456) found to be unhealthy. Failed to restart the container!", Container: "web"})
+	d.Close()
+
+	card := <-cards
+	for key, want := range map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": "D13438",
+		"summary":    "Docker-Guardian",
+	} {
+		if card[key] != want {
+			t.Errorf("%s = %v, want %v", key, card[key], want)
+		}
+	}
+	sections, ok := card["sections"].([]any)
+	if !ok || len(sections) != 1 {
+		t.Fatalf("expected one section, got %v", card["sections"])
+	}
+	section, _ := sections[0].(map[string]any)
+	if text, _ := section["text"].(string); !strings.Contains(text, "Failed to restart the container") {
+		t.Errorf("section text = %q", section["text"])
+	}
+}
+
+func TestDispatch_SeverityPerService(t *testing.T) {
+	bodies := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		bodies <- body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gotify := func(b map[string]any) any { return b["priority"] }
+	discord := func(b map[string]any) any {
+		embeds, _ := b["embeds"].([]any)
+		embed, _ := embeds[0].(map[string]any)
+		return embed["color"]
+	}
+	slack := func(b map[string]any) any {
+		attachments, ok := b["attachments"].([]any)
+		if !ok {
+			return nil
+		}
+		attachment, _ := attachments[0].(map[string]any)
+		return attachment["color"]
+	}
+
+	tests := []struct {
+		name           string
+		cfg            *config.Config
+		field          func(map[string]any) any
+		normal, urgent any
+	}{
+		{"gotify", &config.Config{GotifyURL: server.URL, GotifyToken: "tok"}, gotify, float64(5), float64(8)},
+		{"discord", &config.Config{DiscordWebhook: server.URL}, discord, float64(3066993), float64(15158332)},
+		{"slack", &config.Config{SlackWebhook: server.URL}, slack, nil, "danger"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range []struct {
+				text string
+				want any
+			}{
+				{"Container web (abcdef123456) found to be unhealthy. Successfully restarted the container!", tt.normal},
+				{"[CRITICAL] Container web (abcdef123456) did not recover: still unhealthy", tt.urgent},
+			} {
+				tt.cfg.CurlTimeout, tt.cfg.NotifyEvents = 5, "actions"
+				d := newTestDispatcher(tt.cfg)
+				d.Action(Event{Text: c.text, Container: "web"})
+				d.Close()
+				if got := tt.field(<-bodies); got != c.want {
+					t.Errorf("%q: got %v, want %v", c.text, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSeverityMappings(t *testing.T) {
+	if got := pushoverPriority(severityNormal); got != "0" {
+		t.Errorf("pushover normal priority = %s, want 0", got)
+	}
+	if got := pushoverPriority(severityCritical); got != "1" {
+		t.Errorf("pushover critical priority = %s, want 1", got)
+	}
+	if severityOf("Container web restarted") != severityNormal || severityOf("[CRITICAL] circuit open") != severityCritical {
+		t.Error("severityOf should key on the [CRITICAL] marker")
+	}
+}
+
+func TestDispatch_NtfyHeadersAndBody(t *testing.T) {
+	type request struct {
+		path   string
+		header http.Header
+		body   string
+	}
+	got := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.URL.Path, r.Header, string(body)}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", NtfyURL: server.URL + "/", NtfyTopic: "guardian"})
+	d.Action(Event{Text: "Container web (abcdef123456) found to be unhealthy. Failed to restart the container!", Container: "web"})
+	d.Close()
+
+	req := <-got
+	if req.path != "/guardian" {
+		t.Errorf("path = %q, want /guardian", req.path)
+	}
+	for key, want := range map[string]string{"Title": "Docker-Guardian", "Priority": "high", "Tags": "warning"} {
+		if v := req.header.Get(key); v != want {
+			t.Errorf("%s header = %q, want %q", key, v, want)
+		}
+	}
+	if !strings.Contains(req.body, "Failed to restart the container!") {
+		t.Errorf("body = %q", req.body)
+	}
+}
+
+func TestDispatch_TelegramPostPerChatID(t *testing.T) {
+	got := make(chan map[string]any, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bottok/sendMessage" {
+			t.Errorf("path = %q, want /bottok/sendMessage", r.URL.Path)
+		}
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		got <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sent := testutil.ToFloat64(metrics.NotificationsTotal.WithLabelValues("telegram", "success"))
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", TelegramToken: "tok", TelegramChatID: "-100123, 456", TelegramThreadID: 7})
+	d.telegramAPI = server.URL
+	d.Action(Event{Text: "Container web (abcdef123456) found to be unhealthy. Successfully restarted the container!", Container: "web"})
+	d.Close()
+
+	if len(got) != 2 {
+		t.Fatalf("expected one POST per chat ID, got %d", len(got))
+	}
+	chats := map[any]bool{}
+	for range 2 {
+		payload := <-got
+		chats[payload["chat_id"]] = true
+		if payload["message_thread_id"] != float64(7) {
+			t.Errorf("message_thread_id = %v, want 7", payload["message_thread_id"])
+		}
+	}
+	if !chats["-100123"] || !chats["456"] {
+		t.Errorf("chat IDs sent = %v", chats)
+	}
+	if n := testutil.ToFloat64(metrics.NotificationsTotal.WithLabelValues("telegram", "success")) - sent; n != 2 {
+		t.Errorf("telegram successes = %v, want 2", n)
+	}
+
+	// A single ID without a thread sends exactly as before
+	d = newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", TelegramToken: "tok", TelegramChatID: "456"})
+	d.telegramAPI = server.URL
+	d.Action(Event{Text: "Container db (bcdef1234567) found to be unhealthy. Successfully restarted the container!", Container: "db"})
+	d.Close()
+	if len(got) != 1 {
+		t.Fatalf("expected one POST for a single chat ID, got %d", len(got))
+	}
+	if payload := <-got; payload["chat_id"] != "456" || payload["message_thread_id"] != nil {
+		t.Errorf("single-chat payload = %v", payload)
+	}
+}
+
+func TestDispatch_WebhookSignature(t *testing.T) {
+	type request struct {
+		signature string
+		body      []byte
+	}
+	got := make(chan request, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.Header.Get("X-Guardian-Signature"), body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: server.URL, WebhookJSONKey: "text", WebhookSecret: "s3cret"})
+	d.Action(Event{Text: "Container web (abcdef123456) found to be unhealthy. Successfully restarted the container!", Container: "web"})
+	d.Close()
+
+	req := <-got
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(req.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); req.signature != want {
+		t.Errorf("X-Guardian-Signature = %q, want %q", req.signature, want)
+	}
+
+	// Without a secret the header is left off
+	d = newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: server.URL, WebhookJSONKey: "text"})
+	d.Action(Event{Text: "Container db (bcdef1234567) found to be unhealthy. Successfully restarted the container!", Container: "db"})
+	d.Close()
+	if req := <-got; req.signature != "" {
+		t.Errorf("unsigned webhook sent X-Guardian-Signature %q", req.signature)
+	}
+}
+
+func TestFit_TruncatesPerService(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions"})
+	long := strings.Repeat("x", 50000)
+
+	tests := []struct {
+		service string
+		want    int
+	}{
+		{"discord", 4096},
+		{"slack", 40000},
+		{"telegram", 4096},
+		{"pushover", 1024},
+		{"gotify", 50000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			got := d.fit(tt.service, long)
+			if n := utf8.RuneCountInString(got); n != tt.want {
+				t.Errorf("length = %d, want %d", n, tt.want)
+			}
+			if tt.want < len(long) && !strings.HasSuffix(got, "…") {
+				t.Error("truncated message should end with an ellipsis")
+			}
+		})
+	}
+
+	capped := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", NotifyMaxLength: 500})
+	for _, svc := range []string{"gotify", "pushover"} {
+		if n := utf8.RuneCountInString(capped.fit(svc, long)); n != 500 {
+			t.Errorf("%s with NOTIFY_MAX_LENGTH=500: length = %d", svc, n)
+		}
+	}
+	if got := capped.fit("pushover", "short"); got != "short" {
+		t.Errorf("short message changed: %q", got)
+	}
+}
+
+func TestDispatch_TruncatesAfterEnrichment(t *testing.T) {
+	descriptions := make(chan string, 1)
+	discord := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []struct {
+				Description string `json:"description"`
+			} `json:"embeds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		descriptions <- payload.Embeds[0].Description
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer discord.Close()
+	webhookBodies := make(chan string, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		webhookBodies <- payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhook.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:    5,
+		NotifyEvents:   "actions",
+		NotifyFooter:   "runbook: https://wiki.example.com/{{.Container}}",
+		DiscordWebhook: discord.URL,
+		WebhookURL:     webhook.URL,
+		WebhookJSONKey: "text",
+	})
+
+	text := "Container web restarted. Health log: " + strings.Repeat("connection refused; ", 300)
+	d.Action(Event{Text: text, Container: "web"})
+	d.Close()
+
+	got := <-descriptions
+	if n := utf8.RuneCountInString(got); n != 4096 || !strings.HasSuffix(got, "…") {
+		t.Errorf("discord: got %d characters ending %q, want 4096 ending with an ellipsis", n, got[len(got)-10:])
+	}
+	full := <-webhookBodies
+	if !strings.HasSuffix(full, "runbook: https://wiki.example.com/web") {
+		t.Errorf("webhook has no limit and should keep the footer, got suffix %q", full[len(full)-40:])
+	}
+}
+
+func TestAction_AppendsLogsHint(t *testing.T) {
+	bodies := make(chan string, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		bodies <- payload["text"]
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:    5,
+		NotifyEvents:   "actions",
+		NotifyLogsHint: "docker logs --tail 100 {{.Container}} # {{.ShortID}}",
+		WebhookURL:     server.URL,
+		WebhookJSONKey: "text",
+	})
+
+	d.Action(Event{Text: "Container web restarted", Container: "web", ContainerID: "abcdef1234567890abcdef"})
+	d.Action(Event{Text: "Docker-Guardian actions DISABLED (POST /disable)"})
+	d.Close()
+
+	got := []string{<-bodies, <-bodies}
+	want := "Container web restarted\ndocker logs --tail 100 web # abcdef123456"
+	if !slices.Contains(got, want) {
+		t.Errorf("expected %q among %q", want, got)
+	}
+	if !slices.Contains(got, "Docker-Guardian actions DISABLED (POST /disable)") {
+		t.Errorf("guardian-level notification should carry no hint, got %q", got)
+	}
+}
+
+func TestDispatch_PerServiceTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:    1,
+		NotifyEvents:   "startup",
+		GotifyURL:      slow.URL,
+		DiscordWebhook: slow.URL,
+		NotifyTimeouts: map[string]int{"gotify": 5},
+	})
+
+	gotifyOK := metrics.NotificationsTotal.WithLabelValues("gotify", "success")
+	discordFailed := metrics.NotificationsTotal.WithLabelValues("discord", "failure")
+	gotifyBefore, discordBefore := testutil.ToFloat64(gotifyOK), testutil.ToFloat64(discordFailed)
+
+	d.Startup("Docker-Guardian started")
+	d.Close()
+
+	if got := testutil.ToFloat64(gotifyOK) - gotifyBefore; got != 1 {
+		t.Errorf("gotify with NOTIFY_GOTIFY_TIMEOUT=5 should outlast the slow server, successes +%v", got)
+	}
+	if got := testutil.ToFloat64(discordFailed) - discordBefore; got != 1 {
+		t.Errorf("discord on the 1s CURL_TIMEOUT default should time out, failures +%v", got)
+	}
+}
+
+func TestRateLimit_PrunesExpiredKeys(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", NotifyRateLimit: 60})
+
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		d.rateLimit[fmt.Sprintf("Container old-%d restarted", i)] = rateWindow{start: now.Add(-2 * time.Minute), sent: 1}
+	}
+	d.rateLimit["Container recent restarted"] = rateWindow{start: now.Add(-10 * time.Second), sent: 1}
+
+	if d.isRateLimited("Container new restarted") {
+		t.Fatal("first notification for a new key should not be limited")
+	}
+
+	if n := len(d.rateLimit); n != 2 {
+		t.Errorf("expected only the recent and new keys to remain, got %d", n)
+	}
+	if !d.isRateLimited("Container recent restarted") {
+		t.Error("recent key should still be rate limited after pruning")
+	}
+}
+
+func TestRateLimit_KeyedByContainerAndCategory(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:     5,
+		NotifyEvents:    "actions,skips",
+		WebhookURL:      srv.URL,
+		WebhookJSONKey:  "text",
+		NotifyRateLimit: 60,
+	})
+
+	d.Action(Event{Text: "Container web (0123456789ab) found to be unhealthy. Successfully restarted the container!", Container: "web"})
+	d.Action(Event{Text: "Container db (abcdef123456) found to be unhealthy. Successfully restarted the container!", Container: "db"})
+	d.Action(Event{Text: "Container web (0123456789ab) found to be unhealthy. Failed to restart the container!", Container: "web"})
+	d.Skip(Event{Text: "Container web (0123456789ab) skipped - grace period", Container: "web"})
+	d.Action(Event{Text: "[CRITICAL] Container web (0123456789ab) did not recover", Container: "web"})
+	d.Close()
+
+	// web and db each once, web's skip and critical alert in their own
+	// categories; web's second action is suppressed.
+	if got := hits.Load(); got != 4 {
+		t.Errorf("sent %d notifications, want 4", got)
+	}
+}
+
+func TestRateLimit_Burst(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", NotifyRateLimit: 60, NotifyRateLimitBurst: 3})
+
+	key := rateKey(Event{Container: "web"}, "action")
+	for i := range 3 {
+		if d.isRateLimited(key) {
+			t.Fatalf("notification %d should fit in the burst", i+1)
+		}
+	}
+	if !d.isRateLimited(key) {
+		t.Error("fourth notification in the window should be limited")
+	}
+	if d.isRateLimited(rateKey(Event{Container: "db"}, "action")) {
+		t.Error("another container should have its own burst")
+	}
+}
+
+func TestDispatch_BoundedConcurrencyAndCloseFlushes(t *testing.T) {
+	var inFlight, peak, received atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		received.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:     5,
+		NotifyEvents:    "startup",
+		WebhookURL:      srv.URL,
+		AppriseURL:      srv.URL,
+		GotifyURL:       srv.URL,
+		DiscordWebhook:  srv.URL,
+		SlackWebhook:    srv.URL,
+		LunaSeaWebhook:  srv.URL,
+		NotifyWorkers:   2,
+		NotifyQueueSize: 100,
+	})
+
+	const burst = 20
+	for i := range burst {
+		d.Startup(fmt.Sprintf("startup %d", i))
+	}
+	d.Close()
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent sends with NOTIFY_WORKERS=2, got %d", got)
+	}
+	if got := received.Load(); got != 6*burst {
+		t.Errorf("Close should flush the queue: received %d of %d sends", got, 6*burst)
+	}
+}
+
+func TestDispatch_QueueFullDrops(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:     5,
+		NotifyEvents:    "startup",
+		WebhookURL:      srv.URL,
+		NotifyWorkers:   1,
+		NotifyQueueSize: 2,
+	})
+	dropped := metrics.NotificationsQueueFullTotal.WithLabelValues("webhook")
+	before := testutil.ToFloat64(dropped)
+
+	d.Startup("first") // picked up by the worker, which then blocks
+	deadline := time.Now().Add(time.Second)
+	for len(d.queues[0]) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	for i := range 5 {
+		d.Startup(fmt.Sprintf("queued %d", i))
+	}
+	close(release)
+	d.Close()
+
+	if got := testutil.ToFloat64(dropped) - before; got != 3 {
+		t.Errorf("expected 3 sends dropped with NOTIFY_QUEUE_SIZE=2, got %v", got)
+	}
+}
+
+func TestDedup_SuppressesIdenticalText(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	d := newTestDispatcher(&config.Config{
+		CurlTimeout:       5,
+		NotifyEvents:      "actions,skips",
+		WebhookURL:        srv.URL,
+		WebhookJSONKey:    "text",
+		NotifyDedupWindow: 60,
+	})
+
+	skip := "Container app (0123456789ab) skipped - grace period"
+	d.Skip(Event{Text: skip})
+	d.Skip(Event{Text: skip})
+	d.Action(Event{Text: "Container app (0123456789ab) found to be unhealthy. Successfully restarted the container!"})
+	d.Action(Event{Text: "Container app (0123456789ab) found to be unhealthy. Successfully restarted the container!"})
+	d.Skip(Event{Text: "Container app (0123456789ab) skipped - backup timeout"})
+	d.Close()
+
+	if got := hits.Load(); got != 3 {
+		t.Errorf("sent %d notifications, want 3 (each distinct text once)", got)
+	}
+}
+
+func TestDedup_AllowsRepeatAfterWindow(t *testing.T) {
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "skips", NotifyDedupWindow: 60})
+
+	text := "Container app (0123456789ab) skipped - grace period"
+	if d.isDuplicate(text) {
+		t.Fatal("first message should not be a duplicate")
+	}
+	if !d.isDuplicate(text) {
+		t.Error("identical message within the window should be suppressed")
+	}
+	for k := range d.dedup {
+		d.dedup[k] = time.Now().Add(-2 * time.Minute)
+	}
+	if d.isDuplicate(text) {
+		t.Error("identical message after the window should be sent again")
+	}
+}
+
+func TestDispatcher_ReloadSwapsEndpointsAndEvents(t *testing.T) {
+	var mu sync.Mutex
+	var hits []string
+	srv := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits = append(hits, name)
+			mu.Unlock()
+		}))
+	}
+	oldSrv, newSrv := srv("old"), srv("new")
+	defer oldSrv.Close()
+	defer newSrv.Close()
+
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: oldSrv.URL})
+	d.Reload(&config.Config{CurlTimeout: 5, NotifyEvents: "all", WebhookURL: newSrv.URL})
+	if !d.hasEvent("skips") {
+		t.Error("reloaded NOTIFY_EVENTS not applied")
+	}
+	d.Skip(Event{Container: "app", Text: "Container app skipped"})
+	d.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(hits) != 1 || hits[0] != "new" {
+		t.Errorf("hits = %v, want one send to the reloaded endpoint", hits)
+	}
+}
+
+// webhookCollector records the message text of each generic webhook post.
+func webhookCollector(t *testing.T) (*httptest.Server, chan string) {
+	t.Helper()
+	got := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		got <- payload["text"]
+	}))
+	t.Cleanup(server.Close)
+	return server, got
+}
+
+func TestBatch_ActionsWithinWindowSentAsOneSummary(t *testing.T) {
+	server, got := webhookCollector(t)
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: server.URL, WebhookJSONKey: "text", NotifyBatchWindow: 1})
+	for _, name := range []string{"a", "b", "c"} {
+		d.Action(Event{Text: "Container " + name + " found to be unhealthy. Successfully restarted the container!", Container: name, Action: "restart", Result: "success"})
+	}
+
+	select {
+	case text := <-got:
+		if !strings.HasPrefix(text, "3 containers restarted: a, b, c\n") || strings.Count(text, "Successfully restarted") != 3 {
+			t.Errorf("summary = %q", text)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("batch was not flushed after the window")
+	}
+	d.Close()
+	if len(got) != 0 {
+		t.Errorf("expected one dispatched message, got %d more", len(got))
+	}
+}
+
+func TestBatch_FlushesOnCloseAndCriticalBypasses(t *testing.T) {
+	server, got := webhookCollector(t)
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: server.URL, WebhookJSONKey: "text", NotifyBatchWindow: 3600})
+	d.Action(Event{Text: "Container a found to be unhealthy. Successfully restarted the container!", Container: "a", Action: "restart", Result: "success"})
+	d.Action(Event{Text: "Container b found to be unhealthy. Failed to restart the container!", Container: "b", Action: "restart", Result: "failure"})
+	d.Action(Event{Text: "[CRITICAL] Container c circuit breaker open", Container: "c"})
+
+	select {
+	case text := <-got:
+		if !strings.HasPrefix(text, "[CRITICAL]") {
+			t.Errorf("first message = %q, want the critical alert sent straight away", text)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("critical alert was held in the batch")
+	}
+
+	d.Close()
+	if len(got) != 1 {
+		t.Fatalf("expected the batch flushed as one message on Close, got %d", len(got))
+	}
+	if text := <-got; !strings.HasPrefix(text, "2 container actions: a, b\n") {
+		t.Errorf("summary = %q", text)
+	}
+}
+
+func TestRecovery_GatedOnRecoveryEvent(t *testing.T) {
+	server, got := webhookCollector(t)
+	evt := Event{Text: "Container app (abcdef123456) recovered (healthy again) - circuit closed", Container: "app"}
+
+	d := newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions", WebhookURL: server.URL, WebhookJSONKey: "text"})
+	d.Recovery(evt)
+	d.Close()
+	if len(got) != 0 {
+		t.Fatalf("recovery sent without the recovery event, got %q", <-got)
+	}
+
+	d = newTestDispatcher(&config.Config{CurlTimeout: 5, NotifyEvents: "actions,recovery", WebhookURL: server.URL, WebhookJSONKey: "text"})
+	d.Recovery(evt)
+	d.Close()
+	if len(got) != 1 || !strings.HasPrefix(<-got, evt.Text) {
+		t.Error("expected the recovery notification with the recovery event enabled")
+	}
+}
@@ -0,0 +1,62 @@
+//go:build !windows
+
+// Package privdrop switches the process to a non-root user once privileged
+// resources (the Docker socket, listening ports) have been acquired.
+package privdrop
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Syscall shims, replaced in tests.
+var (
+	setgroups = syscall.Setgroups
+	setgid    = syscall.Setgid
+	setuid    = syscall.Setuid
+	statGID   = socketGID
+)
+
+// Drop switches the process to uid/gid. When dockerSock is a unix socket, the
+// socket's owning group is added as a supplementary group so the Docker API
+// stays reachable after root is given up. Groups are set before the GID, and
+// the GID before the UID, since each step needs the privileges the next one
+// removes.
+func Drop(uid, gid int, dockerSock string) error {
+	groups := []int{gid}
+	if !strings.HasPrefix(dockerSock, "tcp://") && !strings.HasPrefix(dockerSock, "tcps://") {
+		sockGID, err := statGID(strings.TrimPrefix(dockerSock, "unix://"))
+		if err != nil {
+			return fmt.Errorf("stat docker socket: %w", err)
+		}
+		if sockGID != gid {
+			groups = append(groups, sockGID)
+		}
+	}
+
+	if err := setgroups(groups); err != nil {
+		return fmt.Errorf("setgroups %v: %w", groups, err)
+	}
+	if err := setgid(gid); err != nil {
+		return fmt.Errorf("setgid %d: %w", gid, err)
+	}
+	if err := setuid(uid); err != nil {
+		return fmt.Errorf("setuid %d: %w", uid, err)
+	}
+	return nil
+}
+
+// socketGID returns the group owning the file at path.
+func socketGID(path string) (int, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("no ownership information for %s", path)
+	}
+	return int(st.Gid), nil
+}
@@ -0,0 +1,76 @@
+//go:build !windows
+
+package privdrop
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeSyscalls records the credential changes Drop attempts.
+type fakeSyscalls struct {
+	calls  []string
+	groups []int
+	gid    int
+	uid    int
+}
+
+func install(t *testing.T, sockGID int, failOn string) *fakeSyscalls {
+	t.Helper()
+	f := &fakeSyscalls{gid: -1, uid: -1}
+	origGroups, origGID, origUID, origStat := setgroups, setgid, setuid, statGID
+	t.Cleanup(func() { setgroups, setgid, setuid, statGID = origGroups, origGID, origUID, origStat })
+
+	fail := func(name string) error {
+		f.calls = append(f.calls, name)
+		if name == failOn {
+			return errors.New("operation not permitted")
+		}
+		return nil
+	}
+	setgroups = func(g []int) error { f.groups = g; return fail("setgroups") }
+	setgid = func(gid int) error { f.gid = gid; return fail("setgid") }
+	setuid = func(uid int) error { f.uid = uid; return fail("setuid") }
+	statGID = func(string) (int, error) { return sockGID, nil }
+	return f
+}
+
+func TestDrop_SetsConfiguredIDs(t *testing.T) {
+	f := install(t, 998, "")
+
+	if err := Drop(1000, 1000, "/var/run/docker.sock"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"setgroups", "setgid", "setuid"}; !slices.Equal(f.calls, want) {
+		t.Errorf("calls = %v, want %v", f.calls, want)
+	}
+	if f.uid != 1000 || f.gid != 1000 {
+		t.Errorf("uid/gid = %d/%d, want 1000/1000", f.uid, f.gid)
+	}
+	if !slices.Equal(f.groups, []int{1000, 998}) {
+		t.Errorf("groups = %v, want [1000 998] (socket group joined)", f.groups)
+	}
+}
+
+func TestDrop_TCPSocketSkipsGroupLookup(t *testing.T) {
+	f := install(t, 998, "")
+
+	if err := Drop(1000, 1000, "tcp://docker-proxy:2375"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(f.groups, []int{1000}) {
+		t.Errorf("groups = %v, want [1000]", f.groups)
+	}
+}
+
+func TestDrop_StopsOnFailure(t *testing.T) {
+	f := install(t, 998, "setgid")
+
+	if err := Drop(1000, 1000, "/var/run/docker.sock"); err == nil {
+		t.Fatal("expected error when setgid fails")
+	}
+	if slices.Contains(f.calls, "setuid") {
+		t.Error("setuid must not be attempted after setgid fails")
+	}
+}
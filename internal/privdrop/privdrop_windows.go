@@ -0,0 +1,8 @@
+package privdrop
+
+import "errors"
+
+// Drop is not supported on Windows.
+func Drop(_, _ int, _ string) error {
+	return errors.New("RUN_AS_UID is not supported on windows")
+}
@@ -0,0 +1,42 @@
+// Package shutdown implements two-stage signal handling: the first signal
+// asks for a graceful drain, a second one forces an immediate exit.
+package shutdown
+
+import (
+	"context"
+	"os"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+)
+
+// Watch consumes signals from sigs. The first cancels the returned stop
+// context (stop accepting new work, let in-flight actions finish); the second
+// cancels work as well, which aborts in-flight actions. stop is derived from
+// work, so it is always done once work is. cancel releases the watcher and
+// cancels both.
+func Watch(parent context.Context, sigs <-chan os.Signal, log *logging.Logger) (stop, work context.Context, cancel context.CancelFunc) {
+	work, cancelWork := context.WithCancel(parent)
+	stop, cancelStop := context.WithCancel(work)
+
+	go func() {
+		select {
+		case sig := <-sigs:
+			log.Info("shutdown requested, finishing in-flight actions (signal again to force exit)",
+				"signal", sig.String(), "stage", "drain")
+			cancelStop()
+		case <-work.Done():
+			return
+		}
+		select {
+		case sig := <-sigs:
+			log.Warn("second signal received, forcing exit", "signal", sig.String(), "stage", "force")
+			cancelWork()
+		case <-work.Done():
+		}
+	}()
+
+	return stop, work, func() {
+		cancelStop()
+		cancelWork()
+	}
+}
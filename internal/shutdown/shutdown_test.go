@@ -0,0 +1,77 @@
+package shutdown
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Will-Luck/Docker-Guardian/internal/logging"
+)
+
+// syncBuffer is a bytes.Buffer safe for the watcher goroutine to log into.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func waitDone(t *testing.T, ctx context.Context, what string) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s context not cancelled", what)
+	}
+}
+
+func TestWatch_FirstSignalDrains(t *testing.T) {
+	var out syncBuffer
+	sigs := make(chan os.Signal, 2)
+	stop, work, cancel := Watch(context.Background(), sigs, logging.NewWriter(&out, true, slog.LevelDebug))
+	defer cancel()
+
+	sigs <- syscall.SIGTERM
+	waitDone(t, stop, "stop")
+
+	time.Sleep(20 * time.Millisecond)
+	if work.Err() != nil {
+		t.Error("one signal should leave in-flight work running")
+	}
+	if !strings.Contains(out.String(), `"stage":"drain"`) {
+		t.Errorf("expected the drain stage to be logged, got %s", out.String())
+	}
+}
+
+func TestWatch_SecondSignalForcesExit(t *testing.T) {
+	var out syncBuffer
+	sigs := make(chan os.Signal, 2)
+	stop, work, cancel := Watch(context.Background(), sigs, logging.NewWriter(&out, true, slog.LevelDebug))
+	defer cancel()
+
+	sigs <- syscall.SIGTERM
+	sigs <- syscall.SIGINT
+	waitDone(t, work, "work")
+	waitDone(t, stop, "stop")
+
+	logs := out.String()
+	if !strings.Contains(logs, `"stage":"drain"`) || !strings.Contains(logs, `"stage":"force"`) {
+		t.Errorf("expected both stages to be logged, got %s", logs)
+	}
+}